@@ -0,0 +1,55 @@
+// Copyright 2014 Gary Burd. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package json
+
+import (
+	"math"
+	"strconv"
+)
+
+// The Append functions append the JSON encoding of a value to dst and return
+// the extended buffer. Use them to build JSON in a caller owned buffer
+// without a Writer. The functions do not add separators between values.
+
+// AppendString appends the JSON string encoding of s to dst. The characters
+// <, > and & are escaped as in the Writer default.
+func AppendString(dst []byte, s string) []byte {
+	return appendString(dst, s, true)
+}
+
+// AppendKey appends the JSON encoding of the object member name followed by
+// ':' to dst.
+func AppendKey(dst []byte, name string) []byte {
+	return append(appendString(dst, name, true), ':')
+}
+
+// AppendInt appends the JSON encoding of i to dst.
+func AppendInt(dst []byte, i int64) []byte {
+	return strconv.AppendInt(dst, i, 10)
+}
+
+// AppendUint appends the JSON encoding of u to dst.
+func AppendUint(dst []byte, u uint64) []byte {
+	return strconv.AppendUint(dst, u, 10)
+}
+
+// AppendFloat appends the JSON encoding of f to dst. AppendFloat returns an
+// error and dst unchanged if f is infinite or NaN.
+func AppendFloat(dst []byte, f float64) ([]byte, error) {
+	if math.IsInf(f, 0) || math.IsNaN(f) {
+		return dst, errNonFinite
+	}
+	return strconv.AppendFloat(dst, f, 'g', -1, 64), nil
+}
+
+// AppendBool appends true or false to dst.
+func AppendBool(dst []byte, b bool) []byte {
+	return strconv.AppendBool(dst, b)
+}
+
+// AppendNull appends null to dst.
+func AppendNull(dst []byte) []byte {
+	return append(dst, "null"...)
+}
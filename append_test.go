@@ -0,0 +1,64 @@
+// Copyright 2014 Gary Burd. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package json
+
+import (
+	"bytes"
+	"math"
+	"testing"
+)
+
+func TestAppend(t *testing.T) {
+	var b []byte
+	b = append(b, '{')
+	b = AppendKey(b, "s")
+	b = AppendString(b, "a<\n\u2028")
+	b = append(b, ',')
+	b = AppendKey(b, "a")
+	b = append(b, '[')
+	b = AppendInt(b, -1)
+	b = append(b, ',')
+	b = AppendUint(b, 2)
+	b = append(b, ',')
+	b, err := AppendFloat(b, 1.5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b = append(b, ',')
+	b = AppendBool(b, true)
+	b = append(b, ',')
+	b = AppendNull(b)
+	b = append(b, "]}"...)
+
+	const expected = `{"s":"a\u003c\n\u2028","a":[-1,2,1.5,true,null]}`
+	if string(b) != expected {
+		t.Errorf("got %s, want %s", b, expected)
+	}
+}
+
+func TestAppendStringMatchesWriter(t *testing.T) {
+	for _, s := range []string{"", "hello", "\x00\x7f\b\f\r\t\"\\", "<>&", "\xff", " "} {
+		var buf bytes.Buffer
+		w := NewWriter(&buf)
+		w.String(s)
+		if got := AppendString(nil, s); string(got) != buf.String() {
+			t.Errorf("AppendString(%q) = %s, want %s", s, got, buf.String())
+		}
+	}
+}
+
+func TestAppendFloatInvalid(t *testing.T) {
+	b, err := AppendFloat([]byte("x"), math.NaN())
+	if err == nil || string(b) != "x" {
+		t.Errorf("AppendFloat(NaN) = %q, %v, want error", b, err)
+	}
+}
+
+func BenchmarkAppendString(b *testing.B) {
+	buf := make([]byte, 0, 64)
+	for i := 0; i < b.N; i++ {
+		buf = AppendString(buf[:0], "hello, world\n")
+	}
+}
@@ -0,0 +1,152 @@
+// Copyright 2014 Gary Burd. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package json
+
+import (
+	"errors"
+	"io"
+)
+
+// ArrayWriter writes a large array, for example the rows of a streaming HTTP
+// response. The writer flushes the output after a number of elements or
+// bytes to bound the memory used for buffering and to deliver the elements
+// promptly. If the output has a Flush method, such as http.Flusher, then the
+// method is called after the buffered data is written.
+//
+//  aw := json.NewArrayWriter(w)
+//  aw.SetFlush(100, 64*1024)
+//  for rows.Next() {
+//      if err := aw.Element(func(w *json.Writer) error { return writeRow(w, rows) }); err != nil {
+//          return err
+//      }
+//  }
+//  return aw.Close()
+type ArrayWriter struct {
+	w         *Writer
+	out       *arrayOutput
+	started   bool
+	n         int   // elements written
+	pending   int   // elements written since the last flush
+	flushed   int64 // bytes written at the last flush
+	maxElems  int
+	maxBytes  int
+	flushHook func()
+}
+
+// arrayOutput counts the bytes written to an ArrayWriter's output. The type
+// does not implement WriteByte and WriteString so that the Writer buffers
+// output.
+type arrayOutput struct {
+	w io.Writer
+	n int64
+}
+
+func (o *arrayOutput) Write(p []byte) (int, error) {
+	n, err := o.w.Write(p)
+	o.n += int64(n)
+	return n, err
+}
+
+// NewArrayWriter returns an array writer that writes to w. The writer flushes
+// the output after each element until SetFlush is called.
+func NewArrayWriter(w io.Writer) *ArrayWriter {
+	out := &arrayOutput{w: w}
+	a := &ArrayWriter{w: NewWriter(out), out: out}
+	if f, ok := w.(interface {
+		Flush()
+	}); ok {
+		a.flushHook = f.Flush
+	}
+	return a
+}
+
+// SetFlush sets the number of elements and the number of buffered bytes
+// that trigger a flush. The output is flushed when either limit is reached.
+// A limit less than or equal to zero is not checked. If both limits are not
+// checked, the output is flushed after each element.
+func (a *ArrayWriter) SetFlush(elements, bytes int) {
+	a.maxElems = elements
+	a.maxBytes = bytes
+}
+
+// Writer returns the underlying writer. Use the writer to set options such
+// as indentation before the first element is written.
+func (a *ArrayWriter) Writer() *Writer {
+	return a.w
+}
+
+// Element writes an array element with fn. The function must write exactly
+// one value.
+func (a *ArrayWriter) Element(fn func(w *Writer) error) error {
+	if err := a.start(); err != nil {
+		return err
+	}
+	if err := fn(a.w); err != nil {
+		return err
+	}
+	if a.w.depth != 1 {
+		return errors.New("array element not complete")
+	}
+	a.n++
+	a.pending++
+	if a.maxElems <= 0 && a.maxBytes <= 0 ||
+		a.maxElems > 0 && a.pending >= a.maxElems ||
+		a.maxBytes > 0 && a.BytesWritten()-a.flushed >= int64(a.maxBytes) {
+		return a.Flush()
+	}
+	return nil
+}
+
+func (a *ArrayWriter) start() error {
+	if a.started {
+		return nil
+	}
+	a.started = true
+	return a.w.StartArray()
+}
+
+// buffered returns the number of bytes buffered by the writer.
+func (a *ArrayWriter) buffered() int {
+	if a.w.bw == nil {
+		return 0
+	}
+	return a.w.bw.Buffered()
+}
+
+// Flush writes the buffered data to the output.
+func (a *ArrayWriter) Flush() error {
+	a.pending = 0
+	if err := a.w.Flush(); err != nil {
+		return err
+	}
+	a.flushed = a.out.n
+	if a.flushHook != nil {
+		a.flushHook()
+	}
+	return nil
+}
+
+// Close ends the array and flushes the output. Close writes an empty array
+// if no elements were written.
+func (a *ArrayWriter) Close() error {
+	if err := a.start(); err != nil {
+		return err
+	}
+	if err := a.w.EndArray(); err != nil {
+		return err
+	}
+	return a.Flush()
+}
+
+// Len returns the number of elements written.
+func (a *ArrayWriter) Len() int {
+	return a.n
+}
+
+// BytesWritten returns the number of bytes written, including bytes buffered
+// by the writer.
+func (a *ArrayWriter) BytesWritten() int64 {
+	return a.out.n + int64(a.buffered())
+}
@@ -0,0 +1,96 @@
+// Copyright 2014 Gary Burd. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package json
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// flushRecorder records the output at each call to Flush.
+type flushRecorder struct {
+	buf     bytes.Buffer
+	flushes []string
+}
+
+func (r *flushRecorder) Write(p []byte) (int, error) { return r.buf.Write(p) }
+func (r *flushRecorder) Flush()                      { r.flushes = append(r.flushes, r.buf.String()) }
+
+func TestArrayWriter(t *testing.T) {
+	tests := []struct {
+		elements, bytes int
+		flushes         []string
+	}{
+		{0, 0, []string{`[1`, `[1,2`, `[1,2,3`, `[1,2,3,4`, `[1,2,3,4]`}},
+		{3, 0, []string{`[1,2,3`, `[1,2,3,4]`}},
+		{0, 4, []string{`[1,2`, `[1,2,3,4`, `[1,2,3,4]`}},
+		{10, 100, []string{`[1,2,3,4]`}},
+	}
+	for _, tt := range tests {
+		var r flushRecorder
+		aw := NewArrayWriter(&r)
+		aw.SetFlush(tt.elements, tt.bytes)
+		for i := int64(1); i <= 4; i++ {
+			if err := aw.Element(func(w *Writer) error { return w.Int(i) }); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if aw.Len() != 4 {
+			t.Errorf("Len() = %d, want 4", aw.Len())
+		}
+		if n := aw.BytesWritten(); n != 8 {
+			t.Errorf("BytesWritten() = %d, want 8", n)
+		}
+		if err := aw.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if got, want := strings.Join(r.flushes, "|"), strings.Join(tt.flushes, "|"); got != want {
+			t.Errorf("SetFlush(%d, %d): got flushes %q, want %q", tt.elements, tt.bytes, got, want)
+		}
+	}
+}
+
+func TestArrayWriterLargeBytes(t *testing.T) {
+	// The limit is larger than the writer's buffer.
+	var r flushRecorder
+	aw := NewArrayWriter(&r)
+	aw.SetFlush(0, 5000)
+	s := strings.Repeat("a", 999)
+	for i := 0; i < 20; i++ {
+		if err := aw.Element(func(w *Writer) error { return w.String(s) }); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := aw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if len(r.flushes) != 5 {
+		t.Fatalf("got %d flushes, want 5", len(r.flushes))
+	}
+	for i, f := range r.flushes[:4] {
+		if n := len(f); n != 5010*(i+1) {
+			t.Errorf("flush %d: got %d bytes, want %d", i, n, 5010*(i+1))
+		}
+	}
+}
+
+func TestArrayWriterEmpty(t *testing.T) {
+	var buf bytes.Buffer
+	aw := NewArrayWriter(&buf)
+	if err := aw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != "[]" {
+		t.Errorf("got %q, want []", buf.String())
+	}
+}
+
+func TestArrayWriterIncomplete(t *testing.T) {
+	aw := NewArrayWriter(&bytes.Buffer{})
+	if err := aw.Element(func(w *Writer) error { return w.StartObject() }); err == nil {
+		t.Error("Element returned nil for incomplete element")
+	}
+}
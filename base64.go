@@ -0,0 +1,68 @@
+// Copyright 2014 Gary Burd. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package json
+
+import (
+	"encoding/base64"
+	"io"
+)
+
+// Bytes writes p as a base64 encoded string using the standard encoding
+// with padding. This is the encoding used by encoding/json for []byte.
+func (w *Writer) Bytes(p []byte) error {
+	if err := w.value(); err != nil {
+		return err
+	}
+	w.sw.WriteByte('"')
+	enc := base64.NewEncoder(base64.StdEncoding, w.sw)
+	enc.Write(p)
+	enc.Close()
+	return w.end(w.sw.WriteByte('"'))
+}
+
+// BytesWriter starts a base64 encoded string value and returns a writer for
+// the bytes to encode. Close the returned writer to end the string. See
+// StringWriter for restrictions on the Writer while the string is open.
+func (w *Writer) BytesWriter() io.WriteCloser {
+	sw := w.StringWriter()
+	return &base64Writer{enc: base64.NewEncoder(base64.StdEncoding, sw), sw: sw}
+}
+
+type base64Writer struct {
+	enc io.WriteCloser
+	sw  io.WriteCloser
+}
+
+func (bw *base64Writer) Write(p []byte) (int, error) {
+	return bw.enc.Write(p)
+}
+
+func (bw *base64Writer) Close() error {
+	err := bw.enc.Close()
+	if e := bw.sw.Close(); err == nil {
+		err = e
+	}
+	return err
+}
+
+// BytesValue decodes the current string value from base64 using the
+// standard encoding with padding.
+func (s *Scanner) BytesValue() ([]byte, error) {
+	if s.kind != String {
+		return nil, &UnmarshalTypeError{s.kind.String(), bytesType}
+	}
+	p := s.Value()
+	b := make([]byte, base64.StdEncoding.DecodedLen(len(p)))
+	n, err := base64.StdEncoding.Decode(b, p)
+	return b[:n], err
+}
+
+// BytesReader returns a reader that decodes the current string value from
+// base64. Use BytesReader with StreamStrings to decode large values without
+// buffering the entire value. The reader is valid until the next call to
+// Scan.
+func (s *Scanner) BytesReader() io.Reader {
+	return base64.NewDecoder(base64.StdEncoding, s.StringReader())
+}
@@ -0,0 +1,75 @@
+// Copyright 2014 Gary Burd. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package json
+
+import (
+	"bytes"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+var base64Tests = []struct {
+	p []byte
+	s string
+}{
+	{nil, `""`},
+	{[]byte("h"), `"aA=="`},
+	{[]byte("hello"), `"aGVsbG8="`},
+	{[]byte{0xfb, 0xff, 0xbf}, `"+/+/"`},
+	{bytes.Repeat([]byte("012345678901234567890123456789"), 25), `"` + strings.Repeat("MDEyMzQ1Njc4OTAxMjM0NTY3ODkwMTIzNDU2Nzg5", 25) + `"`},
+}
+
+func TestWriteBytes(t *testing.T) {
+	for _, tt := range base64Tests {
+		var buf bytes.Buffer
+		w := NewWriter(writerOnly{&buf})
+		w.StartArray()
+		w.Bytes(tt.p)
+		bw := w.BytesWriter()
+		for _, b := range tt.p {
+			bw.Write([]byte{b})
+		}
+		if err := bw.Close(); err != nil {
+			t.Fatal(err)
+		}
+		w.EndArray()
+		want := "[" + tt.s + "," + tt.s + "]"
+		if got := buf.String(); got != want {
+			t.Errorf("got %s, want %s", got, want)
+		}
+	}
+}
+
+func TestBytesValue(t *testing.T) {
+	for _, tt := range base64Tests {
+		s := NewScannerBytes([]byte(tt.s))
+		s.Scan()
+		p, err := s.BytesValue()
+		if err != nil || !bytes.Equal(p, tt.p) {
+			t.Errorf("%s: got %q, %v, want %q", tt.s, p, err, tt.p)
+		}
+
+		s = NewScannerSize(iotest.OneByteReader(strings.NewReader(tt.s)), 16)
+		s.StreamStrings(true)
+		s.Scan()
+		p, err = ioutil.ReadAll(s.BytesReader())
+		if err != nil || !bytes.Equal(p, tt.p) {
+			t.Errorf("%s: reader got %q, %v, want %q", tt.s, p, err, tt.p)
+		}
+	}
+
+	s := NewScannerBytes([]byte(`"a!=="`))
+	s.Scan()
+	if _, err := s.BytesValue(); err == nil {
+		t.Error("invalid base64 returned nil error")
+	}
+	s = NewScannerBytes([]byte(`1`))
+	s.Scan()
+	if _, err := s.BytesValue(); err == nil {
+		t.Error("number returned nil error")
+	}
+}
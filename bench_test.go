@@ -12,6 +12,8 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"testing"
 
 	"github.com/garyburd/json"
@@ -57,6 +59,93 @@ func BenchmarkScanOnly(b *testing.B) {
 	b.SetBytes(int64(len(codeJSON)))
 }
 
+func BenchmarkScanBytesOnly(b *testing.B) {
+	b.StopTimer()
+	if codeJSON == nil {
+		codeInit()
+	}
+	b.StartTimer()
+	for i := 0; i < b.N; i++ {
+		s := json.NewScannerBytes(codeJSON)
+		for s.Scan() {
+		}
+		if s.Err() != nil {
+			b.Fatal(s.Err())
+		}
+	}
+	b.SetBytes(int64(len(codeJSON)))
+}
+
+// treeJSON and treeIndentJSON are generated documents with the shape of
+// code.json. recordsJSON is an array of small records. stringsJSON is an
+// array of long strings. The documents do not depend on the test data in the
+// Go distribution.
+var treeJSON, treeIndentJSON, recordsJSON, stringsJSON []byte
+
+func generatedInit() {
+	var tree func(depth, i int) interface{}
+	tree = func(depth, i int) interface{} {
+		kids := []interface{}{}
+		if depth > 0 {
+			for k := 0; k < 5; k++ {
+				kids = append(kids, tree(depth-1, i*5+k))
+			}
+		}
+		return map[string]interface{}{
+			"name":      "pkg" + strconv.Itoa(i%100),
+			"kids":      kids,
+			"cl_weight": float64(i%1000) / 997,
+			"touches":   i % 17,
+			"min_t":     1238100000 + i,
+			"max_t":     1238200000 + i,
+			"mean_t":    1238150000 + i,
+		}
+	}
+	v := map[string]interface{}{"tree": tree(6, 1), "username": "rsc"}
+	treeJSON, _ = sjson.Marshal(v)
+	treeIndentJSON, _ = sjson.MarshalIndent(v, "", "\t")
+
+	var records []interface{}
+	for i := 0; i < 30000; i++ {
+		records = append(records, map[string]interface{}{
+			"id":   i,
+			"name": "user" + strconv.Itoa(i),
+			"ok":   true,
+			"v":    float64(i) + 0.5,
+			"tags": []string{"a", "b"},
+		})
+	}
+	recordsJSON, _ = sjson.Marshal(records)
+
+	var a []string
+	for i := 0; i < 20000; i++ {
+		a = append(a, strings.Repeat("lorem ipsum dolor sit amet ", i%12)+strconv.Itoa(i))
+	}
+	stringsJSON, _ = sjson.Marshal(a)
+}
+
+func benchmarkScan(b *testing.B, data *[]byte) {
+	b.StopTimer()
+	if *data == nil {
+		generatedInit()
+	}
+	b.StartTimer()
+	for i := 0; i < b.N; i++ {
+		s := json.NewScannerBytes(*data)
+		for s.Scan() {
+		}
+		if s.Err() != nil {
+			b.Fatal(s.Err())
+		}
+	}
+	b.SetBytes(int64(len(*data)))
+}
+
+func BenchmarkScanTree(b *testing.B)       { benchmarkScan(b, &treeJSON) }
+func BenchmarkScanTreeIndent(b *testing.B) { benchmarkScan(b, &treeIndentJSON) }
+func BenchmarkScanRecords(b *testing.B)    { benchmarkScan(b, &recordsJSON) }
+func BenchmarkScanStrings(b *testing.B)    { benchmarkScan(b, &stringsJSON) }
+
 func BenchmarkScanToMap(b *testing.B) {
 	b.StopTimer()
 	if codeJSON == nil {
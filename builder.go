@@ -0,0 +1,235 @@
+// Copyright 2014 Gary Burd. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package json
+
+// Object writes an object. The function fn adds the members of the object
+// using the methods on Obj. Object returns the first error encountered while
+// writing the object.
+//
+//  err := w.Object(func(o *json.Obj) {
+//      o.Str("name", "gopher").Int("age", 5)
+//      o.Arr("tags", func(a *json.Arr) {
+//          a.Str("blue").Str("small")
+//      })
+//  })
+func (w *Writer) Object(fn func(o *Obj)) error {
+	o := &Obj{w: w}
+	o.set(w.StartObject())
+	if o.err == nil {
+		fn(o)
+	}
+	o.set(w.EndObject())
+	return o.err
+}
+
+// Array writes an array. The function fn adds the elements of the array
+// using the methods on Arr. Array returns the first error encountered while
+// writing the array.
+func (w *Writer) Array(fn func(a *Arr)) error {
+	a := &Arr{w: w}
+	a.set(w.StartArray())
+	if a.err == nil {
+		fn(a)
+	}
+	a.set(w.EndArray())
+	return a.err
+}
+
+// Obj adds members to an object written by Writer.Object. The methods are
+// no-ops after an error.
+type Obj struct {
+	w   *Writer
+	err error
+}
+
+func (o *Obj) set(err error) {
+	if o.err == nil {
+		o.err = err
+	}
+}
+
+// name writes a member name and returns true if the value should be written.
+func (o *Obj) name(name string) bool {
+	if o.err == nil {
+		o.set(o.w.Name(name))
+	}
+	return o.err == nil
+}
+
+// Str adds a string member.
+func (o *Obj) Str(name, v string) *Obj {
+	if o.name(name) {
+		o.set(o.w.String(v))
+	}
+	return o
+}
+
+// Int adds an integer member.
+func (o *Obj) Int(name string, v int64) *Obj {
+	if o.name(name) {
+		o.set(o.w.Int(v))
+	}
+	return o
+}
+
+// Uint adds an unsigned integer member.
+func (o *Obj) Uint(name string, v uint64) *Obj {
+	if o.name(name) {
+		o.set(o.w.Uint(v))
+	}
+	return o
+}
+
+// Float adds a floating point member.
+func (o *Obj) Float(name string, v float64) *Obj {
+	if o.name(name) {
+		o.set(o.w.Float(v))
+	}
+	return o
+}
+
+// Bool adds a boolean member.
+func (o *Obj) Bool(name string, v bool) *Obj {
+	if o.name(name) {
+		o.set(o.w.Bool(v))
+	}
+	return o
+}
+
+// Null adds a null member.
+func (o *Obj) Null(name string) *Obj {
+	if o.name(name) {
+		o.set(o.w.Null())
+	}
+	return o
+}
+
+// Raw adds a member with the encoded JSON value p.
+func (o *Obj) Raw(name string, p []byte) *Obj {
+	if o.name(name) {
+		o.set(o.w.Raw(p))
+	}
+	return o
+}
+
+// Value adds a member encoded by v.
+func (o *Obj) Value(name string, v Marshaler) *Obj {
+	if o.name(name) {
+		o.set(v.EncodeJSON(o.w))
+	}
+	return o
+}
+
+// Obj adds an object member. The function fn adds the members of the nested
+// object.
+func (o *Obj) Obj(name string, fn func(o *Obj)) *Obj {
+	if o.name(name) {
+		o.set(o.w.Object(fn))
+	}
+	return o
+}
+
+// Arr adds an array member. The function fn adds the elements of the array.
+func (o *Obj) Arr(name string, fn func(a *Arr)) *Obj {
+	if o.name(name) {
+		o.set(o.w.Array(fn))
+	}
+	return o
+}
+
+// Arr adds elements to an array written by Writer.Array. The methods are
+// no-ops after an error.
+type Arr struct {
+	w   *Writer
+	err error
+}
+
+func (a *Arr) set(err error) {
+	if a.err == nil {
+		a.err = err
+	}
+}
+
+// Str adds a string element.
+func (a *Arr) Str(v string) *Arr {
+	if a.err == nil {
+		a.set(a.w.String(v))
+	}
+	return a
+}
+
+// Int adds an integer element.
+func (a *Arr) Int(v int64) *Arr {
+	if a.err == nil {
+		a.set(a.w.Int(v))
+	}
+	return a
+}
+
+// Uint adds an unsigned integer element.
+func (a *Arr) Uint(v uint64) *Arr {
+	if a.err == nil {
+		a.set(a.w.Uint(v))
+	}
+	return a
+}
+
+// Float adds a floating point element.
+func (a *Arr) Float(v float64) *Arr {
+	if a.err == nil {
+		a.set(a.w.Float(v))
+	}
+	return a
+}
+
+// Bool adds a boolean element.
+func (a *Arr) Bool(v bool) *Arr {
+	if a.err == nil {
+		a.set(a.w.Bool(v))
+	}
+	return a
+}
+
+// Null adds a null element.
+func (a *Arr) Null() *Arr {
+	if a.err == nil {
+		a.set(a.w.Null())
+	}
+	return a
+}
+
+// Raw adds the encoded JSON value p.
+func (a *Arr) Raw(p []byte) *Arr {
+	if a.err == nil {
+		a.set(a.w.Raw(p))
+	}
+	return a
+}
+
+// Value adds an element encoded by v.
+func (a *Arr) Value(v Marshaler) *Arr {
+	if a.err == nil {
+		a.set(v.EncodeJSON(a.w))
+	}
+	return a
+}
+
+// Obj adds an object element. The function fn adds the members of the
+// object.
+func (a *Arr) Obj(fn func(o *Obj)) *Arr {
+	if a.err == nil {
+		a.set(a.w.Object(fn))
+	}
+	return a
+}
+
+// Arr adds an array element. The function fn adds the elements of the
+// nested array.
+func (a *Arr) Arr(fn func(a *Arr)) *Arr {
+	if a.err == nil {
+		a.set(a.w.Array(fn))
+	}
+	return a
+}
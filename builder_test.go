@@ -0,0 +1,55 @@
+// Copyright 2014 Gary Burd. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package json
+
+import (
+	"bytes"
+	"math"
+	"testing"
+)
+
+type rawMarshaler string
+
+func (m rawMarshaler) EncodeJSON(w *Writer) error {
+	return w.RawString(string(m))
+}
+
+func TestBuilder(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	err := w.Object(func(o *Obj) {
+		o.Str("s", "x").Int("i", -1).Uint("u", 2).Float("f", 1.5).Bool("b", true).Null("n")
+		o.Raw("r", []byte(`{}`)).Value("v", rawMarshaler(`[0]`))
+		o.Obj("o", func(o *Obj) {
+			o.Str("a", "b")
+		})
+		o.Arr("a", func(a *Arr) {
+			a.Str("x").Int(-1).Uint(2).Float(1.5).Bool(false).Null().Raw([]byte(`1`)).Value(rawMarshaler(`2`))
+			a.Obj(func(o *Obj) {})
+			a.Arr(func(a *Arr) { a.Int(3) })
+		})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	const want = `{"s":"x","i":-1,"u":2,"f":1.5,"b":true,"n":null,"r":{},"v":[0],"o":{"a":"b"},"a":["x",-1,2,1.5,false,null,1,2,{},[3]]}`
+	if buf.String() != want {
+		t.Errorf("got  %s\nwant %s", buf.String(), want)
+	}
+}
+
+func TestBuilderError(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	err := w.Array(func(a *Arr) {
+		a.Int(1).Float(math.NaN()).Int(2)
+	})
+	if err == nil {
+		t.Error("expected error")
+	}
+	if buf.String() != `[1]` {
+		t.Errorf("got %s, want [1]", buf.String())
+	}
+}
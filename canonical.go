@@ -0,0 +1,219 @@
+// Copyright 2014 Gary Burd. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package json
+
+import (
+	"errors"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"unicode/utf8"
+)
+
+// Canonicalize reads a JSON document from src and writes the document to dst
+// in the RFC 8785 JSON Canonicalization Scheme. Object members are sorted by
+// the UTF-16 code units of their names, numbers are written in the shortest
+// form that round trips through an IEEE 754 double and strings are written
+// with minimal escaping. Canonicalize returns an error if the document has
+// duplicate member names or a number that is out of range for a double.
+func Canonicalize(dst io.Writer, src io.Reader) error {
+	s := NewScanner(src)
+	s.RejectDuplicateKeys(true)
+	if !s.Scan() {
+		if err := s.Err(); err != nil {
+			return err
+		}
+		return io.ErrUnexpectedEOF
+	}
+	v, err := DecodeValueWith(s, DecodeOptions{Number: UseFloat64})
+	if err != nil {
+		return err
+	}
+	if s.Scan() {
+		return errors.New("unexpected data after top-level value")
+	}
+	if err := s.Err(); err != nil {
+		return err
+	}
+	p, err := appendCanonical(nil, v)
+	if err != nil {
+		return err
+	}
+	_, err = dst.Write(p)
+	return err
+}
+
+func appendCanonical(dst []byte, v interface{}) ([]byte, error) {
+	switch v := v.(type) {
+	case nil:
+		return append(dst, "null"...), nil
+	case bool:
+		return strconv.AppendBool(dst, v), nil
+	case string:
+		return appendCanonicalString(dst, v), nil
+	case float64:
+		return appendCanonicalNumber(dst, v)
+	case []interface{}:
+		dst = append(dst, '[')
+		for i, e := range v {
+			if i > 0 {
+				dst = append(dst, ',')
+			}
+			var err error
+			dst, err = appendCanonical(dst, e)
+			if err != nil {
+				return dst, err
+			}
+		}
+		return append(dst, ']'), nil
+	case map[string]interface{}:
+		names := make([]string, 0, len(v))
+		for name := range v {
+			names = append(names, name)
+		}
+		sort.Sort(byUTF16(names))
+		dst = append(dst, '{')
+		for i, name := range names {
+			if i > 0 {
+				dst = append(dst, ',')
+			}
+			dst = appendCanonicalString(dst, name)
+			dst = append(dst, ':')
+			var err error
+			dst, err = appendCanonical(dst, v[name])
+			if err != nil {
+				return dst, err
+			}
+		}
+		return append(dst, '}'), nil
+	default:
+		return dst, errors.New("unexpected value")
+	}
+}
+
+// appendCanonicalNumber appends f formatted as ECMAScript
+// Number.prototype.toString does.
+func appendCanonicalNumber(dst []byte, f float64) ([]byte, error) {
+	if math.IsInf(f, 0) || math.IsNaN(f) {
+		return dst, errors.New("unsupported value (inf, nan)")
+	}
+	if f == 0 {
+		return append(dst, '0'), nil
+	}
+	if f < 0 {
+		dst = append(dst, '-')
+		f = -f
+	}
+
+	// Split the shortest decimal representation d.ddde±x into digits and
+	// exponent.
+	var scratch, buf [32]byte
+	e := strconv.AppendFloat(scratch[:0], f, 'e', -1, 64)
+	i := 0
+	for e[i] != 'e' {
+		i++
+	}
+	x, _ := strconv.Atoi(string(e[i+1:]))
+	digits := append(buf[:0], e[0])
+	if i > 1 {
+		digits = append(digits, e[2:i]...)
+	}
+
+	k := len(digits)
+	n := x + 1 // position of the decimal point relative to the digits
+	switch {
+	case k <= n && n <= 21:
+		dst = append(dst, digits...)
+		for ; k < n; k++ {
+			dst = append(dst, '0')
+		}
+	case 0 < n && n <= 21:
+		dst = append(dst, digits[:n]...)
+		dst = append(dst, '.')
+		dst = append(dst, digits[n:]...)
+	case -6 < n && n <= 0:
+		dst = append(dst, "0."...)
+		for ; n < 0; n++ {
+			dst = append(dst, '0')
+		}
+		dst = append(dst, digits...)
+	default:
+		dst = append(dst, digits[0])
+		if k > 1 {
+			dst = append(dst, '.')
+			dst = append(dst, digits[1:]...)
+		}
+		dst = append(dst, 'e')
+		if x >= 0 {
+			dst = append(dst, '+')
+		}
+		dst = strconv.AppendInt(dst, int64(x), 10)
+	}
+	return dst, nil
+}
+
+// appendCanonicalString appends s as a JSON string with the minimal escaping
+// required by RFC 8785.
+func appendCanonicalString(dst []byte, s string) []byte {
+	dst = append(dst, '"')
+	start := 0
+	for i := 0; i < len(s); i++ {
+		b := s[i]
+		if b >= 0x20 && b != '\\' && b != '"' {
+			continue
+		}
+		dst = append(dst, s[start:i]...)
+		switch b {
+		case '\\', '"':
+			dst = append(dst, '\\', b)
+		case '\n':
+			dst = append(dst, '\\', 'n')
+		case '\r':
+			dst = append(dst, '\\', 'r')
+		case '\t':
+			dst = append(dst, '\\', 't')
+		case '\b':
+			dst = append(dst, '\\', 'b')
+		case '\f':
+			dst = append(dst, '\\', 'f')
+		default:
+			dst = append(dst, '\\', 'u', '0', '0', hex[b>>4], hex[b&0xF])
+		}
+		start = i + 1
+	}
+	dst = append(dst, s[start:]...)
+	return append(dst, '"')
+}
+
+// byUTF16 sorts strings by their UTF-16 code units.
+type byUTF16 []string
+
+func (p byUTF16) Len() int      { return len(p) }
+func (p byUTF16) Swap(i, j int) { p[i], p[j] = p[j], p[i] }
+
+func (p byUTF16) Less(i, j int) bool {
+	a, b := p[i], p[j]
+	for a != "" && b != "" {
+		ra, na := utf8.DecodeRuneInString(a)
+		rb, nb := utf8.DecodeRuneInString(b)
+		if ra != rb {
+			if ua, ub := utf16Unit(ra), utf16Unit(rb); ua != ub {
+				return ua < ub
+			}
+			return ra < rb
+		}
+		a, b = a[na:], b[nb:]
+	}
+	return len(a) < len(b)
+}
+
+// utf16Unit returns the first UTF-16 code unit of r.
+func utf16Unit(r rune) rune {
+	if r >= 0x10000 {
+		return 0xd800 + (r-0x10000)>>10
+	}
+	return r
+}
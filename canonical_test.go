@@ -0,0 +1,45 @@
+// Copyright 2014 Gary Burd. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package json
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+var canonicalizeTests = []struct {
+	in, out string
+}{
+	{` { "b" : 1 , "a" : [ true , false , null ] } `, `{"a":[true,false,null],"b":1}`},
+	{`"\u20ac$\u000F\u000aA'\u0042\u0022\u005c\\\"\/<>\u2028"`, "\"€$\\u000f\\nA'B\\\"\\\\\\\\\\\"/<>\u2028\""},
+	{`{"\u20ac":1,"\r":2,"\ud83d\ude00":3,"1":4,"\u0080":5,"\u00f6":6,"\ufb33":7}`, "{\"\\r\":2,\"1\":4,\"\u0080\":5,\"\u00f6\":6,\"\u20ac\":1,\"\U0001f600\":3,\"\ufb33\":7}"},
+	{`[0,-0,1.0,100,1e21,1e20,1E-7,0.000001,-1.5e-10,123456789012345680000,4.50,2e-3,0.000000000000000000000000001]`,
+		`[0,0,1,100,1e+21,100000000000000000000,1e-7,0.000001,-1.5e-10,123456789012345680000,4.5,0.002,1e-27]`},
+	{`[333333333.33333329,1E30,4.5,2e-3,0.000000000000000000000000001]`, `[333333333.3333333,1e+30,4.5,0.002,1e-27]`},
+	{`[-5e-324,1.7976931348623157e308,9007199254740992]`, `[-5e-324,1.7976931348623157e+308,9007199254740992]`},
+}
+
+func TestCanonicalize(t *testing.T) {
+	for _, tt := range canonicalizeTests {
+		var buf bytes.Buffer
+		if err := Canonicalize(&buf, strings.NewReader(tt.in)); err != nil {
+			t.Errorf("%s: %v", tt.in, err)
+			continue
+		}
+		if buf.String() != tt.out {
+			t.Errorf("%s:\n got %s\nwant %s", tt.in, buf.String(), tt.out)
+		}
+	}
+}
+
+func TestCanonicalizeError(t *testing.T) {
+	for _, in := range []string{`{"a":1,"a":2}`, `1e400`, `[1`, `1 2`, ``} {
+		var buf bytes.Buffer
+		if err := Canonicalize(&buf, strings.NewReader(in)); err == nil {
+			t.Errorf("%s: got %s, want error", in, buf.String())
+		}
+	}
+}
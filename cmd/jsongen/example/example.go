@@ -0,0 +1,33 @@
+// Copyright 2014 Gary Burd. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package example contains types with methods generated by jsongen.
+package example
+
+//go:generate go run github.com/garyburd/json/cmd/jsongen example.go
+
+type Color int
+
+type Tags []string
+
+type Point struct {
+	X, Y float64
+}
+
+type Shape struct {
+	Name     string            `json:"name"`
+	Color    Color             `json:"color,omitempty"`
+	Visible  bool              `json:"visible"`
+	Count    int8              `json:"count"`
+	Size     uint32            `json:"size,omitempty"`
+	Center   Point             `json:"center"`
+	Anchor   *Point            `json:"anchor"`
+	Points   []Point           `json:"points"`
+	Tags     Tags              `json:"tags,omitempty"`
+	Labels   map[string]string `json:"labels"`
+	Weights  map[string]*int64 `json:"weights,omitempty"`
+	Note     *string           `json:"note,omitempty"`
+	Internal string            `json:"-"`
+	private  int
+}
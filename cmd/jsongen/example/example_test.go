@@ -0,0 +1,82 @@
+// Copyright 2014 Gary Burd. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package example
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/garyburd/json"
+)
+
+func TestRoundTrip(t *testing.T) {
+	weight := int64(3)
+	note := "hello"
+	v := Shape{
+		Name:    "square",
+		Color:   2,
+		Visible: true,
+		Count:   -4,
+		Size:    10,
+		Center:  Point{1.5, 2},
+		Anchor:  &Point{0, 1},
+		Points:  []Point{{0, 0}, {1, 1}},
+		Tags:    Tags{"a", "b"},
+		Labels:  map[string]string{"b": "2", "a": "1"},
+		Weights: map[string]*int64{"x": &weight, "y": nil},
+		Note:    &note,
+	}
+	const want = `{"name":"square","color":2,"visible":true,"count":-4,"size":10,"center":{"X":1.5,"Y":2},"anchor":{"X":0,"Y":1},"points":[{"X":0,"Y":0},{"X":1,"Y":1}],"tags":["a","b"],"labels":{"a":"1","b":"2"},"weights":{"x":3,"y":null},"note":"hello"}`
+
+	var buf bytes.Buffer
+	if err := v.EncodeJSON(json.NewWriter(&buf)); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != want {
+		t.Fatalf("got  %s\nwant %s", buf.String(), want)
+	}
+
+	var got Shape
+	s := json.NewScannerBytes(buf.Bytes())
+	s.Scan()
+	if err := got.DecodeJSON(s); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, v) {
+		t.Errorf("got %+v, want %+v", got, v)
+	}
+}
+
+func TestOmitEmpty(t *testing.T) {
+	const want = `{"name":"","visible":false,"count":0,"center":{"X":0,"Y":0},"anchor":null,"points":null,"labels":null}`
+	var buf bytes.Buffer
+	Shape{}.EncodeJSON(json.NewWriter(&buf))
+	if buf.String() != want {
+		t.Errorf("got  %s\nwant %s", buf.String(), want)
+	}
+}
+
+var decodeErrorTests = []string{
+	`[]`,
+	`{"name": 1}`,
+	`{"count": 128}`,
+	`{"size": -1}`,
+	`{"points": {}}`,
+	`{"labels": [1]}`,
+	`{"center": {"X": "1"}}`,
+	`{"name": "x",`,
+}
+
+func TestDecodeError(t *testing.T) {
+	for _, in := range decodeErrorTests {
+		var v Shape
+		s := json.NewScannerBytes([]byte(in))
+		s.Scan()
+		if err := v.DecodeJSON(s); err == nil {
+			t.Errorf("%s: expected error", in)
+		}
+	}
+}
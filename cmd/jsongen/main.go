@@ -0,0 +1,538 @@
+// Copyright 2014 Gary Burd. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Command jsongen generates JSON encoding and decoding methods for struct
+// types. The generated methods use the Scanner and Writer from the
+// github.com/garyburd/json package and do not use reflection.
+//
+// Usage:
+//
+//	jsongen [-type T1,T2] [-o output] file.go
+//
+// For each struct type declared in file.go, or for each type listed with the
+// -type flag, jsongen generates the methods
+//
+//	func (v T) EncodeJSON(w *json.Writer) error
+//	func (v *T) DecodeJSON(s *json.Scanner) error
+//
+// The methods are written to file_json.go unless the -o flag is specified.
+//
+// Struct fields are named using the "json" field tag as in the encoding/json
+// package. The tag options "omitempty" and the name "-" are supported.
+// Supported field types are strings, booleans, integers, floating point
+// numbers, pointers, slices, maps with string keys and types that have
+// EncodeJSON and DecodeJSON methods, including types generated by jsongen.
+// Embedded fields are not supported.
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"log"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+var (
+	typeNames = flag.String("type", "", "comma-separated list of type names; default is all struct types")
+	output    = flag.String("o", "", "output file name; default is <file>_json.go")
+)
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "usage: jsongen [-type T1,T2] [-o output] file.go\n")
+	flag.PrintDefaults()
+	os.Exit(2)
+}
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("jsongen: ")
+	flag.Usage = usage
+	flag.Parse()
+	if flag.NArg() != 1 {
+		usage()
+	}
+	filename := flag.Arg(0)
+	src, err := ioutil.ReadFile(filename)
+	if err != nil {
+		log.Fatal(err)
+	}
+	var names []string
+	if *typeNames != "" {
+		names = strings.Split(*typeNames, ",")
+	}
+	p, err := generate(filename, src, names)
+	if err != nil {
+		log.Fatal(err)
+	}
+	out := *output
+	if out == "" {
+		out = strings.TrimSuffix(filename, ".go") + "_json.go"
+	}
+	if err := ioutil.WriteFile(out, p, 0666); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// generate returns the formatted source of the methods for the named struct
+// types in src or all struct types in src if names is empty.
+func generate(filename string, src []byte, names []string) ([]byte, error) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, filename, src, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	g := &generator{types: make(map[string]ast.Expr)}
+	var all []string
+	for _, decl := range f.Decls {
+		decl, ok := decl.(*ast.GenDecl)
+		if !ok || decl.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range decl.Specs {
+			spec := spec.(*ast.TypeSpec)
+			g.types[spec.Name.Name] = spec.Type
+			if _, ok := spec.Type.(*ast.StructType); ok {
+				all = append(all, spec.Name.Name)
+			}
+		}
+	}
+	if len(names) == 0 {
+		names = all
+	}
+	if len(names) == 0 {
+		return nil, errors.New("no struct types in " + filename)
+	}
+
+	for _, name := range names {
+		st, ok := g.types[name].(*ast.StructType)
+		if !ok {
+			return nil, fmt.Errorf("%s is not a struct type in %s", name, filename)
+		}
+		if err := g.genType(name, st); err != nil {
+			return nil, err
+		}
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated by jsongen. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&buf, "package %s\n\n", f.Name.Name)
+	fmt.Fprintf(&buf, "import (\n\"fmt\"\n")
+	if g.sort {
+		fmt.Fprintf(&buf, "\"sort\"\n")
+	}
+	fmt.Fprintf(&buf, "\n\"github.com/garyburd/json\"\n)\n")
+	buf.Write(g.buf.Bytes())
+	return format.Source(buf.Bytes())
+}
+
+type generator struct {
+	buf   bytes.Buffer
+	types map[string]ast.Expr // types declared in the file
+	sort  bool                // if true, the generated code uses package sort
+	n     int                 // counter for temporary variable names
+}
+
+func (g *generator) p(format string, args ...interface{}) {
+	fmt.Fprintf(&g.buf, format, args...)
+	g.buf.WriteByte('\n')
+}
+
+// tmp returns a unique temporary variable name.
+func (g *generator) tmp(prefix string) string {
+	g.n++
+	return prefix + strconv.Itoa(g.n)
+}
+
+type field struct {
+	name      string // Go field name
+	key       string // JSON member name
+	omitEmpty bool
+	typ       ast.Expr
+}
+
+func (g *generator) genType(name string, st *ast.StructType) error {
+	var fields []field
+	for _, f := range st.Fields.List {
+		if len(f.Names) == 0 {
+			return fmt.Errorf("%s: embedded field %s not supported", name, exprString(f.Type))
+		}
+		var tag string
+		if f.Tag != nil {
+			s, err := strconv.Unquote(f.Tag.Value)
+			if err != nil {
+				return err
+			}
+			tag = reflect.StructTag(s).Get("json")
+		}
+		if tag == "-" {
+			continue
+		}
+		opts := strings.Split(tag, ",")
+		omitEmpty := false
+		for _, opt := range opts[1:] {
+			if opt == "omitempty" {
+				omitEmpty = true
+			}
+		}
+		for _, n := range f.Names {
+			if !ast.IsExported(n.Name) {
+				continue
+			}
+			key := opts[0]
+			if key == "" {
+				key = n.Name
+			}
+			fields = append(fields, field{name: n.Name, key: key, omitEmpty: omitEmpty, typ: f.Type})
+		}
+	}
+
+	g.p("// EncodeJSON writes v to w as a JSON object.")
+	g.p("func (v %s) EncodeJSON(w *json.Writer) error {", name)
+	g.p("w.StartObject()")
+	for _, f := range fields {
+		x := "v." + f.name
+		cond := g.nonEmpty(x, f.typ)
+		if f.omitEmpty && cond != "" {
+			g.p("if %s {", cond)
+		}
+		g.p("w.Name(%s)", strconv.Quote(f.key))
+		if err := g.encode(x, f.typ); err != nil {
+			return fmt.Errorf("%s.%s: %v", name, f.name, err)
+		}
+		if f.omitEmpty && cond != "" {
+			g.p("}")
+		}
+	}
+	g.p("return w.EndObject()")
+	g.p("}\n")
+
+	g.p("// DecodeJSON decodes the JSON object at the scanner's current element to v.")
+	g.p("func (v *%s) DecodeJSON(s *json.Scanner) error {", name)
+	g.p("switch s.Kind() {")
+	g.p("case json.Null:")
+	g.p("return nil")
+	g.p("case json.Object:")
+	g.p("default:")
+	g.typeError(name)
+	g.p("}")
+	g.p("n := s.NestingLevel()")
+	g.p("for s.ScanAtLevel(n) {")
+	g.p("switch string(s.Name()) {")
+	for _, f := range fields {
+		g.p("case %s:", strconv.Quote(f.key))
+		if err := g.decode("v."+f.name, f.typ); err != nil {
+			return fmt.Errorf("%s.%s: %v", name, f.name, err)
+		}
+	}
+	g.p("}")
+	g.p("}")
+	g.p("return s.Err()")
+	g.p("}\n")
+	return nil
+}
+
+// underlying returns the underlying type of t for types declared in the file
+// with a non-struct type. Other types are returned unchanged.
+func (g *generator) underlying(t ast.Expr) ast.Expr {
+	for i := 0; i < 100; i++ {
+		id, ok := t.(*ast.Ident)
+		if !ok {
+			break
+		}
+		u, ok := g.types[id.Name]
+		if !ok {
+			break
+		}
+		if _, ok := u.(*ast.StructType); ok {
+			break
+		}
+		t = u
+	}
+	return t
+}
+
+const (
+	kindString = "string"
+	kindBool   = "bool"
+	kindInt    = "int"
+	kindUint   = "uint"
+	kindFloat  = "float"
+	kindMethod = "method" // type with EncodeJSON and DecodeJSON methods
+	kindPtr    = "ptr"
+	kindSlice  = "slice"
+	kindMap    = "map"
+)
+
+// kind returns the kind of type t and the underlying type.
+func (g *generator) kind(t ast.Expr) (string, ast.Expr, error) {
+	u := g.underlying(t)
+	switch u := u.(type) {
+	case *ast.Ident:
+		switch u.Name {
+		case "string":
+			return kindString, u, nil
+		case "bool":
+			return kindBool, u, nil
+		case "int", "int8", "int16", "int32", "int64", "rune":
+			return kindInt, u, nil
+		case "uint", "uint8", "uint16", "uint32", "uint64", "uintptr", "byte":
+			return kindUint, u, nil
+		case "float32", "float64":
+			return kindFloat, u, nil
+		case "complex64", "complex128", "error":
+			return "", nil, fmt.Errorf("type %s not supported", u.Name)
+		}
+		return kindMethod, u, nil
+	case *ast.StarExpr:
+		return kindPtr, u, nil
+	case *ast.ArrayType:
+		if u.Len != nil {
+			return "", nil, fmt.Errorf("array type %s not supported", exprString(t))
+		}
+		if k, _, _ := g.kind(u.Elt); k == kindUint {
+			if id, ok := g.underlying(u.Elt).(*ast.Ident); ok && (id.Name == "byte" || id.Name == "uint8") {
+				return "", nil, fmt.Errorf("byte slice type %s not supported", exprString(t))
+			}
+		}
+		return kindSlice, u, nil
+	case *ast.MapType:
+		if k, _, _ := g.kind(u.Key); k != kindString {
+			return "", nil, fmt.Errorf("map key type %s not supported", exprString(u.Key))
+		}
+		return kindMap, u, nil
+	}
+	return "", nil, fmt.Errorf("type %s not supported", exprString(t))
+}
+
+// nonEmpty returns a condition that is true if x of type t is not empty as
+// defined by the omitempty option. The condition is "" if x is never empty.
+func (g *generator) nonEmpty(x string, t ast.Expr) string {
+	k, _, _ := g.kind(t)
+	switch k {
+	case kindString:
+		return x + ` != ""`
+	case kindBool:
+		return x
+	case kindInt, kindUint, kindFloat:
+		return x + " != 0"
+	case kindPtr:
+		return x + " != nil"
+	case kindSlice, kindMap:
+		return "len(" + x + ") != 0"
+	}
+	return ""
+}
+
+// encode generates code to write x of type t to w.
+func (g *generator) encode(x string, t ast.Expr) error {
+	k, u, err := g.kind(t)
+	if err != nil {
+		return err
+	}
+	switch k {
+	case kindString:
+		g.p("w.String(string(%s))", x)
+	case kindBool:
+		g.p("w.Bool(bool(%s))", x)
+	case kindInt:
+		g.p("w.Int(int64(%s))", x)
+	case kindUint:
+		g.p("w.Uint(uint64(%s))", x)
+	case kindFloat:
+		g.p("w.Float(float64(%s))", x)
+	case kindMethod:
+		g.p("%s.EncodeJSON(w)", x)
+	case kindPtr:
+		g.p("if %s == nil {", x)
+		g.p("w.Null()")
+		g.p("} else {")
+		if err := g.encode("(*"+x+")", u.(*ast.StarExpr).X); err != nil {
+			return err
+		}
+		g.p("}")
+	case kindSlice:
+		e := g.tmp("e")
+		g.p("if %s == nil {", x)
+		g.p("w.Null()")
+		g.p("} else {")
+		g.p("w.StartArray()")
+		g.p("for _, %s := range %s {", e, x)
+		if err := g.encode(e, u.(*ast.ArrayType).Elt); err != nil {
+			return err
+		}
+		g.p("}")
+		g.p("w.EndArray()")
+		g.p("}")
+	case kindMap:
+		g.sort = true
+		m := u.(*ast.MapType)
+		keys := g.tmp("keys")
+		key := g.tmp("k")
+		g.p("if %s == nil {", x)
+		g.p("w.Null()")
+		g.p("} else {")
+		g.p("w.StartObject()")
+		g.p("%s := make([]string, 0, len(%s))", keys, x)
+		g.p("for %s := range %s {", key, x)
+		g.p("%s = append(%s, string(%s))", keys, keys, key)
+		g.p("}")
+		g.p("sort.Strings(%s)", keys)
+		g.p("for _, %s := range %s {", key, keys)
+		g.p("w.Name(%s)", key)
+		if err := g.encode(fmt.Sprintf("%s[%s(%s)]", x, exprString(m.Key), key), m.Value); err != nil {
+			return err
+		}
+		g.p("}")
+		g.p("w.EndObject()")
+		g.p("}")
+	}
+	return nil
+}
+
+// decode generates code to decode the scanner's current element to the
+// addressable expression x of type t.
+func (g *generator) decode(x string, t ast.Expr) error {
+	k, u, err := g.kind(t)
+	if err != nil {
+		return err
+	}
+	tn := exprString(t)
+	switch k {
+	case kindString:
+		g.p("switch s.Kind() {")
+		g.p("case json.Null:")
+		g.p("case json.String:")
+		g.p("%s = %s(s.Value())", x, tn)
+		g.p("default:")
+		g.typeError(tn)
+		g.p("}")
+	case kindBool:
+		g.p("switch s.Kind() {")
+		g.p("case json.Null:")
+		g.p("case json.Bool:")
+		g.p("%s = %s(s.Value()[0] == 't')", x, tn)
+		g.p("default:")
+		g.typeError(tn)
+		g.p("}")
+	case kindInt, kindUint:
+		basic := u.(*ast.Ident).Name
+		method, conv := "Int64", "int64"
+		if k == kindUint {
+			method, conv = "Uint64", "uint64"
+		}
+		i := g.tmp("i")
+		g.p("if s.Kind() != json.Null {")
+		g.p("%s, err := s.%s()", i, method)
+		g.p("if err != nil {")
+		g.p("return err")
+		g.p("}")
+		if basic != conv {
+			g.p("if %s(%s(%s)) != %s {", conv, basic, i, i)
+			g.p("return fmt.Errorf(\"cannot unmarshal number %%s into Go value of type %s\", s.Value())", tn)
+			g.p("}")
+		}
+		g.p("%s = %s(%s)", x, tn, i)
+		g.p("}")
+	case kindFloat:
+		f := g.tmp("f")
+		g.p("if s.Kind() != json.Null {")
+		g.p("%s, err := s.Float64()", f)
+		g.p("if err != nil {")
+		g.p("return err")
+		g.p("}")
+		g.p("%s = %s(%s)", x, tn, f)
+		g.p("}")
+	case kindMethod:
+		g.p("if err := %s.DecodeJSON(s); err != nil {", x)
+		g.p("return err")
+		g.p("}")
+	case kindPtr:
+		elem := u.(*ast.StarExpr).X
+		g.p("if s.Kind() == json.Null {")
+		g.p("%s = nil", x)
+		g.p("} else {")
+		g.p("if %s == nil {", x)
+		g.p("%s = new(%s)", x, exprString(elem))
+		g.p("}")
+		if err := g.decode("(*"+x+")", elem); err != nil {
+			return err
+		}
+		g.p("}")
+	case kindSlice:
+		elem := u.(*ast.ArrayType).Elt
+		n := g.tmp("n")
+		e := g.tmp("e")
+		g.p("switch s.Kind() {")
+		g.p("case json.Null:")
+		g.p("%s = nil", x)
+		g.p("case json.Array:")
+		g.p("if %s == nil {", x)
+		g.p("%s = %s{}", x, tn)
+		g.p("}")
+		g.p("%s = %s[:0]", x, x)
+		g.p("%s := s.NestingLevel()", n)
+		g.p("for s.ScanAtLevel(%s) {", n)
+		g.p("var %s %s", e, exprString(elem))
+		if err := g.decode(e, elem); err != nil {
+			return err
+		}
+		g.p("%s = append(%s, %s)", x, x, e)
+		g.p("}")
+		g.p("if err := s.Err(); err != nil {")
+		g.p("return err")
+		g.p("}")
+		g.p("default:")
+		g.typeError(tn)
+		g.p("}")
+	case kindMap:
+		m := u.(*ast.MapType)
+		n := g.tmp("n")
+		key := g.tmp("k")
+		e := g.tmp("e")
+		g.p("switch s.Kind() {")
+		g.p("case json.Null:")
+		g.p("%s = nil", x)
+		g.p("case json.Object:")
+		g.p("if %s == nil {", x)
+		g.p("%s = make(%s)", x, tn)
+		g.p("}")
+		g.p("%s := s.NestingLevel()", n)
+		g.p("for s.ScanAtLevel(%s) {", n)
+		g.p("%s := %s(s.Name())", key, exprString(m.Key))
+		g.p("var %s %s", e, exprString(m.Value))
+		if err := g.decode(e, m.Value); err != nil {
+			return err
+		}
+		g.p("%s[%s] = %s", x, key, e)
+		g.p("}")
+		g.p("if err := s.Err(); err != nil {")
+		g.p("return err")
+		g.p("}")
+		g.p("default:")
+		g.typeError(tn)
+		g.p("}")
+	}
+	return nil
+}
+
+func (g *generator) typeError(typeName string) {
+	g.p("return fmt.Errorf(\"cannot unmarshal %%v into Go value of type %s\", s.Kind())", typeName)
+}
+
+func exprString(x ast.Expr) string {
+	var buf bytes.Buffer
+	format.Node(&buf, token.NewFileSet(), x)
+	return buf.String()
+}
@@ -0,0 +1,51 @@
+// Copyright 2014 Gary Burd. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"testing"
+)
+
+func TestGenerateExample(t *testing.T) {
+	src, err := ioutil.ReadFile("example/example.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := generate("example.go", src, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := ioutil.ReadFile("example/example_json.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Error("generated code does not match example/example_json.go, run go generate in example directory")
+	}
+}
+
+var generateErrorTests = []struct {
+	src   string
+	names []string
+}{
+	{"package p; type T struct { C complex128 }", nil},
+	{"package p; type T struct { B []byte }", nil},
+	{"package p; type T struct { M map[int]string }", nil},
+	{"package p; type T struct { A [2]int }", nil},
+	{"package p; type E struct{}; type T struct { E }", nil},
+	{"package p; type T struct { F func() }", nil},
+	{"package p; type T int", nil},
+	{"package p; type T struct{}", []string{"U"}},
+}
+
+func TestGenerateError(t *testing.T) {
+	for _, tt := range generateErrorTests {
+		if _, err := generate("x.go", []byte(tt.src), tt.names); err == nil {
+			t.Errorf("%s: expected error", tt.src)
+		}
+	}
+}
@@ -0,0 +1,43 @@
+// Copyright 2014 Gary Burd. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package json
+
+import (
+	"bufio"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+)
+
+// NewScannerAuto allocates and initializes a new scanner that reads from r.
+// If the input starts with a gzip or zlib header, then the scanner
+// decompresses the input before scanning.
+func NewScannerAuto(r io.Reader) *Scanner {
+	br, ok := r.(*bufio.Reader)
+	if !ok {
+		br = bufio.NewReader(r)
+	}
+	var (
+		rd  io.Reader
+		err error
+	)
+	switch p, _ := br.Peek(2); {
+	case len(p) == 2 && p[0] == 0x1f && p[1] == 0x8b:
+		rd, err = gzip.NewReader(br)
+	case len(p) == 2 && p[0] == 0x78 && p[1]&0x20 == 0 && (uint(p[0])<<8|uint(p[1]))%31 == 0:
+		// Deflate with a 32K window and no preset dictionary. Other
+		// headers are not accepted because JSON numbers can look like
+		// zlib headers.
+		rd, err = zlib.NewReader(br)
+	default:
+		return NewScanner(br)
+	}
+	if err != nil {
+		s := NewScannerBytes(nil)
+		s.err = err
+		return s
+	}
+	return NewScanner(rd)
+}
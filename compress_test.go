@@ -0,0 +1,81 @@
+// Copyright 2014 Gary Burd. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package json
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"strconv"
+	"testing"
+)
+
+func TestNewScannerAuto(t *testing.T) {
+	const doc = `{"a": [1, "two", true]}`
+	var gz, zl bytes.Buffer
+	gw := gzip.NewWriter(&gz)
+	io.WriteString(gw, doc)
+	gw.Close()
+	zw := zlib.NewWriter(&zl)
+	io.WriteString(zw, doc)
+	zw.Close()
+
+	for _, tt := range []struct {
+		name string
+		in   []byte
+	}{
+		{"plain", []byte(doc)},
+		{"gzip", gz.Bytes()},
+		{"zlib", zl.Bytes()},
+	} {
+		s := NewScannerAuto(readerOnly{bytes.NewReader(tt.in)})
+		n := 0
+		for s.Scan() {
+			n++
+		}
+		if err := s.Err(); err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+		}
+		if n != 7 {
+			t.Errorf("%s: got %d elements, want 7", tt.name, n)
+		}
+	}
+
+	s := NewScannerAuto(bytes.NewReader([]byte{0x1f, 0x8b, 0}))
+	if s.Scan() || s.Err() == nil {
+		t.Error("no error for truncated gzip header")
+	}
+}
+
+func TestNewScannerAutoNumbers(t *testing.T) {
+	// Some numbers, such as 80, are valid zlib headers with other window
+	// sizes.
+	for i := 0; i < 1000; i++ {
+		for _, in := range []string{strconv.Itoa(i), strconv.Itoa(i) + " "} {
+			s := NewScannerAuto(readerOnly{bytes.NewReader([]byte(in))})
+			if !s.Scan() || s.Kind() != Number || string(s.Value()) != strconv.Itoa(i) {
+				t.Errorf("%q: got %v %q, err %v, want number", in, s.Kind(), s.Value(), s.Err())
+			}
+		}
+	}
+}
+
+func TestNewScannerAutoZlibLevels(t *testing.T) {
+	for _, level := range []int{zlib.NoCompression, zlib.BestSpeed, zlib.DefaultCompression, zlib.BestCompression} {
+		var buf bytes.Buffer
+		zw, _ := zlib.NewWriterLevel(&buf, level)
+		io.WriteString(zw, `[80]`)
+		zw.Close()
+		s := NewScannerAuto(&buf)
+		var got []string
+		for s.Scan() {
+			got = append(got, string(s.Value()))
+		}
+		if err := s.Err(); err != nil || len(got) != 3 || got[1] != "80" {
+			t.Errorf("level %d: got %q, %v", level, got, err)
+		}
+	}
+}
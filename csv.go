@@ -0,0 +1,89 @@
+// Copyright 2014 Gary Burd. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package json
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+)
+
+// ToCSV writes the objects in s to w as CSV records. The input is a
+// top-level array of objects or, when the scanner accepts multiple values, a
+// sequence of top-level objects such as newline delimited JSON.
+//
+// The first record is a header with the column names. If columns is nil, then
+// the columns are the member names of the first object in the order they
+// appear. Objects must be flat: member values are strings, numbers, booleans
+// or null. Strings are written unquoted, numbers and booleans are written as
+// they appear in the input and null is written as an empty field. Missing
+// members are written as empty fields. ToCSV returns an error for a member
+// that is not a column.
+func ToCSV(w io.Writer, s *Scanner, columns []string) error {
+	cw := csv.NewWriter(w)
+	index := make(map[string]int, len(columns))
+	for i, c := range columns {
+		index[c] = i
+	}
+	discover := columns == nil
+	if !discover {
+		if err := cw.Write(columns); err != nil {
+			return err
+		}
+	}
+	record := make([]string, len(columns))
+	array := false
+	for s.Scan() {
+		switch {
+		case s.Kind() == Array && !array:
+			array = true
+			continue
+		case s.Kind() == End:
+			// End of the top-level array.
+			continue
+		case s.Kind() != Object:
+			return fmt.Errorf("unexpected %v, expected object", s.Kind())
+		}
+		for i := range record {
+			record[i] = ""
+		}
+		for s.Scan() && s.Kind() != End {
+			name := s.NameString()
+			var v string
+			switch s.Kind() {
+			case String, Number, Bool:
+				v = string(s.Value())
+			case Null:
+			default:
+				return fmt.Errorf("member %s is %v, expected string, number, boolean or null", name, s.Kind())
+			}
+			i, ok := index[name]
+			if !ok {
+				if !discover {
+					return fmt.Errorf("member %s is not a column", name)
+				}
+				i = len(columns)
+				index[name] = i
+				columns = append(columns, name)
+				record = append(record, "")
+			}
+			record[i] = v
+		}
+		if discover {
+			if err := cw.Write(columns); err != nil {
+				return err
+			}
+			discover = false
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	if err := s.Err(); err != nil {
+		return err
+	}
+	cw.Flush()
+	return cw.Error()
+}
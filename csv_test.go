@@ -0,0 +1,65 @@
+// Copyright 2014 Gary Burd. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package json
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+var csvTests = []struct {
+	in      string
+	lines   bool
+	columns []string
+	out     string
+}{
+	{`[]`, false, nil, ""},
+	{`[]`, false, []string{"a", "b"}, "a,b\n"},
+	{`[{"a":1,"b":"x"},{"b":"y, z","a":true},{"a":null}]`, false, nil, "a,b\n1,x\ntrue,\"y, z\"\n,\n"},
+	{`[{"a":"q\"r","a":2}]`, false, nil, "a\n2\n"},
+	{`[{"a":"q\"r"},{}]`, false, nil, "a\n\"q\"\"r\"\n\n"},
+	{`[{"a":1,"b":2}]`, false, []string{"b", "a"}, "b,a\n2,1\n"},
+	{"{\"a\":1}\n{\"a\":\"\\u00e9\"}\n", true, nil, "a\n1\né\n"},
+}
+
+func TestToCSV(t *testing.T) {
+	for _, tt := range csvTests {
+		s := NewScanner(strings.NewReader(tt.in))
+		if tt.lines {
+			s.ExpectLines()
+		}
+		var buf bytes.Buffer
+		if err := ToCSV(&buf, s, tt.columns); err != nil {
+			t.Errorf("%s: unexpected error %v", tt.in, err)
+			continue
+		}
+		if got := buf.String(); got != tt.out {
+			t.Errorf("%s:\n got %q\nwant %q", tt.in, got, tt.out)
+		}
+	}
+}
+
+var csvErrorTests = []struct {
+	in      string
+	columns []string
+	err     string
+}{
+	{`[1]`, nil, "unexpected number, expected object"},
+	{`[[]]`, nil, "unexpected array, expected object"},
+	{`[{"a":[1]}]`, nil, "member a is array, expected string, number, boolean or null"},
+	{`[{"a":1,"b":2}]`, []string{"a"}, "member b is not a column"},
+	{`[{"a":1},{"b":2}]`, nil, "member b is not a column"},
+}
+
+func TestToCSVError(t *testing.T) {
+	for _, tt := range csvErrorTests {
+		var buf bytes.Buffer
+		err := ToCSV(&buf, NewScanner(strings.NewReader(tt.in)), tt.columns)
+		if err == nil || err.Error() != tt.err {
+			t.Errorf("%s: got error %v, want %s", tt.in, err, tt.err)
+		}
+	}
+}
@@ -5,8 +5,11 @@
 package json
 
 import (
+	"errors"
 	"fmt"
+	"math/big"
 	"strconv"
+	"strings"
 )
 
 // A NumberValue represents a JSON number literal.
@@ -17,33 +20,123 @@ func (n NumberValue) String() string { return string(n) }
 
 // Float64 returns the number as a float64.
 func (n NumberValue) Float64() (float64, error) {
-	return strconv.ParseFloat(string(n), 64)
+	f, err := strconv.ParseFloat(string(n), 64)
+	return f, n.error("float64", err)
 }
 
 // Int64 returns the number as an int64.
 func (n NumberValue) Int64() (int64, error) {
-	return strconv.ParseInt(string(n), 10, 64)
+	i, err := strconv.ParseInt(string(n), 10, 64)
+	return i, n.error("int64", err)
 }
 
 // Uint64 returns the number as an uint64.
 func (n NumberValue) Uint64() (uint64, error) {
-	return strconv.ParseUint(string(n), 10, 64)
+	u, err := strconv.ParseUint(string(n), 10, 64)
+	return u, n.error("uint64", err)
 }
 
 // Int returns the number as an int.
 func (n NumberValue) Int() (int, error) {
 	i, err := strconv.ParseInt(string(n), 10, 0)
-	return int(i), err
+	return int(i), n.error("int", err)
 }
 
 // Uint returns the number as an uint.
 func (n NumberValue) Uint() (uint, error) {
 	i, err := strconv.ParseUint(string(n), 10, 0)
-	return uint(i), err
+	return uint(i), n.error("uint", err)
+}
+
+// error wraps a conversion error in a *NumberError.
+func (n NumberValue) error(typ string, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &NumberError{Value: string(n), Type: typ, Err: err}
+}
+
+// maxBigIntBits is the maximum size of an integer converted by BigInt from a
+// number with an exponent.
+const maxBigIntBits = 1 << 20
+
+// BigInt returns the number as a *big.Int. Numbers with a fraction or exponent
+// are converted if the value is an integer. If an exponent makes the integer
+// larger than 2^1048576, then BigInt returns a *NumberError that wraps
+// strconv.ErrRange.
+func (n NumberValue) BigInt() (*big.Int, error) {
+	if i, ok := new(big.Int).SetString(string(n), 10); ok {
+		return i, nil
+	}
+	f, err := n.BigFloat()
+	if err != nil {
+		return nil, err
+	}
+	e := f.MantExp(nil)
+	if f.IsInf() || e > maxBigIntBits {
+		return nil, n.error("big.Int", strconv.ErrRange)
+	}
+	if e > 0 && uint(e) > f.Prec() {
+		// Parse again with the precision of the integer.
+		f, _, err = big.ParseFloat(string(n), 10, uint(e), big.ToNearestEven)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if !f.IsInt() {
+		return nil, errors.New("number " + string(n) + " is not an integer")
+	}
+	i, _ := f.Int(nil)
+	return i, nil
+}
+
+// BigFloat returns the number as a *big.Float. The precision of the result
+// is sufficient to represent all digits of the number.
+func (n NumberValue) BigFloat() (*big.Float, error) {
+	prec := uint(4 * len(n))
+	if prec < 64 {
+		prec = 64
+	}
+	f, _, err := big.ParseFloat(string(n), 10, prec, big.ToNearestEven)
+	return f, err
 }
 
 var emptySlice = make([]interface{}, 0, 0)
 
+// NumberMode specifies how DecodeValueWith decodes JSON numbers.
+type NumberMode int
+
+const (
+	// UseNumberValue decodes numbers as NumberValue.
+	UseNumberValue NumberMode = iota
+
+	// UseFloat64 decodes numbers as float64.
+	UseFloat64
+
+	// UseInt64 decodes integers that fit in an int64 as int64 and all
+	// other numbers as float64.
+	UseInt64
+
+	// UseBig decodes integers that fit in an int64 as int64 and other
+	// integers as *big.Int. Numbers with a fraction or exponent are decoded
+	// as float64 if converting the float64 back to decimal yields the same
+	// value and as *big.Float otherwise.
+	UseBig
+)
+
+// DecodeOptions specifies options for DecodeValueWith.
+type DecodeOptions struct {
+	Number NumberMode
+
+	// NumberFunc, if not nil, is called to decode numbers. NumberFunc
+	// overrides Number. Use NumberFunc to decode numbers to a decimal type.
+	NumberFunc func(n NumberValue) (interface{}, error)
+
+	// Ordered specifies that objects are decoded as *OrderedObject to
+	// preserve the order of members and members with duplicate names.
+	Ordered bool
+}
+
 // DecodeValue decodes the current scanner value to to Go types as follows:
 //
 //   JSON   Go
@@ -53,35 +146,124 @@ var emptySlice = make([]interface{}, 0, 0)
 //   string string
 //   bolean bool
 //   number NumberValue
+//
+// Use DecodeValueWith and the Ordered option to decode objects as
+// *OrderedObject.
 func DecodeValue(s *Scanner) (interface{}, error) {
+	return DecodeValueWith(s, DecodeOptions{})
+}
+
+// DecodeValueWith decodes the current scanner value as DecodeValue does using
+// the specified options.
+func DecodeValueWith(s *Scanner, opts DecodeOptions) (interface{}, error) {
+	if k := s.Kind(); k != Array && k != Object {
+		return decodeScalar(s, opts)
+	}
+
+	v := s.startValue()
+	x, err := decodeNested(s, opts)
+	return x, s.endValue(v, err)
+}
+
+// decodeNested decodes the array or object at the current scanner value.
+func decodeNested(s *Scanner, opts DecodeOptions) (interface{}, error) {
+	// Decode nested values with an explicit stack to bound the goroutine
+	// stack on deeply nested input.
+	stack := []decodeFrame{newDecodeFrame(s, "", opts)}
+	for {
+		f := &stack[len(stack)-1]
+		if !s.ScanAtLevel(f.level) {
+			if err := s.Err(); err != nil {
+				return stack[0].value(), err
+			}
+			v := f.value()
+			stack = stack[:len(stack)-1]
+			if len(stack) == 0 {
+				return v, nil
+			}
+			stack[len(stack)-1].add(f.name, v)
+			continue
+		}
+		var name string
+		if f.object != nil || f.ordered != nil {
+			name = s.NameString()
+		}
+		if k := s.Kind(); k == Array || k == Object {
+			stack = append(stack, newDecodeFrame(s, name, opts))
+			continue
+		}
+		v, err := decodeScalar(s, opts)
+		if err != nil {
+			return stack[0].value(), err
+		}
+		f.add(name, v)
+	}
+}
+
+// decodeFrame is an open array or object in DecodeValueWith.
+type decodeFrame struct {
+	level   int
+	name    string // member name of the value in the parent object
+	array   []interface{}
+	object  map[string]interface{}
+	ordered *OrderedObject
+}
+
+func newDecodeFrame(s *Scanner, name string, opts DecodeOptions) decodeFrame {
+	f := decodeFrame{level: s.NestingLevel(), name: name}
+	switch {
+	case s.Kind() == Array:
+		f.array = emptySlice
+	case opts.Ordered:
+		f.ordered = &OrderedObject{}
+	default:
+		f.object = make(map[string]interface{})
+	}
+	return f
+}
+
+func (f *decodeFrame) add(name string, v interface{}) {
+	switch {
+	case f.object != nil:
+		f.object[name] = v
+	case f.ordered != nil:
+		f.ordered.Members = append(f.ordered.Members, Member{name, v})
+	default:
+		f.array = append(f.array, v)
+	}
+}
+
+func (f *decodeFrame) value() interface{} {
+	if f.object != nil {
+		return f.object
+	}
+	if f.ordered != nil {
+		return f.ordered
+	}
+	return f.array
+}
+
+func decodeScalar(s *Scanner, opts DecodeOptions) (interface{}, error) {
 	switch s.Kind() {
 	case Number:
-		return NumberValue(s.Value()), nil
-	case String:
-		return string(s.Value()), nil
-	case Array:
-		v := emptySlice
-		n := s.NestingLevel()
-		for s.ScanAtLevel(n) {
-			subv, err := DecodeValue(s)
-			if err != nil {
-				return v, err
-			}
-			v = append(v, subv)
+		if opts.NumberFunc != nil {
+			return opts.NumberFunc(NumberValue(s.Value()))
 		}
-		return v, s.Err()
-	case Object:
-		v := make(map[string]interface{})
-		n := s.NestingLevel()
-		for s.ScanAtLevel(n) {
-			name := string(s.Name())
-			subv, err := DecodeValue(s)
-			if err != nil {
-				return v, err
+		switch opts.Number {
+		case UseFloat64:
+			return s.Float64()
+		case UseInt64:
+			if i, err := s.Int64(); err == nil {
+				return i, nil
 			}
-			v[name] = subv
+			return s.Float64()
+		case UseBig:
+			return decodeBig(NumberValue(s.Value()))
+		default:
+			return NumberValue(s.Value()), nil
 		}
-		return v, s.Err()
+	case String:
+		return string(s.Value()), nil
 	case Bool:
 		return s.Value()[0] == 't', nil
 	case Null:
@@ -90,3 +272,74 @@ func DecodeValue(s *Scanner) (interface{}, error) {
 		return nil, fmt.Errorf("unexpected %v", s.Kind())
 	}
 }
+
+// decodeBig decodes a number for the UseBig mode.
+func decodeBig(n NumberValue) (interface{}, error) {
+	isInt := true
+	for i := 0; i < len(n); i++ {
+		if c := n[i]; c == '.' || c == 'e' || c == 'E' {
+			isInt = false
+			break
+		}
+	}
+	if isInt {
+		if i, err := n.Int64(); err == nil {
+			return i, nil
+		}
+		return n.BigInt()
+	}
+	f, err := n.Float64()
+	if err == nil {
+		digits, exp := decimal(string(n))
+		fdigits, fexp := decimal(strconv.FormatFloat(f, 'e', -1, 64))
+		if digits == fdigits && exp == fexp {
+			return f, nil
+		}
+	}
+	return n.BigFloat()
+}
+
+// decimal returns the significant digits of the number s and the exponent
+// such that the value of s is ±digits × 10^exp. The digits have no leading or
+// trailing zeros.
+func decimal(s string) (digits string, exp int) {
+	var buf []byte
+	i := 0
+	if i < len(s) && s[i] == '-' {
+		i++
+	}
+	frac := false
+	for ; i < len(s); i++ {
+		c := s[i]
+		if c == '.' {
+			frac = true
+			continue
+		}
+		if !isDecimalDigit(c) {
+			break
+		}
+		if c == '0' && len(buf) == 0 {
+			if frac {
+				exp--
+			}
+			continue
+		}
+		buf = append(buf, c)
+		if frac {
+			exp--
+		}
+	}
+	if i < len(s) {
+		// Exponent.
+		e, _ := strconv.Atoi(strings.TrimPrefix(s[i+1:], "+"))
+		exp += e
+	}
+	for len(buf) > 0 && buf[len(buf)-1] == '0' {
+		buf = buf[:len(buf)-1]
+		exp++
+	}
+	if len(buf) == 0 {
+		exp = 0
+	}
+	return string(buf), exp
+}
@@ -0,0 +1,170 @@
+// Copyright 2014 Gary Burd. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package json
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+var decodeValueTests = []struct {
+	in   string
+	mode NumberMode
+	want interface{}
+}{
+	{`[1,1.5,"a",true,null]`, UseNumberValue, []interface{}{NumberValue("1"), NumberValue("1.5"), "a", true, nil}},
+	{`[1,1.5]`, UseFloat64, []interface{}{float64(1), 1.5}},
+	{`[1,-2,1.5,1e2,9223372036854775808]`, UseInt64, []interface{}{int64(1), int64(-2), 1.5, float64(100), float64(9223372036854775808)}},
+	{`{"a":{"b":2}}`, UseInt64, map[string]interface{}{"a": map[string]interface{}{"b": int64(2)}}},
+}
+
+func TestDecodeValueWith(t *testing.T) {
+	for _, tt := range decodeValueTests {
+		s := NewScannerBytes([]byte(tt.in))
+		s.Scan()
+		v, err := DecodeValueWith(s, DecodeOptions{Number: tt.mode})
+		if err != nil {
+			t.Errorf("%s: %v", tt.in, err)
+			continue
+		}
+		if !reflect.DeepEqual(v, tt.want) {
+			t.Errorf("%s, mode %d: got %#v, want %#v", tt.in, tt.mode, v, tt.want)
+		}
+	}
+}
+
+var decodeBigTests = []struct {
+	in   string
+	want string // %T:%v of the decoded value
+}{
+	{`1`, "int64:1"},
+	{`-9223372036854775808`, "int64:-9223372036854775808"},
+	{`9223372036854775808`, "*big.Int:9223372036854775808"},
+	{`-123456789012345678901234567890`, "*big.Int:-123456789012345678901234567890"},
+	{`1.5`, "float64:1.5"},
+	{`0.1`, "float64:0.1"},
+	{`0.0`, "float64:0"},
+	{`1e2`, "float64:100"},
+	{`1.2345678901234567`, "float64:1.2345678901234567"},
+	{`1.23456789012345678901`, "*big.Float:1.23456789012345678901"},
+	{`1e400`, "*big.Float:1e+400"},
+}
+
+func TestDecodeBig(t *testing.T) {
+	for _, tt := range decodeBigTests {
+		s := NewScannerBytes([]byte(tt.in))
+		s.Scan()
+		v, err := DecodeValueWith(s, DecodeOptions{Number: UseBig})
+		if err != nil {
+			t.Errorf("%s: %v", tt.in, err)
+			continue
+		}
+		var got string
+		if f, ok := v.(*big.Float); ok {
+			got = fmt.Sprintf("%T:%s", v, f.Text('g', -1))
+		} else {
+			got = fmt.Sprintf("%T:%v", v, v)
+		}
+		if got != tt.want {
+			t.Errorf("%s: got %s, want %s", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestNumberValueBig(t *testing.T) {
+	i, err := NumberValue("1.5e3").BigInt()
+	if err != nil || i.String() != "1500" {
+		t.Errorf("BigInt(1.5e3) = %v, %v", i, err)
+	}
+	if _, err := NumberValue("1.5").BigInt(); err == nil {
+		t.Error("BigInt(1.5) returned nil error")
+	}
+	for _, n := range []NumberValue{"1e99999999", "1e999999999", "-1e99999999"} {
+		_, err := n.BigInt()
+		if e, ok := err.(*NumberError); !ok || !errors.Is(e, strconv.ErrRange) {
+			t.Errorf("BigInt(%s) returned %v, want range error", n, err)
+		}
+	}
+	if i, err := NumberValue("1e1000").BigInt(); err != nil || i.String() != "1"+strings.Repeat("0", 1000) {
+		t.Errorf("BigInt(1e1000) = %v, %v", i, err)
+	}
+	f, err := NumberValue("-12345678901234567890.125").BigFloat()
+	if err != nil || f.Text('f', 3) != "-12345678901234567890.125" {
+		t.Errorf("BigFloat() = %v, %v", f, err)
+	}
+}
+
+type testDecimal struct {
+	coef int64
+	exp  int
+}
+
+func TestDecodeNumberFunc(t *testing.T) {
+	opts := DecodeOptions{
+		Number: UseFloat64,
+		NumberFunc: func(n NumberValue) (interface{}, error) {
+			digits, exp := decimal(string(n))
+			coef, err := strconv.ParseInt(digits, 10, 64)
+			if err != nil {
+				return nil, err
+			}
+			return testDecimal{coef, exp}, nil
+		},
+	}
+	s := NewScannerBytes([]byte(`{"a":[1.25,300]}`))
+	s.Scan()
+	v, err := DecodeValueWith(s, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{"a": []interface{}{testDecimal{125, -2}, testDecimal{3, 2}}}
+	if !reflect.DeepEqual(v, want) {
+		t.Errorf("got %v, want %v", v, want)
+	}
+
+	s = NewScannerBytes([]byte(`[123456789012345678901234567890]`))
+	s.Scan()
+	if _, err := DecodeValueWith(s, opts); err == nil {
+		t.Error("NumberFunc error not returned")
+	}
+}
+
+func deepInput(n int) []byte {
+	return []byte(strings.Repeat(`[{"a":`, n) + "1" + strings.Repeat(`}]`, n))
+}
+
+func TestDecodeValueDeep(t *testing.T) {
+	s := NewScannerBytes(deepInput(DefaultMaxDepth))
+	s.Scan()
+	if _, err := DecodeValue(s); !errors.Is(err, ErrTooDeep) {
+		t.Errorf("got error %v, want ErrTooDeep", err)
+	}
+
+	const n = 100000
+	s = NewScannerBytes(deepInput(n))
+	s.SetMaxDepth(-1)
+	s.Scan()
+	v, err := DecodeValue(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < n; i++ {
+		v = v.([]interface{})[0].(map[string]interface{})["a"]
+	}
+	if v != NumberValue("1") {
+		t.Errorf("got %v, want 1", v)
+	}
+
+	s = NewScannerBytes(deepInput(DefaultMaxDepth))
+	s.Scan()
+	if err := Unmarshal(s, new(interface{})); !errors.Is(err, ErrTooDeep) {
+		t.Errorf("Unmarshal got error %v, want ErrTooDeep", err)
+	}
+}
@@ -0,0 +1,139 @@
+// Copyright 2014 Gary Burd. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package json
+
+import (
+	"strconv"
+	"strings"
+)
+
+// DiffEntry describes a difference between two JSON documents.
+type DiffEntry struct {
+	Path string // RFC 6901 JSON Pointer to the value
+	A    []byte // the value in the first document or nil if missing
+	B    []byte // the value in the second document or nil if missing
+}
+
+// Equal returns true if the JSON documents a and b are structurally equal.
+// Object members are compared without regard to order, numbers are compared
+// by numeric value without conversion to floating point and strings are
+// compared after unescaping. If an object has duplicate member names, then
+// the last member with the name is used.
+func Equal(a, b []byte) (bool, error) {
+	d, err := diff(a, b, true)
+	return len(d) == 0, err
+}
+
+// Diff returns the differences between the JSON documents a and b. Values
+// are compared as described for Equal. Object members are reported in the
+// order of the members in a followed by the members only in b. A value with
+// different kinds in a and b is reported as a single entry.
+func Diff(a, b []byte) ([]DiffEntry, error) {
+	return diff(a, b, false)
+}
+
+func diff(a, b []byte, first bool) ([]DiffEntry, error) {
+	da, err := ParseDocument(a)
+	if err != nil {
+		return nil, err
+	}
+	db, err := ParseDocument(b)
+	if err != nil {
+		return nil, err
+	}
+	d := differ{first: first}
+	d.values(nil, da.Root(), db.Root())
+	return d.entries, nil
+}
+
+type differ struct {
+	first   bool // if true, stop at the first difference
+	entries []DiffEntry
+}
+
+func (d *differ) add(path []byte, a, b Value) {
+	d.entries = append(d.entries, DiffEntry{Path: string(path), A: a.Raw(), B: b.Raw()})
+}
+
+func (d *differ) done() bool {
+	return d.first && len(d.entries) > 0
+}
+
+func (d *differ) values(path []byte, a, b Value) {
+	if a.Kind() != b.Kind() || a.Exists() != b.Exists() {
+		d.add(path, a, b)
+		return
+	}
+	switch a.Kind() {
+	case Object:
+		ma, names := lastMembers(a, nil)
+		mb, names := lastMembers(b, names)
+		for _, name := range names {
+			if d.done() {
+				return
+			}
+			d.values(appendPointer(path, name), ma[name], mb[name])
+		}
+	case Array:
+		na, nb := a.Len(), b.Len()
+		for i := 0; i < na || i < nb; i++ {
+			if d.done() {
+				return
+			}
+			d.values(strconv.AppendInt(append(path, '/'), int64(i), 10), a.Index(i), b.Index(i))
+		}
+	case Number:
+		if !numberEqual(string(a.Number()), string(b.Number())) {
+			d.add(path, a, b)
+		}
+	case String:
+		if a.String() != b.String() {
+			d.add(path, a, b)
+		}
+	case Bool:
+		if a.Bool() != b.Bool() {
+			d.add(path, a, b)
+		}
+	}
+}
+
+// lastMembers returns a map of the last member of object v with each name.
+// The names not in names are appended to names in document order.
+func lastMembers(v Value, names []string) (map[string]Value, []string) {
+	m := make(map[string]Value)
+	seen := make(map[string]bool, len(names))
+	for _, name := range names {
+		seen[name] = true
+	}
+	v.Members(func(name string, value Value) bool {
+		m[name] = value
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+		return true
+	})
+	return m, names
+}
+
+// appendPointer appends the JSON Pointer reference token for name to path.
+func appendPointer(path []byte, name string) []byte {
+	path = append(path, '/')
+	if strings.ContainsAny(name, "~/") {
+		name = pointerEscaper.Replace(name)
+	}
+	return append(path, name...)
+}
+
+// numberEqual returns true if the number literals a and b have the same
+// numeric value.
+func numberEqual(a, b string) bool {
+	da, ea := decimal(a)
+	db, eb := decimal(b)
+	if da != db || ea != eb {
+		return false
+	}
+	return da == "" || strings.HasPrefix(a, "-") == strings.HasPrefix(b, "-")
+}
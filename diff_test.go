@@ -0,0 +1,58 @@
+// Copyright 2014 Gary Burd. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package json
+
+import (
+	"reflect"
+	"testing"
+)
+
+var diffTests = []struct {
+	a, b string
+	want []DiffEntry
+}{
+	{`1`, `1`, nil},
+	{`{"a":1,"b":[1,2]}`, `{"b":[1,2],"a":1}`, nil},
+	{`[1.0, 100, -0, 0.5e1]`, `[1, 1e2, 0, 5]`, nil},
+	{`"\u0041/"`, `"A\/"`, nil},
+	{`{"a":1,"a":2}`, `{"a":2}`, nil},
+	{`1`, `"1"`, []DiffEntry{{"", []byte(`1`), []byte(`"1"`)}}},
+	{`-1`, `1`, []DiffEntry{{"", []byte(`-1`), []byte(`1`)}}},
+	{`12345678901234567890`, `12345678901234567891`, []DiffEntry{{"", []byte(`12345678901234567890`), []byte(`12345678901234567891`)}}},
+	{
+		`{"a":1,"b":{"c/d":[true,null]},"x":false}`,
+		`{"a":2,"b":{"c/d":[false]},"y":"z"}`,
+		[]DiffEntry{
+			{"/a", []byte(`1`), []byte(`2`)},
+			{"/b/c~1d/0", []byte(`true`), []byte(`false`)},
+			{"/b/c~1d/1", []byte(`null`), nil},
+			{"/x", []byte(`false`), nil},
+			{"/y", nil, []byte(`"z"`)},
+		},
+	},
+}
+
+func TestDiff(t *testing.T) {
+	for _, tt := range diffTests {
+		got, err := Diff([]byte(tt.a), []byte(tt.b))
+		if err != nil {
+			t.Errorf("%s %s: %v", tt.a, tt.b, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s %s:\n got %q\nwant %q", tt.a, tt.b, got, tt.want)
+		}
+		eq, err := Equal([]byte(tt.a), []byte(tt.b))
+		if err != nil || eq != (len(tt.want) == 0) {
+			t.Errorf("%s %s: Equal() = %v, %v", tt.a, tt.b, eq, err)
+		}
+	}
+}
+
+func TestDiffError(t *testing.T) {
+	if _, err := Equal([]byte(`[1`), []byte(`[1]`)); err == nil {
+		t.Error("no error for invalid document")
+	}
+}
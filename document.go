@@ -0,0 +1,274 @@
+// Copyright 2014 Gary Burd. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package json
+
+import (
+	"reflect"
+	"strconv"
+)
+
+// Document is a parsed JSON document. The document is an index of offsets
+// into the original input. Strings are unescaped when accessed.
+type Document struct {
+	data  []byte
+	nodes []node
+}
+
+type node struct {
+	kind      Kind
+	cookName  bool // if true, name may contain escapes or invalid UTF-8.
+	cookValue bool // if true, string value may contain escapes or invalid UTF-8.
+	name      int  // offset of name in data or -1 if not an object member
+	nameEnd   int
+	pos       int // offset of value in data, excluding quotes for strings
+	end       int
+	next      int // index of the next sibling node
+}
+
+// ParseDocument parses the JSON document in data. The document references
+// data. The application must not modify data while the document is in use.
+func ParseDocument(data []byte) (*Document, error) {
+	d := &Document{data: data}
+	s := NewScannerBytes(data)
+	var open []int // indices of open objects and arrays
+	for s.Scan() {
+		if s.Kind() == End {
+			i := open[len(open)-1]
+			open = open[:len(open)-1]
+			d.nodes[i].end = s.pos
+			d.nodes[i].next = len(d.nodes)
+			continue
+		}
+		n := node{kind: s.Kind(), name: -1}
+		if name := &s.data[nameData]; name.pos >= 0 {
+			n.name, n.nameEnd, n.cookName = name.pos, name.end, name.cook
+		}
+		switch s.Kind() {
+		case Array, Object:
+			n.pos = s.pos - 1
+			open = append(open, len(d.nodes))
+		default:
+			value := &s.data[valueData]
+			n.pos, n.end, n.cookValue = value.pos, value.end, value.cook
+			n.next = len(d.nodes) + 1
+		}
+		d.nodes = append(d.nodes, n)
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// Root returns the root value of the document.
+func (d *Document) Root() Value {
+	if len(d.nodes) == 0 {
+		return Value{}
+	}
+	return Value{d, 0}
+}
+
+// Get returns the value at the given path from the root of the document. See
+// Value.Get for details.
+func (d *Document) Get(path ...interface{}) Value {
+	return d.Root().Get(path...)
+}
+
+// Value is a value in a Document. The zero value represents a value that
+// does not exist.
+type Value struct {
+	d *Document
+	i int
+}
+
+// Exists returns true if the value exists in the document.
+func (v Value) Exists() bool {
+	return v.d != nil
+}
+
+// Kind returns the kind of the value. Kind returns Null if the value does
+// not exist.
+func (v Value) Kind() Kind {
+	if v.d == nil {
+		return Null
+	}
+	return v.d.nodes[v.i].kind
+}
+
+// Get returns the value at the given path from v. Each element of the path
+// is a string object member name or an int array index. Get returns a value
+// that does not exist if the path is not found.
+func (v Value) Get(path ...interface{}) Value {
+	for _, p := range path {
+		switch p := p.(type) {
+		case string:
+			v = v.member(p)
+		case int:
+			v = v.Index(p)
+		default:
+			return Value{}
+		}
+	}
+	return v
+}
+
+func (v Value) member(name string) Value {
+	if v.Kind() != Object {
+		return Value{}
+	}
+	d := v.d
+	for i := v.i + 1; i < d.nodes[v.i].next; i = d.nodes[i].next {
+		n := &d.nodes[i]
+		p := d.data[n.name:n.nameEnd]
+		if n.cookName {
+			p = unescape(make([]byte, len(p)), p)
+		}
+		if string(p) == name {
+			return Value{d, i}
+		}
+	}
+	return Value{}
+}
+
+// Index returns element i of an array. Index returns a value that does not
+// exist if v is not an array or i is out of range.
+func (v Value) Index(i int) Value {
+	if v.Kind() != Array || i < 0 {
+		return Value{}
+	}
+	d := v.d
+	for j := v.i + 1; j < d.nodes[v.i].next; j = d.nodes[j].next {
+		if i == 0 {
+			return Value{d, j}
+		}
+		i--
+	}
+	return Value{}
+}
+
+// Len returns the number of elements in an array or members in an object.
+func (v Value) Len() int {
+	if v.Kind() != Array && v.Kind() != Object {
+		return 0
+	}
+	d := v.d
+	n := 0
+	for j := v.i + 1; j < d.nodes[v.i].next; j = d.nodes[j].next {
+		n++
+	}
+	return n
+}
+
+// Members calls fn for each member of an object in document order. If fn
+// returns false, then iteration stops.
+func (v Value) Members(fn func(name string, value Value) bool) {
+	if v.Kind() != Object {
+		return
+	}
+	d := v.d
+	for i := v.i + 1; i < d.nodes[v.i].next; i = d.nodes[i].next {
+		if !fn(Value{d, i}.Name(), Value{d, i}) {
+			return
+		}
+	}
+}
+
+// Name returns the object member name of the value or "" if the value is not
+// an object member.
+func (v Value) Name() string {
+	if v.d == nil {
+		return ""
+	}
+	n := &v.d.nodes[v.i]
+	if n.name < 0 {
+		return ""
+	}
+	p := v.d.data[n.name:n.nameEnd]
+	if n.cookName {
+		p = unescape(make([]byte, len(p)), p)
+	}
+	return string(p)
+}
+
+// Raw returns the input bytes of the value.
+func (v Value) Raw() []byte {
+	if v.d == nil {
+		return nil
+	}
+	n := &v.d.nodes[v.i]
+	if n.kind == String {
+		return v.d.data[n.pos-1 : n.end+1]
+	}
+	return v.d.data[n.pos:n.end]
+}
+
+// text returns the unescaped text of a scalar value.
+func (v Value) text() []byte {
+	n := &v.d.nodes[v.i]
+	p := v.d.data[n.pos:n.end]
+	if n.cookValue {
+		p = unescape(make([]byte, len(p)), p)
+	}
+	return p
+}
+
+// String returns the value of a string. For other kinds, String returns the
+// JSON text of the value.
+func (v Value) String() string {
+	switch v.Kind() {
+	case String:
+		return string(v.text())
+	default:
+		return string(v.Raw())
+	}
+}
+
+// Bool returns the value of a boolean. Bool returns false if the value is
+// not a boolean.
+func (v Value) Bool() bool {
+	return v.Kind() == Bool && v.d.data[v.d.nodes[v.i].pos] == 't'
+}
+
+// Number returns the text of a number. Number returns "" if the value is not
+// a number.
+func (v Value) Number() NumberValue {
+	if v.Kind() != Number {
+		return ""
+	}
+	return NumberValue(v.Raw())
+}
+
+var (
+	int64Type   = reflect.TypeOf(int64(0))
+	uint64Type  = reflect.TypeOf(uint64(0))
+	float64Type = reflect.TypeOf(float64(0))
+	bytesType   = reflect.TypeOf([]byte(nil))
+)
+
+// Int64 returns the value of a number as an int64.
+func (v Value) Int64() (int64, error) {
+	if v.Kind() != Number {
+		return 0, &UnmarshalTypeError{v.Kind().String(), int64Type}
+	}
+	return strconv.ParseInt(string(v.Raw()), 10, 64)
+}
+
+// Float64 returns the value of a number as a float64.
+func (v Value) Float64() (float64, error) {
+	if v.Kind() != Number {
+		return 0, &UnmarshalTypeError{v.Kind().String(), float64Type}
+	}
+	return strconv.ParseFloat(string(v.Raw()), 64)
+}
+
+// Interface returns the value decoded with DecodeValue.
+func (v Value) Interface() (interface{}, error) {
+	if v.d == nil {
+		return nil, nil
+	}
+	s := NewScannerBytes(v.Raw())
+	s.Scan()
+	return DecodeValue(s)
+}
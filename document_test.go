@@ -0,0 +1,96 @@
+// Copyright 2014 Gary Burd. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package json
+
+import (
+	"reflect"
+	"testing"
+)
+
+const documentText = `{
+	"a": [1, {"b": "x\ty", "c": null}, [], true],
+	"d": -2.5,
+	"e": {}
+}`
+
+func TestDocument(t *testing.T) {
+	d, err := ParseDocument([]byte(documentText))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if k := d.Root().Kind(); k != Object {
+		t.Errorf("root kind = %v, want object", k)
+	}
+	if n := d.Root().Len(); n != 3 {
+		t.Errorf("root len = %d, want 3", n)
+	}
+	if n := d.Get("a").Len(); n != 4 {
+		t.Errorf("a len = %d, want 4", n)
+	}
+	if s := d.Get("a", 1, "b").String(); s != "x\ty" {
+		t.Errorf("a/1/b = %q, want %q", s, "x\ty")
+	}
+	if k := d.Get("a", 1, "c").Kind(); k != Null {
+		t.Errorf("a/1/c kind = %v, want null", k)
+	}
+	if !d.Get("a", 1, "c").Exists() {
+		t.Errorf("a/1/c does not exist")
+	}
+	if i, err := d.Get("a", 0).Int64(); i != 1 || err != nil {
+		t.Errorf("a/0 = %d, %v, want 1", i, err)
+	}
+	if f, err := d.Get("d").Float64(); f != -2.5 || err != nil {
+		t.Errorf("d = %g, %v, want -2.5", f, err)
+	}
+	if !d.Get("a", 3).Bool() {
+		t.Errorf("a/3 = false, want true")
+	}
+	if raw := string(d.Get("a", 1).Raw()); raw != `{"b": "x\ty", "c": null}` {
+		t.Errorf("a/1 raw = %q", raw)
+	}
+	if raw := string(d.Get("a", 1, "b").Raw()); raw != `"x\ty"` {
+		t.Errorf("a/1/b raw = %q", raw)
+	}
+	if k := d.Get("e").Kind(); k != Object || d.Get("e").Len() != 0 {
+		t.Errorf("e kind = %v, len = %d", k, d.Get("e").Len())
+	}
+
+	for _, path := range [][]interface{}{
+		{"x"},
+		{"a", 4},
+		{"a", -1},
+		{"a", "b"},
+		{"d", 0},
+		{1.5},
+	} {
+		if v := d.Get(path...); v.Exists() {
+			t.Errorf("%v exists", path)
+		}
+	}
+
+	var names []string
+	d.Root().Members(func(name string, v Value) bool {
+		names = append(names, name)
+		return true
+	})
+	if want := []string{"a", "d", "e"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("names = %v, want %v", names, want)
+	}
+
+	v, err := d.Get("a", 1).Interface()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string]interface{}{"b": "x\ty", "c": nil}; !reflect.DeepEqual(v, want) {
+		t.Errorf("a/1 = %v, want %v", v, want)
+	}
+}
+
+func TestDocumentError(t *testing.T) {
+	if _, err := ParseDocument([]byte(`{"a": [1,]}`)); err == nil {
+		t.Error("expected error")
+	}
+}
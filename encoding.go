@@ -0,0 +1,178 @@
+// Copyright 2014 Gary Burd. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package json
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// DetectEncoding enables detection of UTF-16 and UTF-32 encoded input. The
+// encoding is detected from a byte order mark or from the pattern of zero
+// bytes in the first four bytes of the input as described in RFC 4627. Input
+// in UTF-16 or UTF-32 is transcoded to UTF-8. Offsets and positions reported
+// by the scanner refer to the transcoded input. This method must be called
+// before scanning the input.
+//
+// The scanner always skips a UTF-8 byte order mark at the start of the input.
+func (s *Scanner) DetectEncoding() {
+	if s.rd == nil {
+		if s.pos != 0 || len(s.buf) == 0 {
+			return
+		}
+		// Transcode the input through the reader path.
+		s.rd = bytes.NewReader(s.buf)
+		s.buf = make([]byte, 0, defaultBufSize)
+		s.shared = false
+		if s.err == io.EOF {
+			s.err = nil
+		}
+	}
+	s.rd = &transcoder{rd: s.rd}
+}
+
+type encoding int
+
+const (
+	encodingUnknown encoding = iota
+	encodingUTF8
+	encodingUTF16BE
+	encodingUTF16LE
+	encodingUTF32BE
+	encodingUTF32LE
+)
+
+// detectEncoding returns the encoding of p and the length of the byte order
+// mark, if any.
+func detectEncoding(p []byte) (encoding, int) {
+	switch {
+	case len(p) >= 4 && p[0] == 0 && p[1] == 0 && p[2] == 0xfe && p[3] == 0xff:
+		return encodingUTF32BE, 4
+	case len(p) >= 4 && p[0] == 0xff && p[1] == 0xfe && p[2] == 0 && p[3] == 0:
+		return encodingUTF32LE, 4
+	case len(p) >= 2 && p[0] == 0xfe && p[1] == 0xff:
+		return encodingUTF16BE, 2
+	case len(p) >= 2 && p[0] == 0xff && p[1] == 0xfe:
+		return encodingUTF16LE, 2
+	case len(p) >= 4 && p[0] == 0 && p[1] == 0 && p[2] == 0 && p[3] != 0:
+		return encodingUTF32BE, 0
+	case len(p) >= 4 && p[0] != 0 && p[1] == 0 && p[2] == 0 && p[3] == 0:
+		return encodingUTF32LE, 0
+	case len(p) >= 2 && p[0] == 0 && p[1] != 0:
+		return encodingUTF16BE, 0
+	case len(p) >= 2 && p[0] != 0 && p[1] == 0:
+		return encodingUTF16LE, 0
+	}
+	return encodingUTF8, 0
+}
+
+// transcoder is a reader that detects the encoding of the input and
+// transcodes UTF-16 and UTF-32 input to UTF-8.
+type transcoder struct {
+	rd  io.Reader
+	enc encoding
+	in  []byte // input not yet transcoded
+	out []byte // transcoded output not yet read
+	hi  rune   // pending high surrogate or 0
+	err error
+}
+
+func (t *transcoder) Read(p []byte) (int, error) {
+	for len(t.out) == 0 {
+		if t.enc == encodingUTF8 {
+			// Pass through UTF-8 input after detection.
+			if len(t.in) > 0 {
+				n := copy(p, t.in)
+				t.in = t.in[n:]
+				return n, nil
+			}
+			if t.err != nil {
+				return 0, t.err
+			}
+			return t.rd.Read(p)
+		}
+		if t.err != nil {
+			t.flush()
+			if len(t.out) == 0 {
+				return 0, t.err
+			}
+			break
+		}
+		var buf [512]byte
+		n, err := t.rd.Read(buf[:])
+		t.in = append(t.in, buf[:n]...)
+		t.err = err
+		if t.enc == encodingUnknown {
+			if len(t.in) < 4 && t.err == nil {
+				continue
+			}
+			var bom int
+			t.enc, bom = detectEncoding(t.in)
+			t.in = t.in[bom:]
+			continue
+		}
+		t.transcode()
+	}
+	n := copy(p, t.out)
+	t.out = t.out[n:]
+	return n, nil
+}
+
+// transcode converts the complete code units in t.in to UTF-8.
+func (t *transcoder) transcode() {
+	size := 2
+	if t.enc == encodingUTF32BE || t.enc == encodingUTF32LE {
+		size = 4
+	}
+	i := 0
+	for ; i+size <= len(t.in); i += size {
+		var r rune
+		switch t.enc {
+		case encodingUTF16BE:
+			r = rune(binary.BigEndian.Uint16(t.in[i:]))
+		case encodingUTF16LE:
+			r = rune(binary.LittleEndian.Uint16(t.in[i:]))
+		case encodingUTF32BE:
+			r = rune(binary.BigEndian.Uint32(t.in[i:]))
+		case encodingUTF32LE:
+			r = rune(binary.LittleEndian.Uint32(t.in[i:]))
+		}
+		if size == 2 {
+			if t.hi != 0 {
+				hi := t.hi
+				t.hi = 0
+				if r >= 0xdc00 && r <= 0xdfff {
+					t.out = appendRune(t.out, utf16.DecodeRune(hi, r))
+					continue
+				}
+				t.out = appendRune(t.out, utf8.RuneError)
+			}
+			if r >= 0xd800 && r <= 0xdbff {
+				t.hi = r
+				continue
+			}
+		}
+		t.out = appendRune(t.out, r)
+	}
+	t.in = append(t.in[:0], t.in[i:]...)
+}
+
+// flush transcodes the remaining input at the end of the input.
+func (t *transcoder) flush() {
+	t.transcode()
+	if t.hi != 0 || len(t.in) > 0 {
+		t.out = appendRune(t.out, utf8.RuneError)
+		t.hi = 0
+		t.in = t.in[:0]
+	}
+}
+
+func appendRune(p []byte, r rune) []byte {
+	var buf [utf8.UTFMax]byte
+	return append(p, buf[:utf8.EncodeRune(buf[:], r)]...)
+}
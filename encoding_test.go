@@ -0,0 +1,100 @@
+// Copyright 2014 Gary Burd. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package json
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"unicode/utf16"
+)
+
+func encodeUTF16(s string, order binary.ByteOrder, bom bool) []byte {
+	var buf bytes.Buffer
+	units := utf16.Encode([]rune(s))
+	if bom {
+		units = append([]uint16{0xfeff}, units...)
+	}
+	for _, u := range units {
+		binary.Write(&buf, order, u)
+	}
+	return buf.Bytes()
+}
+
+func encodeUTF32(s string, order binary.ByteOrder, bom bool) []byte {
+	var buf bytes.Buffer
+	runes := []rune(s)
+	if bom {
+		runes = append([]rune{0xfeff}, runes...)
+	}
+	for _, r := range runes {
+		binary.Write(&buf, order, uint32(r))
+	}
+	return buf.Bytes()
+}
+
+func TestDetectEncoding(t *testing.T) {
+	const doc = `{"a": ["é𝄞", 1]}`
+	inputs := [][]byte{
+		[]byte(doc),
+		[]byte("\xef\xbb\xbf" + doc),
+		encodeUTF16(doc, binary.BigEndian, false),
+		encodeUTF16(doc, binary.BigEndian, true),
+		encodeUTF16(doc, binary.LittleEndian, false),
+		encodeUTF16(doc, binary.LittleEndian, true),
+		encodeUTF32(doc, binary.BigEndian, false),
+		encodeUTF32(doc, binary.BigEndian, true),
+		encodeUTF32(doc, binary.LittleEndian, false),
+		encodeUTF32(doc, binary.LittleEndian, true),
+	}
+	want := []scan{
+		{k: Object},
+		{k: Array, n: "a"},
+		{k: String, v: "é𝄞"},
+		{k: Number, v: "1"},
+		{k: End},
+		{k: End},
+		{k: -1},
+	}
+	for _, in := range inputs {
+		for _, s := range []*Scanner{
+			NewScannerBytes(in),
+			NewScanner(iotest.OneByteReader(bytes.NewReader(in))),
+		} {
+			s.DetectEncoding()
+			checkScans(t, s, string(in), want)
+		}
+	}
+}
+
+func TestDetectEncodingShort(t *testing.T) {
+	for _, in := range [][]byte{[]byte("1"), []byte("12"), encodeUTF16("1", binary.LittleEndian, false), encodeUTF16("1", binary.BigEndian, false)} {
+		s := NewScanner(bytes.NewReader(in))
+		s.DetectEncoding()
+		if !s.Scan() || s.Kind() != Number {
+			t.Errorf("%q: got %v, %v", in, s.Kind(), s.Err())
+		}
+	}
+}
+
+func TestUTF8BOM(t *testing.T) {
+	for _, s := range []*Scanner{
+		NewScanner(strings.NewReader("\xef\xbb\xbf[1]")),
+		NewScannerBytes([]byte("\xef\xbb\xbf[1]")),
+	} {
+		checkScans(t, s, "bom", []scan{{k: Array}, {k: Number, v: "1"}, {k: End}, {k: -1}})
+	}
+
+	s := NewScannerBytes([]byte("\xef\xbb\xbf1\n\xef\xbb\xbf2"))
+	s.ExpectLines()
+	checkScans(t, s, "lines", []scan{{k: Number, v: "1"}, {k: -1, e: "expected start of JSON value, found 'ï'"}})
+
+	s = NewScannerBytes([]byte("\xef\xbb1"))
+	if s.Scan() || s.Err() == nil {
+		t.Errorf("expected error for invalid byte order mark")
+	}
+}
@@ -0,0 +1,59 @@
+// Copyright 2014 Gary Burd. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package json
+
+import "context"
+
+// Event is a copy of a scanner element. Unlike the slices returned by the
+// scanner's Name and Value methods, the fields of an Event are not
+// overwritten by later calls to Scan.
+type Event struct {
+	Kind  Kind
+	Name  string // object member name, "" if the element is not in an object
+	Value []byte // copy of the element's value, nil for arrays, objects and End
+	Level int    // nesting level of the element, see Scanner.NestingLevel
+}
+
+// Bool returns true if the event is the JSON boolean true.
+func (e Event) Bool() bool {
+	return e.Kind == Bool && e.Value[0] == 't'
+}
+
+// Snapshot returns a copy of the current element that remains valid after
+// the next call to Scan. Use Snapshot to retain elements; the slices returned
+// by Name and Value are overwritten by Scan.
+func (s *Scanner) Snapshot() Event {
+	e := Event{Kind: s.kind, Level: s.NestingLevel()}
+	if s.data[nameData].pos >= 0 {
+		e.Name = s.NameString()
+	}
+	switch e.Kind {
+	case Null, Bool, String, Number:
+		e.Value = append([]byte{}, s.Value()...)
+	}
+	return e
+}
+
+// Events scans the input in a new goroutine and sends each element to the
+// returned channel. The channel is closed at the end of the input, on error
+// and when ctx is done. The application must not call other scanner methods
+// until the channel is closed. After the channel is closed, Err returns the
+// scanning error or the context's error.
+func (s *Scanner) Events(ctx context.Context) <-chan Event {
+	ch := make(chan Event, 64)
+	go func() {
+		defer close(ch)
+		for s.Scan() {
+			e := s.Snapshot()
+			select {
+			case ch <- e:
+			case <-ctx.Done():
+				s.err = ctx.Err()
+				return
+			}
+		}
+	}()
+	return ch
+}
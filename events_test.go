@@ -0,0 +1,84 @@
+// Copyright 2014 Gary Burd. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package json
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestEvents(t *testing.T) {
+	s := NewScanner(readerOnly{strings.NewReader(`{"a": [1, "x\n"], "bA": true}`)})
+	var got []Event
+	for e := range s.Events(context.Background()) {
+		got = append(got, e)
+	}
+	if err := s.Err(); err != nil {
+		t.Fatal(err)
+	}
+	want := []Event{
+		{Kind: Object, Level: 2},
+		{Kind: Array, Name: "a", Level: 3},
+		{Kind: Number, Value: []byte("1"), Level: 3},
+		{Kind: String, Value: []byte("x\n"), Level: 3},
+		{Kind: End, Level: 2},
+		{Kind: Bool, Name: "bA", Value: []byte("true"), Level: 2},
+		{Kind: End, Level: 1},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got  %+v\nwant %+v", got, want)
+	}
+}
+
+func TestEventsCancel(t *testing.T) {
+	s := NewScannerBytes([]byte("[" + strings.Repeat("1,", 1000) + "1]"))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	n := 0
+	for range s.Events(ctx) {
+		n++
+		if n == 10 {
+			cancel()
+		}
+	}
+	if n > 10+64+1 {
+		t.Errorf("received %d events after cancel", n)
+	}
+	if s.Err() != context.Canceled {
+		t.Errorf("got error %v, want %v", s.Err(), context.Canceled)
+	}
+}
+
+func TestSnapshot(t *testing.T) {
+	const input = `[{"ab": "x\ty"}, true, false, null, ` + `"` + "0123456789abcdef0123456789abcdef" + `"]`
+	s := NewScanner(readerOnly{strings.NewReader(input)})
+	s.Buffer(make([]byte, 0, 16), 1024)
+	var got []Event
+	for s.Scan() {
+		got = append(got, s.Snapshot())
+	}
+	if err := s.Err(); err != nil {
+		t.Fatal(err)
+	}
+	want := []Event{
+		{Kind: Array, Level: 2},
+		{Kind: Object, Level: 3},
+		{Kind: String, Name: "ab", Value: []byte("x\ty"), Level: 3},
+		{Kind: End, Level: 2},
+		{Kind: Bool, Value: []byte("true"), Level: 2},
+		{Kind: Bool, Value: []byte("false"), Level: 2},
+		{Kind: Null, Value: []byte("null"), Level: 2},
+		{Kind: String, Value: []byte("0123456789abcdef0123456789abcdef"), Level: 2},
+		{Kind: End, Level: 1},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got  %+v\nwant %+v", got, want)
+	}
+	if !got[4].Bool() || got[5].Bool() || got[6].Bool() {
+		t.Error("Bool returned wrong value")
+	}
+}
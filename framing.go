@@ -0,0 +1,54 @@
+// Copyright 2014 Gary Burd. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package json
+
+// Framing specifies how top-level values are delimited in a stream.
+type Framing int
+
+const (
+	// FrameSingle is a single top-level value.
+	FrameSingle Framing = iota
+
+	// FrameConcatenated is a stream of top-level values separated by
+	// optional whitespace.
+	FrameConcatenated
+
+	// FrameLines is newline-delimited JSON. Each value is on a single line.
+	FrameLines
+
+	// FrameSeq is an RFC 7464 JSON text sequence. Each value is preceded by
+	// an ASCII record separator (0x1E) and followed by a newline.
+	FrameSeq
+)
+
+const recordSeparator = 0x1e
+
+// SetFraming sets how the scanner delimits top-level values. SetFraming
+// must be called before the first call to Scan. The FrameConcatenated and
+// FrameLines framings are equivalent to AllowMultiple and ExpectLines.
+func (s *Scanner) SetFraming(f Framing) {
+	s.lines = false
+	s.multiple = false
+	s.seq = false
+	switch f {
+	case FrameConcatenated:
+		s.AllowMultiple()
+	case FrameLines:
+		s.ExpectLines()
+	case FrameSeq:
+		s.seq = true
+		s.top((*Scanner).stateSeq)
+	default:
+		s.top((*Scanner).stateSingleStart)
+	}
+}
+
+// SetFraming sets how the writer delimits top-level values. With a framing
+// other than FrameSingle, the writer ends each top-level value with a
+// newline and EndDocument does not write output. With FrameSeq, the writer
+// also writes a record separator before each top-level value.
+func (w *Writer) SetFraming(f Framing) {
+	w.framing = f
+}
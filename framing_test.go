@@ -0,0 +1,93 @@
+// Copyright 2014 Gary Burd. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package json
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+var seqTests = []struct {
+	s     string
+	scans []scan
+}{
+	{"", []scan{eof}},
+	{"\x1e1\n\x1e\"a\"\n", []scan{{k: Number, v: "1"}, {k: String, v: "a"}, eof}},
+	{"\x1e{\"a\":\n[1]}\n\x1e\x1e true\n", []scan{{k: Object}, {k: Array, n: "a"}, {k: Number, v: "1"}, {k: End}, {k: End}, {k: Bool, v: "true"}, eof}},
+	{"\x1e1\x1e2", []scan{{k: Number, v: "1"}, {k: Number, v: "2"}, eof}},
+	{"1\n", []scan{syntaxError('1', expectRecordSeparator)}},
+	{"\x1e1 2\n", []scan{{k: Number, v: "1"}, syntaxError('2', expectRecordSeparator)}},
+}
+
+func TestFrameSeq(t *testing.T) {
+	for _, tt := range seqTests {
+		s := NewScanner(iotest.OneByteReader(strings.NewReader(tt.s)))
+		s.SetFraming(FrameSeq)
+		checkScans(t, s, tt.s, tt.scans)
+	}
+}
+
+func TestFrameSeqResync(t *testing.T) {
+	s := NewScanner(strings.NewReader("\x1e[1,\n\x1e2\n\x1e{x}\n\x1e3\n"))
+	s.SetFraming(FrameSeq)
+	var got []string
+	for {
+		for s.Scan() {
+			if s.Kind() == Number {
+				got = append(got, string(s.Value()))
+			}
+		}
+		if s.Err() == nil || !s.Resync() {
+			break
+		}
+	}
+	if s.Err() != nil {
+		t.Fatal(s.Err())
+	}
+	if strings.Join(got, ",") != "1,2,3" {
+		t.Errorf("got %v, want [1 2 3]", got)
+	}
+}
+
+func TestWriteFraming(t *testing.T) {
+	for _, tt := range []struct {
+		f    Framing
+		want string
+	}{
+		{FrameSingle, `1`},
+		{FrameConcatenated, "1\n{\"a\":[]}\n\"x\"\n"},
+		{FrameLines, "1\n{\"a\":[]}\n\"x\"\n"},
+		{FrameSeq, "\x1e1\n\x1e{\"a\":[]}\n\x1e\"x\"\n"},
+	} {
+		var buf bytes.Buffer
+		w := NewWriter(&buf)
+		w.SetFraming(tt.f)
+		w.Int(1)
+		w.StartObject()
+		w.Name("a")
+		w.StartArray()
+		w.EndArray()
+		w.EndObject()
+		w.String("x")
+		if got := buf.String(); got != tt.want {
+			t.Errorf("framing %d: got %q, want %q", tt.f, got, tt.want)
+		}
+
+		s := NewScannerBytes(buf.Bytes())
+		s.SetFraming(tt.f)
+		if tt.f == FrameSingle {
+			continue
+		}
+		n := 0
+		for s.Scan() {
+			n++
+		}
+		if s.Err() != nil || n != 6 {
+			t.Errorf("framing %d: scanned %d elements, err %v", tt.f, n, s.Err())
+		}
+	}
+}
@@ -0,0 +1,411 @@
+// Copyright 2014 Gary Burd. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package geojson reads the features of a GeoJSON (RFC 7946)
+// FeatureCollection one at a time:
+//
+//  r := geojson.NewReader(f)
+//  for r.Next() {
+//      feature := r.Feature()
+//      // handle feature
+//  }
+//  if err := r.Err(); err != nil {
+//      // handle error
+//  }
+//
+// The reader holds one feature in memory at a time. Members of the
+// FeatureCollection other than "type" and "features" are skipped.
+package geojson
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/garyburd/json"
+)
+
+// Position is a longitude, latitude and optional altitude.
+type Position []float64
+
+// Geometry is a GeoJSON geometry object. The field that holds the
+// coordinates depends on the geometry type.
+type Geometry struct {
+	Type string
+
+	Point           Position       // Point
+	MultiPoint      []Position     // MultiPoint
+	LineString      []Position     // LineString
+	MultiLineString [][]Position   // MultiLineString
+	Polygon         [][]Position   // Polygon
+	MultiPolygon    [][][]Position // MultiPolygon
+	Geometries      []*Geometry    // GeometryCollection
+}
+
+// Feature is a GeoJSON feature object.
+type Feature struct {
+	ID         interface{} // string, json.NumberValue or nil if absent
+	Geometry   *Geometry   // nil if the geometry is null
+	Properties map[string]interface{}
+	BBox       []float64
+}
+
+// Reader reads the features of a FeatureCollection.
+type Reader struct {
+	s       *json.Scanner
+	level   int // nesting level of the features array or zero
+	state   int
+	feature *Feature
+	err     error
+}
+
+const (
+	stateStart    = iota // before the collection
+	stateFeatures        // in the features array
+	stateDone            // after the features array
+)
+
+// NewReader returns a reader for the FeatureCollection in r.
+func NewReader(r io.Reader) *Reader {
+	return NewScannerReader(json.NewScanner(r))
+}
+
+// NewScannerReader returns a reader for the FeatureCollection in the input
+// of s. Options such as limits can be set on the scanner before the first
+// call to Next.
+func NewScannerReader(s *json.Scanner) *Reader {
+	return &Reader{s: s}
+}
+
+// Next advances the reader to the next feature. Next returns false at the
+// end of the collection or on error.
+func (r *Reader) Next() bool {
+	r.feature = nil
+	if r.err != nil {
+		return false
+	}
+	switch r.state {
+	case stateStart:
+		if !r.start() {
+			return false
+		}
+	case stateDone:
+		return false
+	}
+	s := r.s
+	if !s.ScanAtLevel(r.level) {
+		if r.fail(s.Err()) {
+			return false
+		}
+		r.state = stateDone
+		r.finish()
+		return false
+	}
+	f, err := readFeature(s)
+	if r.fail(err) {
+		return false
+	}
+	r.feature = f
+	return true
+}
+
+// Feature returns the current feature.
+func (r *Reader) Feature() *Feature {
+	return r.feature
+}
+
+// Err returns the first error encountered by the reader.
+func (r *Reader) Err() error {
+	return r.err
+}
+
+// fail records err if not nil and returns true if the reader has an error.
+func (r *Reader) fail(err error) bool {
+	if err != nil && r.err == nil {
+		r.err = err
+	}
+	return r.err != nil
+}
+
+// start scans the collection members up to the start of the features array.
+func (r *Reader) start() bool {
+	s := r.s
+	if !s.Scan() {
+		r.fail(s.Err())
+		r.fail(io.ErrUnexpectedEOF)
+		return false
+	}
+	if s.Kind() != json.Object {
+		r.fail(fmt.Errorf("unexpected %v, expected FeatureCollection object", s.Kind()))
+		return false
+	}
+	if r.members(s.NestingLevel()) {
+		r.state = stateFeatures
+		return true
+	}
+	if !r.fail(s.Err()) {
+		r.fail(errors.New("features not found"))
+	}
+	return false
+}
+
+// members scans the collection members at level. The function returns true
+// when positioned at the start of the features array.
+func (r *Reader) members(level int) bool {
+	s := r.s
+	for s.ScanAtLevel(level) {
+		switch {
+		case s.NameIs("type"):
+			if s.Kind() != json.String || string(s.Value()) != "FeatureCollection" {
+				r.fail(fmt.Errorf("type is %s, expected FeatureCollection", describe(s)))
+				return false
+			}
+		case s.NameIs("features") && r.level == 0:
+			if s.Kind() != json.Array {
+				r.fail(fmt.Errorf("unexpected %v, expected features array", s.Kind()))
+				return false
+			}
+			r.level = s.NestingLevel()
+			return true
+		}
+	}
+	return false
+}
+
+// finish scans the collection members following the features array.
+func (r *Reader) finish() {
+	r.members(r.level - 1)
+	r.fail(r.s.Err())
+}
+
+// describe returns a description of the current value for error messages.
+func describe(s *json.Scanner) string {
+	if s.Kind() == json.String {
+		return fmt.Sprintf("%q", s.Value())
+	}
+	return s.Kind().String()
+}
+
+func readFeature(s *json.Scanner) (*Feature, error) {
+	if s.Kind() != json.Object {
+		return nil, fmt.Errorf("unexpected %v, expected Feature object", s.Kind())
+	}
+	f := &Feature{}
+	level := s.NestingLevel()
+	for s.ScanAtLevel(level) {
+		var err error
+		switch {
+		case s.NameIs("type"):
+			if s.Kind() != json.String || string(s.Value()) != "Feature" {
+				err = fmt.Errorf("type is %s, expected Feature", describe(s))
+			}
+		case s.NameIs("id"):
+			switch s.Kind() {
+			case json.String:
+				f.ID = string(s.Value())
+			case json.Number:
+				f.ID = json.NumberValue(s.Value())
+			default:
+				err = fmt.Errorf("unexpected %v for feature id", s.Kind())
+			}
+		case s.NameIs("geometry"):
+			if s.Kind() != json.Null {
+				f.Geometry, err = readGeometry(s)
+			}
+		case s.NameIs("properties"):
+			var v interface{}
+			v, err = json.DecodeValue(s)
+			if m, ok := v.(map[string]interface{}); ok {
+				f.Properties = m
+			} else if err == nil && v != nil {
+				err = errors.New("properties is not an object or null")
+			}
+		case s.NameIs("bbox"):
+			f.BBox, err = readNumbers(s)
+		default:
+			err = s.Skip()
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return f, s.Err()
+}
+
+// readGeometry reads the geometry object at the scanner's current element.
+// The coordinates are read before converting them for the geometry type
+// because the coordinates member can precede the type member.
+func readGeometry(s *json.Scanner) (*Geometry, error) {
+	if s.Kind() != json.Object {
+		return nil, fmt.Errorf("unexpected %v, expected geometry object", s.Kind())
+	}
+	g := &Geometry{}
+	var coords *coordinates
+	level := s.NestingLevel()
+	for s.ScanAtLevel(level) {
+		var err error
+		switch {
+		case s.NameIs("type"):
+			if s.Kind() != json.String {
+				return nil, fmt.Errorf("unexpected %v for geometry type", s.Kind())
+			}
+			g.Type = string(s.Value())
+		case s.NameIs("coordinates"):
+			coords, err = readCoordinates(s)
+		case s.NameIs("geometries"):
+			if s.Kind() != json.Array {
+				return nil, fmt.Errorf("unexpected %v for geometries", s.Kind())
+			}
+			n := s.NestingLevel()
+			for s.ScanAtLevel(n) {
+				child, err := readGeometry(s)
+				if err != nil {
+					return nil, err
+				}
+				g.Geometries = append(g.Geometries, child)
+			}
+		default:
+			err = s.Skip()
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	if g.Type == "GeometryCollection" {
+		return g, nil
+	}
+	if coords == nil {
+		return nil, fmt.Errorf("coordinates not found for %s geometry", g.Type)
+	}
+	var ok bool
+	switch g.Type {
+	case "Point":
+		g.Point, ok = coords.position()
+	case "MultiPoint":
+		g.MultiPoint, ok = coords.positions()
+	case "LineString":
+		g.LineString, ok = coords.positions()
+	case "MultiLineString":
+		g.MultiLineString, ok = coords.positions2()
+	case "Polygon":
+		g.Polygon, ok = coords.positions2()
+	case "MultiPolygon":
+		g.MultiPolygon, ok = coords.positions3()
+	default:
+		return nil, fmt.Errorf("unknown geometry type %q", g.Type)
+	}
+	if !ok {
+		return nil, fmt.Errorf("invalid coordinates for %s geometry", g.Type)
+	}
+	return g, nil
+}
+
+// coordinates is a position or an array of coordinates.
+type coordinates struct {
+	pos      Position // position if children is nil
+	children []*coordinates
+}
+
+// readCoordinates reads the array at the scanner's current element.
+func readCoordinates(s *json.Scanner) (*coordinates, error) {
+	if s.Kind() != json.Array {
+		return nil, fmt.Errorf("unexpected %v, expected coordinates array", s.Kind())
+	}
+	c := &coordinates{children: []*coordinates{}}
+	level := s.NestingLevel()
+	for s.ScanAtLevel(level) {
+		switch s.Kind() {
+		case json.Number:
+			if len(c.children) > 0 {
+				return nil, errors.New("coordinates mix numbers and arrays")
+			}
+			f, err := s.Float64()
+			if err != nil {
+				return nil, err
+			}
+			c.pos = append(c.pos, f)
+		case json.Array:
+			if c.pos != nil {
+				return nil, errors.New("coordinates mix numbers and arrays")
+			}
+			child, err := readCoordinates(s)
+			if err != nil {
+				return nil, err
+			}
+			c.children = append(c.children, child)
+		default:
+			return nil, fmt.Errorf("unexpected %v in coordinates", s.Kind())
+		}
+	}
+	if c.pos != nil {
+		c.children = nil
+	}
+	return c, s.Err()
+}
+
+func (c *coordinates) position() (Position, bool) {
+	return c.pos, c.children == nil && len(c.pos) >= 2
+}
+
+func (c *coordinates) positions() ([]Position, bool) {
+	if c.children == nil {
+		return nil, false
+	}
+	p := make([]Position, len(c.children))
+	for i, child := range c.children {
+		var ok bool
+		if p[i], ok = child.position(); !ok {
+			return nil, false
+		}
+	}
+	return p, true
+}
+
+func (c *coordinates) positions2() ([][]Position, bool) {
+	if c.children == nil {
+		return nil, false
+	}
+	p := make([][]Position, len(c.children))
+	for i, child := range c.children {
+		var ok bool
+		if p[i], ok = child.positions(); !ok {
+			return nil, false
+		}
+	}
+	return p, true
+}
+
+func (c *coordinates) positions3() ([][][]Position, bool) {
+	if c.children == nil {
+		return nil, false
+	}
+	p := make([][][]Position, len(c.children))
+	for i, child := range c.children {
+		var ok bool
+		if p[i], ok = child.positions2(); !ok {
+			return nil, false
+		}
+	}
+	return p, true
+}
+
+// readNumbers reads an array of numbers.
+func readNumbers(s *json.Scanner) ([]float64, error) {
+	if s.Kind() != json.Array {
+		return nil, fmt.Errorf("unexpected %v, expected array of numbers", s.Kind())
+	}
+	p := []float64{}
+	level := s.NestingLevel()
+	for s.ScanAtLevel(level) {
+		f, err := s.Float64()
+		if err != nil {
+			return nil, err
+		}
+		p = append(p, f)
+	}
+	return p, s.Err()
+}
@@ -0,0 +1,98 @@
+// Copyright 2014 Gary Burd. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package geojson
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/garyburd/json"
+)
+
+const testCollection = `{
+	"type": "FeatureCollection",
+	"bbox": [0, 0, 10, 10],
+	"features": [
+		{"type": "Feature", "id": "a", "geometry": {"type": "Point", "coordinates": [1, 2]}, "properties": {"name": "x"}},
+		{"type": "Feature", "id": 7, "geometry": {"coordinates": [[1, 2], [3, 4.5]], "type": "LineString"}, "properties": null},
+		{"type": "Feature", "geometry": {"type": "Polygon", "coordinates": [[[0, 0], [1, 0], [1, 1], [0, 0]]]}, "bbox": [0, 0, 1, 1]},
+		{"type": "Feature", "geometry": {"type": "MultiPolygon", "coordinates": [[[[0, 0, 5], [1, 0], [0, 0]]]]}},
+		{"type": "Feature", "geometry": {"type": "GeometryCollection", "geometries": [{"type": "MultiPoint", "coordinates": [[1, 1]]}]}},
+		{"type": "Feature", "geometry": null, "foreign": {"a": [1]}}
+	],
+	"crs": {"type": "name"}
+}`
+
+func TestReader(t *testing.T) {
+	want := []*Feature{
+		{ID: "a", Geometry: &Geometry{Type: "Point", Point: Position{1, 2}}, Properties: map[string]interface{}{"name": "x"}},
+		{ID: json.NumberValue("7"), Geometry: &Geometry{Type: "LineString", LineString: []Position{{1, 2}, {3, 4.5}}}},
+		{Geometry: &Geometry{Type: "Polygon", Polygon: [][]Position{{{0, 0}, {1, 0}, {1, 1}, {0, 0}}}}, BBox: []float64{0, 0, 1, 1}},
+		{Geometry: &Geometry{Type: "MultiPolygon", MultiPolygon: [][][]Position{{{{0, 0, 5}, {1, 0}, {0, 0}}}}}},
+		{Geometry: &Geometry{Type: "GeometryCollection", Geometries: []*Geometry{{Type: "MultiPoint", MultiPoint: []Position{{1, 1}}}}}},
+		{},
+	}
+	r := NewReader(strings.NewReader(testCollection))
+	var got []*Feature
+	for r.Next() {
+		got = append(got, r.Feature())
+	}
+	if err := r.Err(); err != nil {
+		t.Fatalf("Err() = %v", err)
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d features, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if !reflect.DeepEqual(got[i], want[i]) {
+			t.Errorf("feature %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+	if r.Next() {
+		t.Error("Next() after end returned true")
+	}
+}
+
+func TestReaderEmpty(t *testing.T) {
+	r := NewReader(strings.NewReader(`{"features": [], "type": "FeatureCollection"}`))
+	if r.Next() {
+		t.Fatal("Next() returned true")
+	}
+	if err := r.Err(); err != nil {
+		t.Fatalf("Err() = %v", err)
+	}
+}
+
+var readerErrorTests = []struct {
+	in  string
+	err string
+}{
+	{`[]`, "expected FeatureCollection object"},
+	{`{"type": "Feature"}`, "expected FeatureCollection"},
+	{`{"type": "FeatureCollection"}`, "features not found"},
+	{`{"features": {}}`, "expected features array"},
+	{`{"features": [1]}`, "expected Feature object"},
+	{`{"features": [{"type": "Point"}]}`, "expected Feature"},
+	{`{"features": [{"geometry": {"type": "Circle", "coordinates": [1, 2]}}]}`, "unknown geometry type"},
+	{`{"features": [{"geometry": {"type": "Point"}}]}`, "coordinates not found"},
+	{`{"features": [{"geometry": {"type": "Point", "coordinates": [[1, 2]]}}]}`, "invalid coordinates for Point"},
+	{`{"features": [{"geometry": {"type": "Polygon", "coordinates": [[1, 2]]}}]}`, "invalid coordinates for Polygon"},
+	{`{"features": [{"geometry": {"type": "LineString", "coordinates": [[1, [2]]]}}]}`, "mix numbers and arrays"},
+	{`{"features": [{"properties": 1}]}`, "properties is not an object"},
+	{`{"features": [], "type": "Topology"}`, "expected FeatureCollection"},
+}
+
+func TestReaderError(t *testing.T) {
+	for _, tt := range readerErrorTests {
+		r := NewReader(strings.NewReader(tt.in))
+		for r.Next() {
+		}
+		err := r.Err()
+		if err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("%s: Err() = %v, want error containing %q", tt.in, err, tt.err)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+// Copyright 2014 Gary Burd. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package json
+
+import (
+	stdjson "encoding/json"
+	"errors"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// DefaultMaxRequestBytes is the request body size limit used by
+// DecodeRequest.
+const DefaultMaxRequestBytes = 1 << 20
+
+// DecodeRequest decodes the JSON body of r to the value pointed to by v as
+// DecodeRequestLimit does with a limit of DefaultMaxRequestBytes.
+func DecodeRequest(r *http.Request, v interface{}) error {
+	return DecodeRequestLimit(r, v, DefaultMaxRequestBytes)
+}
+
+// DecodeRequestLimit decodes the JSON body of r to the value pointed to by v
+// using Unmarshal. The request content type must be application/json, a
+// type with the +json suffix or not specified. If the request body is larger
+// than maxBytes, then DecodeRequestLimit returns a *LimitError.
+func DecodeRequestLimit(r *http.Request, v interface{}, maxBytes int64) error {
+	if ct := r.Header.Get("Content-Type"); ct != "" {
+		t, _, err := mime.ParseMediaType(ct)
+		if err != nil || (t != "application/json" && !strings.HasSuffix(t, "+json")) {
+			return errors.New("unsupported content type " + ct)
+		}
+	}
+	if r.ContentLength > maxBytes {
+		return &LimitError{"request body", maxBytes}
+	}
+	if r.Body == nil {
+		return errors.New("missing request body")
+	}
+	s := NewScanner(r.Body)
+	s.SetMaxBytes(maxBytes)
+	if !s.Scan() {
+		if err := s.Err(); err != nil {
+			return err
+		}
+		return errors.New("empty request body")
+	}
+	if err := Unmarshal(s, v); err != nil {
+		return err
+	}
+	s.Scan()
+	return s.Err()
+}
+
+// EncodeResponse writes v as the JSON body of a response with the given
+// status code. EncodeResponse sets the Content-Type header to
+// application/json if the header is not already set. If v implements
+// Marshaler, then the body is streamed with the value's EncodeJSON method.
+// Otherwise, v is encoded with the encoding/json package before the header is
+// written.
+func EncodeResponse(w http.ResponseWriter, status int, v interface{}) error {
+	if w.Header().Get("Content-Type") == "" {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	}
+	if m, ok := v.(Marshaler); ok {
+		w.WriteHeader(status)
+		jw := NewWriter(w)
+		if err := m.EncodeJSON(jw); err != nil {
+			return err
+		}
+		return jw.EndDocument()
+	}
+	p, err := stdjson.Marshal(v)
+	if err != nil {
+		return err
+	}
+	w.WriteHeader(status)
+	jw := NewWriter(w)
+	if err := jw.Raw(p); err != nil {
+		return err
+	}
+	return jw.EndDocument()
+}
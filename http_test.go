@@ -0,0 +1,91 @@
+// Copyright 2014 Gary Burd. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package json
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestDecodeRequest(t *testing.T) {
+	r := httptest.NewRequest("POST", "/", strings.NewReader(`{"A":1,"b":"x"}`))
+	r.Header.Set("Content-Type", "application/json; charset=utf-8")
+	var v unmarshalStruct
+	if err := DecodeRequest(r, &v); err != nil {
+		t.Fatal(err)
+	}
+	if v.A != 1 || v.B != "x" {
+		t.Errorf("got %+v", v)
+	}
+
+	for _, tt := range []struct {
+		ct   string
+		body string
+	}{
+		{"text/plain", `{}`},
+		{"", `{} {}`},
+		{"", ``},
+		{"application/problem+json", `[` + strings.Repeat(`1,`, 100) + `1]`},
+	} {
+		r := httptest.NewRequest("POST", "/", strings.NewReader(tt.body))
+		if tt.ct != "" {
+			r.Header.Set("Content-Type", tt.ct)
+		}
+		var v interface{}
+		if err := DecodeRequestLimit(r, &v, 100); err == nil {
+			t.Errorf("%s %q: no error", tt.ct, tt.body)
+		}
+	}
+
+	r = httptest.NewRequest("POST", "/", strings.NewReader(`[1]`))
+	r.ContentLength = 1000
+	var i []int
+	if _, ok := DecodeRequestLimit(r, &i, 100).(*LimitError); !ok {
+		t.Error("Content-Length over limit did not return *LimitError")
+	}
+}
+
+type testPoint struct{ X, Y int }
+
+func (p testPoint) EncodeJSON(w *Writer) error {
+	w.StartArray()
+	w.Int(int64(p.X))
+	w.Int(int64(p.Y))
+	return w.EndArray()
+}
+
+func TestEncodeResponse(t *testing.T) {
+	for _, tt := range []struct {
+		v    interface{}
+		want string
+	}{
+		{testPoint{1, 2}, "[1,2]\n"},
+		{map[string]int{"a": 1}, "{\"a\":1}\n"},
+	} {
+		rec := httptest.NewRecorder()
+		if err := EncodeResponse(rec, http.StatusCreated, tt.v); err != nil {
+			t.Fatal(err)
+		}
+		if rec.Code != http.StatusCreated {
+			t.Errorf("status = %d, want %d", rec.Code, http.StatusCreated)
+		}
+		if ct := rec.Header().Get("Content-Type"); ct != "application/json; charset=utf-8" {
+			t.Errorf("Content-Type = %q", ct)
+		}
+		if got := rec.Body.String(); got != tt.want {
+			t.Errorf("body = %q, want %q", got, tt.want)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	if err := EncodeResponse(rec, http.StatusOK, func() {}); err == nil {
+		t.Error("no error for unsupported value")
+	}
+	if rec.Code != http.StatusOK || rec.Body.Len() != 0 {
+		t.Errorf("response written for unsupported value")
+	}
+}
@@ -0,0 +1,273 @@
+// Copyright 2014 Gary Burd. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package jsonrpc2
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+
+	"github.com/garyburd/json"
+)
+
+// Transport sends messages to a server.
+type Transport interface {
+	// RoundTrip writes a message with write. If write returns an error,
+	// then RoundTrip discards the partially written message and returns
+	// the error. If read is not nil, then RoundTrip reads the response
+	// message with read. The scanner passed to read is positioned before
+	// the message.
+	RoundTrip(write func(w *json.Writer) error, read func(s *json.Scanner) error) error
+}
+
+// HTTPTransport sends messages as HTTP POST requests.
+type HTTPTransport struct {
+	URL string
+
+	// Client is the HTTP client. If nil, http.DefaultClient is used.
+	Client *http.Client
+}
+
+// RoundTrip implements the Transport interface.
+func (t *HTTPTransport) RoundTrip(write func(w *json.Writer) error, read func(s *json.Scanner) error) error {
+	var buf bytes.Buffer
+	w := json.NewWriter(&buf)
+	if err := write(w); err != nil {
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	c := t.Client
+	if c == nil {
+		c = http.DefaultClient
+	}
+	resp, err := c.Post(t.URL, "application/json", &buf)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("unexpected HTTP status %s", resp.Status)
+	}
+	if read == nil {
+		_, err := io.Copy(ioutil.Discard, resp.Body)
+		return err
+	}
+	return read(json.NewScanner(resp.Body))
+}
+
+// ConnTransport sends messages over a connection such as a net.Conn.
+// Messages are written as newline-delimited JSON. ConnTransport sends one
+// message at a time and waits for the response before sending the next.
+//
+// Each message is encoded to a buffer and sent with a single call to the
+// connection's Write method. A message that fails to encode is not sent. If
+// writing to or reading from the connection fails, the transport is broken
+// and RoundTrip returns the error for all later messages.
+type ConnTransport struct {
+	mu   sync.Mutex
+	s    *json.Scanner
+	conn io.Writer
+	buf  bytes.Buffer
+	err  error
+}
+
+// NewConnTransport returns a transport for conn.
+func NewConnTransport(conn io.ReadWriter) *ConnTransport {
+	t := &ConnTransport{s: json.NewScanner(conn), conn: conn}
+	t.s.SetFraming(json.FrameConcatenated)
+	return t
+}
+
+// RoundTrip implements the Transport interface.
+func (t *ConnTransport) RoundTrip(write func(w *json.Writer) error, read func(s *json.Scanner) error) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.err != nil {
+		return t.err
+	}
+	t.buf.Reset()
+	w := json.NewWriter(&t.buf)
+	w.SetFraming(json.FrameLines)
+	if err := write(w); err != nil {
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if _, err := t.conn.Write(t.buf.Bytes()); err != nil {
+		t.err = err
+		return err
+	}
+	if read == nil {
+		return nil
+	}
+	if err := read(t.s); err != nil {
+		// The scanner may be positioned in the middle of a message.
+		t.err = err
+		return err
+	}
+	return nil
+}
+
+// Call is a call in a batch.
+type Call struct {
+	Method string
+	Params interface{} // encoded as Handler results are, omitted if nil
+	Result interface{} // pointer to the result value or nil to discard the result
+	Notify bool        // send as a notification without a response
+	Err    error       // set by Client.Batch
+}
+
+// Client is a JSON-RPC client.
+type Client struct {
+	t  Transport
+	id int64
+}
+
+// NewClient returns a client that sends messages with t.
+func NewClient(t Transport) *Client {
+	return &Client{t: t}
+}
+
+// Call calls the method with the given params and decodes the result to the
+// value pointed to by result. If the server returns an error, then Call
+// returns an *Error.
+func (c *Client) Call(method string, params, result interface{}) error {
+	call := &Call{Method: method, Params: params, Result: result}
+	if err := c.send([]*Call{call}, false); err != nil {
+		return err
+	}
+	return call.Err
+}
+
+// Notify sends a notification. The server does not respond to notifications.
+func (c *Client) Notify(method string, params interface{}) error {
+	return c.send([]*Call{{Method: method, Params: params, Notify: true}}, false)
+}
+
+// Batch sends the calls as a batch. The error for each call is set in the
+// call's Err field. Batch returns an error if the batch could not be sent or
+// the response could not be read.
+func (c *Client) Batch(calls []*Call) error {
+	if len(calls) == 0 {
+		return errors.New("empty batch")
+	}
+	return c.send(calls, true)
+}
+
+func (c *Client) send(calls []*Call, batch bool) error {
+	pending := make(map[string]*Call)
+	ids := make([]int64, len(calls))
+	for i, call := range calls {
+		call.Err = nil
+		if !call.Notify {
+			ids[i] = atomic.AddInt64(&c.id, 1)
+			pending[strconv.FormatInt(ids[i], 10)] = call
+		}
+	}
+	write := func(w *json.Writer) error {
+		if batch {
+			if err := w.StartArray(); err != nil {
+				return err
+			}
+		}
+		for i, call := range calls {
+			if err := writeCall(w, call, ids[i]); err != nil {
+				return err
+			}
+		}
+		if batch {
+			if err := w.EndArray(); err != nil {
+				return err
+			}
+		}
+		return w.EndDocument()
+	}
+	if len(pending) == 0 {
+		return c.t.RoundTrip(write, nil)
+	}
+	read := func(s *json.Scanner) error {
+		if !s.Scan() {
+			if err := s.Err(); err != nil {
+				return err
+			}
+			return io.ErrUnexpectedEOF
+		}
+		if s.Kind() != json.Array {
+			return receive(s, pending)
+		}
+		level := s.NestingLevel()
+		for s.ScanAtLevel(level) {
+			if err := receive(s, pending); err != nil {
+				return err
+			}
+		}
+		return s.Err()
+	}
+	if err := c.t.RoundTrip(write, read); err != nil {
+		return err
+	}
+	for _, call := range pending {
+		call.Err = errors.New("no response for call")
+	}
+	return nil
+}
+
+// receive reads a response and sets the result of the matching call.
+func receive(s *json.Scanner, pending map[string]*Call) error {
+	resp, err := ReadResponse(s)
+	if err != nil {
+		return err
+	}
+	var key string
+	switch id := resp.ID.(type) {
+	case json.NumberValue:
+		key = string(id)
+	case nil:
+		if resp.Error != nil {
+			// The server could not read the request.
+			return resp.Error
+		}
+	}
+	call := pending[key]
+	if call == nil {
+		return fmt.Errorf("unexpected response id %v", resp.ID)
+	}
+	delete(pending, key)
+	switch {
+	case resp.Error != nil:
+		call.Err = resp.Error
+	case call.Result != nil:
+		call.Err = json.UnmarshalBytes(resp.Result, call.Result)
+	}
+	return nil
+}
+
+func writeCall(w *json.Writer, call *Call, id int64) error {
+	w.StartObject()
+	w.Name("jsonrpc")
+	w.String("2.0")
+	w.Name("method")
+	w.String(call.Method)
+	if call.Params != nil {
+		w.Name("params")
+		if err := encodeValue(w, call.Params); err != nil {
+			return err
+		}
+	}
+	if !call.Notify {
+		w.Name("id")
+		w.Int(id)
+	}
+	return w.EndObject()
+}
@@ -0,0 +1,326 @@
+// Copyright 2014 Gary Burd. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package jsonrpc2 implements JSON-RPC 2.0 clients and servers.
+//
+// Requests and responses are read with the json.Scanner and written with the
+// json.Writer. Batches are processed one request at a time as the batch is
+// scanned. The request params and response result are held as encoded JSON
+// and decoded by the application:
+//
+//  srv := jsonrpc2.NewServer()
+//  srv.Handle("add", func(r *jsonrpc2.Request) (interface{}, error) {
+//      var p []int
+//      if err := r.UnmarshalParams(&p); err != nil {
+//          return nil, &jsonrpc2.Error{Code: jsonrpc2.InvalidParams, Message: err.Error()}
+//      }
+//      return p[0] + p[1], nil
+//  })
+//  http.Handle("/rpc", srv)
+//
+// Clients send messages with a Transport. This package provides transports
+// for HTTP and for connections such as a net.Conn.
+package jsonrpc2
+
+import (
+	stdjson "encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/garyburd/json"
+)
+
+// Error codes defined by the specification.
+const (
+	ParseError     = -32700
+	InvalidRequest = -32600
+	MethodNotFound = -32601
+	InvalidParams  = -32602
+	InternalError  = -32603
+)
+
+// Error is a JSON-RPC error object.
+type Error struct {
+	Code    int
+	Message string
+	Data    []byte // encoded JSON value or nil
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("jsonrpc2 error %d: %s", e.Code, e.Message)
+}
+
+// EncodeJSON implements the json.Marshaler interface.
+func (e *Error) EncodeJSON(w *json.Writer) error {
+	w.StartObject()
+	w.Name("code")
+	w.Int(int64(e.Code))
+	w.Name("message")
+	w.String(e.Message)
+	if e.Data != nil {
+		w.Name("data")
+		w.Raw(e.Data)
+	}
+	return w.EndObject()
+}
+
+// Request is a JSON-RPC request or notification.
+type Request struct {
+	Method string
+	Params []byte // encoded JSON array or object, nil if absent
+
+	// ID is a string, json.NumberValue or nil. Notification is true if the
+	// request does not have an id member.
+	ID           interface{}
+	Notification bool
+}
+
+// UnmarshalParams decodes the request params to the value pointed to by v.
+func (r *Request) UnmarshalParams(v interface{}) error {
+	if r.Params == nil {
+		return errors.New("missing params")
+	}
+	return json.UnmarshalBytes(r.Params, v)
+}
+
+// EncodeJSON implements the json.Marshaler interface.
+func (r *Request) EncodeJSON(w *json.Writer) error {
+	w.StartObject()
+	w.Name("jsonrpc")
+	w.String("2.0")
+	w.Name("method")
+	w.String(r.Method)
+	if r.Params != nil {
+		w.Name("params")
+		w.Raw(r.Params)
+	}
+	if !r.Notification {
+		w.Name("id")
+		if err := w.Interface(r.ID); err != nil {
+			return err
+		}
+	}
+	return w.EndObject()
+}
+
+// Response is a JSON-RPC response.
+type Response struct {
+	ID     interface{} // string, json.NumberValue or nil
+	Result []byte      // encoded JSON value, nil if Error is set
+	Error  *Error
+}
+
+// UnmarshalResult decodes the response result to the value pointed to by v.
+// If the response is an error, then UnmarshalResult returns the error.
+func (r *Response) UnmarshalResult(v interface{}) error {
+	if r.Error != nil {
+		return r.Error
+	}
+	return json.UnmarshalBytes(r.Result, v)
+}
+
+// EncodeJSON implements the json.Marshaler interface.
+func (r *Response) EncodeJSON(w *json.Writer) error {
+	w.StartObject()
+	w.Name("jsonrpc")
+	w.String("2.0")
+	if r.Error != nil {
+		w.Name("error")
+		r.Error.EncodeJSON(w)
+	} else {
+		w.Name("result")
+		if r.Result == nil {
+			w.Null()
+		} else {
+			w.Raw(r.Result)
+		}
+	}
+	w.Name("id")
+	if err := w.Interface(r.ID); err != nil {
+		return err
+	}
+	return w.EndObject()
+}
+
+// ReadRequest reads the request object at the scanner's current element. If
+// the object is not a valid request, then ReadRequest scans to the end of the
+// object and returns an *Error with code InvalidRequest along with the
+// request fields read.
+func ReadRequest(s *json.Scanner) (*Request, error) {
+	if kind := s.Kind(); kind != json.Object {
+		if err := s.Skip(); err != nil {
+			return nil, err
+		}
+		return &Request{}, invalidRequest(fmt.Sprintf("unexpected %v, expected object", kind))
+	}
+	r := &Request{Notification: true}
+	var invalid string
+	hasMethod, hasVersion := false, false
+	level := s.NestingLevel()
+	for s.ScanAtLevel(level) {
+		var msg string
+		switch {
+		case s.NameIs("jsonrpc"):
+			hasVersion = true
+			if s.Kind() != json.String || string(s.Value()) != "2.0" {
+				msg = "jsonrpc is not 2.0"
+			}
+		case s.NameIs("method"):
+			hasMethod = true
+			if s.Kind() != json.String {
+				msg = "method is not a string"
+				break
+			}
+			r.Method = string(s.Value())
+		case s.NameIs("params"):
+			if s.Kind() != json.Array && s.Kind() != json.Object {
+				msg = "params is not an array or object"
+			}
+			p, err := s.SkipRaw()
+			if err != nil {
+				return nil, err
+			}
+			r.Params = append([]byte(nil), p...)
+		case s.NameIs("id"):
+			id, ok := readID(s)
+			if !ok {
+				msg = "id is not a string, number or null"
+				break
+			}
+			r.ID = id
+			r.Notification = false
+		default:
+			if err := s.Skip(); err != nil {
+				return nil, err
+			}
+		}
+		if invalid == "" {
+			invalid = msg
+		}
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	switch {
+	case invalid != "":
+	case !hasVersion:
+		invalid = "jsonrpc not found"
+	case !hasMethod:
+		invalid = "method not found"
+	default:
+		return r, nil
+	}
+	return r, invalidRequest(invalid)
+}
+
+// ReadResponse reads the response object at the scanner's current element.
+func ReadResponse(s *json.Scanner) (*Response, error) {
+	if s.Kind() != json.Object {
+		return nil, fmt.Errorf("unexpected %v, expected response object", s.Kind())
+	}
+	r := &Response{}
+	hasID := false
+	level := s.NestingLevel()
+	for s.ScanAtLevel(level) {
+		var err error
+		switch {
+		case s.NameIs("jsonrpc"):
+			if s.Kind() != json.String || string(s.Value()) != "2.0" {
+				err = errors.New("response jsonrpc is not 2.0")
+			}
+		case s.NameIs("result"):
+			var p []byte
+			p, err = s.SkipRaw()
+			r.Result = append([]byte(nil), p...)
+		case s.NameIs("error"):
+			r.Error, err = readError(s)
+		case s.NameIs("id"):
+			var ok bool
+			if r.ID, ok = readID(s); !ok {
+				err = errors.New("response id is not a string, number or null")
+			}
+			hasID = true
+		default:
+			err = s.Skip()
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	switch {
+	case !hasID:
+		return nil, errors.New("response id not found")
+	case (r.Result == nil) == (r.Error == nil):
+		return nil, errors.New("response must have one of result or error")
+	}
+	return r, nil
+}
+
+func readError(s *json.Scanner) (*Error, error) {
+	if s.Kind() != json.Object {
+		return nil, fmt.Errorf("unexpected %v, expected error object", s.Kind())
+	}
+	e := &Error{}
+	level := s.NestingLevel()
+	for s.ScanAtLevel(level) {
+		var err error
+		switch {
+		case s.NameIs("code"):
+			var code int64
+			if code, err = s.Int64(); err == nil {
+				e.Code = int(code)
+			}
+		case s.NameIs("message"):
+			if s.Kind() != json.String {
+				return nil, errors.New("error message is not a string")
+			}
+			e.Message = string(s.Value())
+		case s.NameIs("data"):
+			var p []byte
+			p, err = s.SkipRaw()
+			e.Data = append([]byte(nil), p...)
+		default:
+			err = s.Skip()
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return e, s.Err()
+}
+
+// readID returns the id at the scanner's current element.
+func readID(s *json.Scanner) (interface{}, bool) {
+	switch s.Kind() {
+	case json.String:
+		return string(s.Value()), true
+	case json.Number:
+		return json.NumberValue(s.Value()), true
+	case json.Null:
+		return nil, true
+	}
+	s.Skip()
+	return nil, false
+}
+
+func invalidRequest(msg string) *Error {
+	return &Error{Code: InvalidRequest, Message: msg}
+}
+
+// encodeValue writes v to w. If v implements json.Marshaler, then v is
+// written with the value's EncodeJSON method. Otherwise, v is encoded with the
+// encoding/json package.
+func encodeValue(w *json.Writer, v interface{}) error {
+	if m, ok := v.(json.Marshaler); ok {
+		return m.EncodeJSON(w)
+	}
+	p, err := stdjson.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return w.Raw(p)
+}
@@ -0,0 +1,138 @@
+// Copyright 2014 Gary Burd. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package jsonrpc2
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/garyburd/json"
+)
+
+var readRequestTests = []struct {
+	in   string
+	want *Request
+	err  string
+}{
+	{`{"jsonrpc": "2.0", "method": "add", "params": [1, 2], "id": 1}`,
+		&Request{Method: "add", Params: []byte(`[1, 2]`), ID: json.NumberValue("1")}, ""},
+	{`{"id": "a", "params": {"x": "y"}, "method": "m", "jsonrpc": "2.0"}`,
+		&Request{Method: "m", Params: []byte(`{"x": "y"}`), ID: "a"}, ""},
+	{`{"jsonrpc": "2.0", "method": "m"}`,
+		&Request{Method: "m", Notification: true}, ""},
+	{`{"jsonrpc": "2.0", "method": "m", "id": null, "x": [1]}`,
+		&Request{Method: "m"}, ""},
+	{`{"method": "m", "id": 1}`,
+		&Request{Method: "m", ID: json.NumberValue("1")}, "jsonrpc not found"},
+	{`{"jsonrpc": "2.0", "id": 1}`,
+		&Request{ID: json.NumberValue("1")}, "method not found"},
+	{`{"jsonrpc": "1.0", "method": "m"}`,
+		&Request{Method: "m", Notification: true}, "jsonrpc is not 2.0"},
+	{`{"jsonrpc": "2.0", "method": 1}`,
+		&Request{Notification: true}, "method is not a string"},
+	{`{"jsonrpc": "2.0", "method": "m", "params": 1}`,
+		&Request{Method: "m", Params: []byte(`1`), Notification: true}, "params is not an array or object"},
+	{`{"jsonrpc": "2.0", "method": "m", "id": {}}`,
+		&Request{Method: "m", Notification: true}, "id is not a string, number or null"},
+	{`[1]`, &Request{}, "unexpected array, expected object"},
+}
+
+func TestReadRequest(t *testing.T) {
+	for _, tt := range readRequestTests {
+		s := json.NewScannerBytes([]byte(tt.in))
+		s.Scan()
+		r, err := ReadRequest(s)
+		if tt.err == "" {
+			if err != nil {
+				t.Errorf("%s: error %v", tt.in, err)
+				continue
+			}
+		} else {
+			e, ok := err.(*Error)
+			if !ok || e.Code != InvalidRequest || e.Message != tt.err {
+				t.Errorf("%s: error %v, want InvalidRequest %q", tt.in, err, tt.err)
+				continue
+			}
+		}
+		if !reflect.DeepEqual(r, tt.want) {
+			t.Errorf("%s: got %+v, want %+v", tt.in, r, tt.want)
+		}
+		if s.Scan() || s.Err() != nil {
+			t.Errorf("%s: request not consumed, err %v", tt.in, s.Err())
+		}
+	}
+}
+
+var readResponseTests = []struct {
+	in   string
+	want *Response
+	err  string
+}{
+	{`{"jsonrpc": "2.0", "result": {"a": [1]}, "id": 3}`,
+		&Response{Result: []byte(`{"a": [1]}`), ID: json.NumberValue("3")}, ""},
+	{`{"jsonrpc": "2.0", "result": null, "id": "x"}`,
+		&Response{Result: []byte(`null`), ID: "x"}, ""},
+	{`{"jsonrpc": "2.0", "error": {"code": -32601, "message": "no", "data": [1]}, "id": null}`,
+		&Response{Error: &Error{Code: MethodNotFound, Message: "no", Data: []byte(`[1]`)}}, ""},
+	{`{"jsonrpc": "2.0", "result": 1}`, nil, "response id not found"},
+	{`{"jsonrpc": "2.0", "id": 1}`, nil, "response must have one of result or error"},
+	{`{"jsonrpc": "2.0", "result": 1, "error": {}, "id": 1}`, nil, "response must have one of result or error"},
+	{`{"jsonrpc": "2", "result": 1, "id": 1}`, nil, "response jsonrpc is not 2.0"},
+	{`{"jsonrpc": "2.0", "error": {"code": 1.5}, "id": 1}`, nil, "1.5"},
+}
+
+func TestReadResponse(t *testing.T) {
+	for _, tt := range readResponseTests {
+		s := json.NewScannerBytes([]byte(tt.in))
+		s.Scan()
+		r, err := ReadResponse(s)
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("%s: error %v, want %q", tt.in, err, tt.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: error %v", tt.in, err)
+			continue
+		}
+		if !reflect.DeepEqual(r, tt.want) {
+			t.Errorf("%s: got %+v, want %+v", tt.in, r, tt.want)
+		}
+	}
+}
+
+var encodeTests = []struct {
+	v    json.Marshaler
+	want string
+}{
+	{&Request{Method: "m", Params: []byte(`[1]`), ID: json.NumberValue("1")},
+		`{"jsonrpc":"2.0","method":"m","params":[1],"id":1}`},
+	{&Request{Method: "m", Notification: true},
+		`{"jsonrpc":"2.0","method":"m"}`},
+	{&Response{Result: []byte(`true`), ID: "a"},
+		`{"jsonrpc":"2.0","result":true,"id":"a"}`},
+	{&Response{ID: "a"},
+		`{"jsonrpc":"2.0","result":null,"id":"a"}`},
+	{&Response{Error: &Error{Code: InvalidParams, Message: "bad", Data: []byte(`"x"`)}},
+		`{"jsonrpc":"2.0","error":{"code":-32602,"message":"bad","data":"x"},"id":null}`},
+}
+
+func TestEncode(t *testing.T) {
+	for _, tt := range encodeTests {
+		var buf bytes.Buffer
+		w := json.NewWriter(&buf)
+		if err := tt.v.EncodeJSON(w); err != nil {
+			t.Errorf("%+v: error %v", tt.v, err)
+			continue
+		}
+		w.Flush()
+		if buf.String() != tt.want {
+			t.Errorf("%+v:\n got %s\nwant %s", tt.v, buf.String(), tt.want)
+		}
+	}
+}
@@ -0,0 +1,201 @@
+// Copyright 2014 Gary Burd. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package jsonrpc2
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+
+	"github.com/garyburd/json"
+)
+
+// Handler handles a request. The result is encoded with its EncodeJSON method
+// if the result implements json.Marshaler and with the encoding/json package
+// otherwise. If the error is an *Error, then the error is sent to the client
+// as is. Other errors are sent with the code InternalError.
+type Handler func(r *Request) (result interface{}, err error)
+
+// Server dispatches requests to handlers by method name.
+type Server struct {
+	handlers map[string]Handler
+}
+
+// NewServer returns a new server with no handlers.
+func NewServer() *Server {
+	return &Server{handlers: make(map[string]Handler)}
+}
+
+// Handle registers the handler for the given method.
+func (srv *Server) Handle(method string, h Handler) {
+	srv.handlers[method] = h
+}
+
+// ServeHTTP serves a request or batch in the body of a POST request. The
+// server responds with status 204 when the body contains only
+// notifications.
+func (srv *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		w.Header().Set("Allow", "POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	s := json.NewScanner(r.Body)
+	s.SetMaxBytes(json.DefaultMaxRequestBytes)
+	var buf bytes.Buffer
+	jw := json.NewWriter(&buf)
+	if !s.Scan() {
+		err := s.Err()
+		if err == nil {
+			err = io.ErrUnexpectedEOF
+		}
+		writeResponses(jw, []*Response{parseError(err)}, false)
+	} else {
+		resps, batch, err := srv.serve(s)
+		if err == nil {
+			s.Scan()
+			err = s.Err()
+		}
+		if err != nil {
+			resps, batch = []*Response{parseError(err)}, false
+		}
+		if len(resps) == 0 {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		writeResponses(jw, resps, batch)
+	}
+	if err := jw.EndDocument(); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	jw.Flush()
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Write(buf.Bytes())
+}
+
+// ServeConn serves requests and batches read from conn until EOF or an
+// error. Responses are written to conn as newline-delimited JSON. If the
+// input is not valid JSON, then ServeConn writes a parse error response and
+// returns the error.
+func (srv *Server) ServeConn(conn io.ReadWriter) error {
+	s := json.NewScanner(conn)
+	s.SetFraming(json.FrameConcatenated)
+	w := json.NewWriter(conn)
+	w.SetFraming(json.FrameLines)
+	for s.Scan() {
+		resps, batch, err := srv.serve(s)
+		if err != nil {
+			writeResponses(w, []*Response{parseError(err)}, false)
+			w.Flush()
+			return err
+		}
+		if len(resps) == 0 {
+			continue
+		}
+		if err := writeResponses(w, resps, batch); err != nil {
+			return err
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+	}
+	if err := s.Err(); err != nil {
+		writeResponses(w, []*Response{parseError(err)}, false)
+		w.Flush()
+		return err
+	}
+	return nil
+}
+
+// serve handles the request or batch at the scanner's current element and
+// returns the responses. The error is the scanner's error.
+func (srv *Server) serve(s *json.Scanner) ([]*Response, bool, error) {
+	if s.Kind() != json.Array {
+		resp, err := srv.call(s)
+		if err != nil || resp == nil {
+			return nil, false, err
+		}
+		return []*Response{resp}, false, nil
+	}
+	var resps []*Response
+	n := 0
+	level := s.NestingLevel()
+	for s.ScanAtLevel(level) {
+		n++
+		resp, err := srv.call(s)
+		if err != nil {
+			return nil, false, err
+		}
+		if resp != nil {
+			resps = append(resps, resp)
+		}
+	}
+	if err := s.Err(); err != nil {
+		return nil, false, err
+	}
+	if n == 0 {
+		return []*Response{{Error: invalidRequest("empty batch")}}, false, nil
+	}
+	return resps, true, nil
+}
+
+// call reads and handles the request at the scanner's current element. The
+// response is nil for notifications.
+func (srv *Server) call(s *json.Scanner) (*Response, error) {
+	r, err := ReadRequest(s)
+	if e, ok := err.(*Error); ok {
+		return &Response{ID: r.ID, Error: e}, nil
+	} else if err != nil {
+		return nil, err
+	}
+	resp := &Response{ID: r.ID}
+	if h := srv.handlers[r.Method]; h == nil {
+		resp.Error = &Error{Code: MethodNotFound, Message: "method " + r.Method + " not found"}
+	} else if result, err := h(r); err != nil {
+		resp.Error = handlerError(err)
+	} else {
+		var buf bytes.Buffer
+		w := json.NewWriter(&buf)
+		if err := encodeValue(w, result); err != nil {
+			resp.Error = handlerError(err)
+		} else if err := w.Flush(); err != nil {
+			resp.Error = handlerError(err)
+		} else {
+			resp.Result = buf.Bytes()
+		}
+	}
+	if r.Notification {
+		return nil, nil
+	}
+	return resp, nil
+}
+
+func handlerError(err error) *Error {
+	if e, ok := err.(*Error); ok {
+		return e
+	}
+	return &Error{Code: InternalError, Message: err.Error()}
+}
+
+func parseError(err error) *Response {
+	return &Response{Error: &Error{Code: ParseError, Message: err.Error()}}
+}
+
+// writeResponses writes the responses as a single value or as a batch.
+func writeResponses(w *json.Writer, resps []*Response, batch bool) error {
+	if !batch {
+		return resps[0].EncodeJSON(w)
+	}
+	if err := w.StartArray(); err != nil {
+		return err
+	}
+	for _, resp := range resps {
+		if err := resp.EncodeJSON(w); err != nil {
+			return err
+		}
+	}
+	return w.EndArray()
+}
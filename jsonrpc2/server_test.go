@@ -0,0 +1,205 @@
+// Copyright 2014 Gary Burd. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package jsonrpc2
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func newTestServer(notified chan string) *Server {
+	srv := NewServer()
+	srv.Handle("add", func(r *Request) (interface{}, error) {
+		var p []int
+		if err := r.UnmarshalParams(&p); err != nil {
+			return nil, &Error{Code: InvalidParams, Message: err.Error()}
+		}
+		sum := 0
+		for _, n := range p {
+			sum += n
+		}
+		return sum, nil
+	})
+	srv.Handle("fail", func(r *Request) (interface{}, error) {
+		return nil, errors.New("failed")
+	})
+	srv.Handle("log", func(r *Request) (interface{}, error) {
+		var p []string
+		r.UnmarshalParams(&p)
+		notified <- strings.Join(p, " ")
+		return nil, nil
+	})
+	return srv
+}
+
+var serveHTTPTests = []struct {
+	in     string
+	status int
+	want   string
+}{
+	{`{"jsonrpc": "2.0", "method": "add", "params": [1, 2], "id": 1}`, 200,
+		`{"jsonrpc":"2.0","result":3,"id":1}` + "\n"},
+	{`{"jsonrpc": "2.0", "method": "nope", "id": "a"}`, 200,
+		`{"jsonrpc":"2.0","error":{"code":-32601,"message":"method nope not found"},"id":"a"}` + "\n"},
+	{`{"jsonrpc": "2.0", "method": "fail", "id": 1}`, 200,
+		`{"jsonrpc":"2.0","error":{"code":-32603,"message":"failed"},"id":1}` + "\n"},
+	{`[{"jsonrpc": "2.0", "method": "add", "params": [1], "id": 1}, {"jsonrpc": "2.0", "method": "log", "params": ["a"]}, 1, {"jsonrpc": "2.0", "method": "add", "params": [2, 2], "id": 2}]`, 200,
+		`[{"jsonrpc":"2.0","result":1,"id":1},{"jsonrpc":"2.0","error":{"code":-32600,"message":"unexpected number, expected object"},"id":null},{"jsonrpc":"2.0","result":4,"id":2}]` + "\n"},
+	{`[{"jsonrpc": "2.0", "method": "log", "params": ["b"]}]`, 204, ``},
+	{`[]`, 200,
+		`{"jsonrpc":"2.0","error":{"code":-32600,"message":"empty batch"},"id":null}` + "\n"},
+	{`{"jsonrpc": "2.0", "method": "add", "params": "x", "id": 1}`, 200,
+		`{"jsonrpc":"2.0","error":{"code":-32600,"message":"params is not an array or object"},"id":1}` + "\n"},
+	{`{"jsonrpc": "2.0", "method": "add", "params": ["x"], "id": 1}`, 200,
+		`{"jsonrpc":"2.0","error":{"code":-32602,"message":"cannot unmarshal string into Go value of type int"},"id":1}` + "\n"},
+}
+
+func TestServeHTTP(t *testing.T) {
+	notified := make(chan string, 10)
+	srv := newTestServer(notified)
+	for _, tt := range serveHTTPTests {
+		r := httptest.NewRequest("POST", "/", strings.NewReader(tt.in))
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, r)
+		body, _ := ioutil.ReadAll(w.Body)
+		if w.Code != tt.status || string(body) != tt.want {
+			t.Errorf("%s:\n got %d %s\nwant %d %s", tt.in, w.Code, body, tt.status, tt.want)
+		}
+	}
+}
+
+func testClient(t *testing.T, c *Client, notified chan string) {
+	var sum int
+	if err := c.Call("add", []int{1, 2, 3}, &sum); err != nil || sum != 6 {
+		t.Errorf("Call(add) = %d, %v, want 6", sum, err)
+	}
+	err := c.Call("fail", nil, nil)
+	if e, ok := err.(*Error); !ok || e.Code != InternalError || e.Message != "failed" {
+		t.Errorf("Call(fail) = %v, want internal error", err)
+	}
+	if err := c.Notify("log", []string{"hello", "world"}); err != nil {
+		t.Errorf("Notify(log) = %v", err)
+	}
+	if got := <-notified; got != "hello world" {
+		t.Errorf("notification = %q, want %q", got, "hello world")
+	}
+	var a, b int
+	calls := []*Call{
+		{Method: "add", Params: []int{1}, Result: &a},
+		{Method: "log", Params: []string{"batch"}, Notify: true},
+		{Method: "nope"},
+		{Method: "add", Params: []int{2, 3}, Result: &b},
+	}
+	if err := c.Batch(calls); err != nil {
+		t.Fatalf("Batch() = %v", err)
+	}
+	if a != 1 || b != 5 || calls[0].Err != nil || calls[1].Err != nil || calls[3].Err != nil {
+		t.Errorf("Batch() results %d, %d, errors %v, %v, %v", a, b, calls[0].Err, calls[1].Err, calls[3].Err)
+	}
+	if e, ok := calls[2].Err.(*Error); !ok || e.Code != MethodNotFound {
+		t.Errorf("Batch() error for nope = %v, want method not found", calls[2].Err)
+	}
+	if got := <-notified; got != "batch" {
+		t.Errorf("notification = %q, want %q", got, "batch")
+	}
+}
+
+func TestClientHTTP(t *testing.T) {
+	notified := make(chan string, 10)
+	ts := httptest.NewServer(newTestServer(notified))
+	defer ts.Close()
+	testClient(t, NewClient(&HTTPTransport{URL: ts.URL}), notified)
+
+	resp, err := http.Get(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("GET status = %d, want %d", resp.StatusCode, http.StatusMethodNotAllowed)
+	}
+}
+
+func TestClientConn(t *testing.T) {
+	notified := make(chan string, 10)
+	cc, sc := net.Pipe()
+	done := make(chan error, 1)
+	go func() {
+		done <- newTestServer(notified).ServeConn(sc)
+	}()
+	testClient(t, NewClient(NewConnTransport(cc)), notified)
+	cc.Close()
+	if err := <-done; err != nil {
+		t.Errorf("ServeConn() = %v", err)
+	}
+}
+
+func TestClientConnEncodeError(t *testing.T) {
+	notified := make(chan string, 10)
+	cc, sc := net.Pipe()
+	done := make(chan error, 1)
+	go func() {
+		done <- newTestServer(notified).ServeConn(sc)
+	}()
+	c := NewClient(NewConnTransport(cc))
+	var sum int
+	if err := c.Call("add", []interface{}{1, func() {}}, &sum); err == nil {
+		t.Error("Call with unencodable params returned nil error")
+	}
+	if err := c.Call("add", []int{1, 2}, &sum); err != nil || sum != 3 {
+		t.Errorf("Call() = %d, %v, want 3, nil", sum, err)
+	}
+	cc.Close()
+	if err := <-done; err != nil {
+		t.Errorf("ServeConn() = %v", err)
+	}
+}
+
+func TestClientConnBroken(t *testing.T) {
+	var out bytes.Buffer
+	conn := struct {
+		io.Reader
+		io.Writer
+	}{strings.NewReader(`[{"jsonrpc": "2.0", "result": 1, "id": 1}, {"jsonrpc": x`), &out}
+	c := NewClient(NewConnTransport(conn))
+	err := c.Batch([]*Call{{Method: "add"}, {Method: "add"}})
+	if err == nil {
+		t.Fatal("Batch returned nil error")
+	}
+	n := out.Len()
+	if err2 := c.Call("add", nil, nil); err2 != err {
+		t.Errorf("Call() = %v, want %v", err2, err)
+	}
+	if out.Len() != n {
+		t.Errorf("broken transport wrote %q", out.Bytes()[n:])
+	}
+}
+
+func TestServeConnParseError(t *testing.T) {
+	cc, sc := net.Pipe()
+	done := make(chan error, 1)
+	go func() {
+		done <- NewServer().ServeConn(sc)
+		sc.Close()
+	}()
+	go cc.Write([]byte(`{"jsonrpc": x}`))
+	resp, err := ioutil.ReadAll(cc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(resp), `{"jsonrpc":"2.0","error":{"code":-32700,`) {
+		t.Errorf("response = %s, want parse error", resp)
+	}
+	if err := <-done; err == nil {
+		t.Error("ServeConn() returned nil error")
+	}
+}
@@ -0,0 +1,205 @@
+// Copyright 2014 Gary Burd. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package json
+
+import (
+	"errors"
+	"io"
+)
+
+// Mark records the state of a scanner. See Scanner.Mark.
+type Mark struct {
+	seq        int
+	states     []stateFunc
+	pending    stateFunc
+	resume     stateFunc
+	commentEOF bool
+	quote      byte
+	literal    string
+	isName     bool
+	cook       bool
+	eofOK      bool
+	streamed   bool
+	kind       Kind
+	data       [2]struct {
+		off, end int64
+		cook     bool
+	}
+	path      []PathElement
+	pathArray []bool
+	pathLen   int
+	keys      []map[string]bool
+	counts    []countFrame
+	rawStarts []int64
+	rawPos    int64
+	rawEnd    int64
+	line      int
+	lstart    int64
+	off       int64
+}
+
+var errStaleMark = errors.New("mark is not the scanner's current mark")
+
+// Mark records the scanner's current position and state. A later call to
+// Rewind returns the scanner to the recorded state so that the input
+// following the current element can be scanned again. The scanner retains
+// all input from the mark until Rewind or Unmark is called.
+//
+// A scanner has at most one mark. Calling Mark replaces the previous mark.
+func (s *Scanner) Mark() Mark {
+	s.countLines(s.pos)
+	m := Mark{
+		seq:        s.markSeq + 1,
+		states:     append([]stateFunc(nil), s.states...),
+		pending:    s.pending,
+		resume:     s.resume,
+		commentEOF: s.commentEOF,
+		quote:      s.quote,
+		literal:    s.literal,
+		isName:     s.isName,
+		cook:       s.cook,
+		eofOK:      s.eofOK,
+		streamed:   s.streamed,
+		kind:       s.kind,
+		path:       append([]PathElement(nil), s.path...),
+		pathArray:  append([]bool(nil), s.pathArray...),
+		pathLen:    s.pathLen,
+		counts:     append([]countFrame(nil), s.counts...),
+		rawPos:     s.base + int64(s.rawPos),
+		rawEnd:     s.base + int64(s.rawEnd),
+		line:       s.line,
+		lstart:     s.lstart,
+		off:        s.base + int64(s.pos),
+	}
+	keep := s.pos
+	for i, d := range s.data {
+		m.data[i].off = -1
+		if d.pos >= 0 {
+			m.data[i].off = s.base + int64(d.pos)
+			m.data[i].end = s.base + int64(d.end)
+			m.data[i].cook = d.cook
+			if d.pos < keep {
+				keep = d.pos
+			}
+		}
+	}
+	if s.raw && s.kind == End && s.rawPos < keep {
+		keep = s.rawPos
+	}
+	for _, p := range s.rawStarts {
+		m.rawStarts = append(m.rawStarts, s.base+int64(p))
+	}
+	if s.dupKeys {
+		for _, keys := range s.keys[:s.nkeys] {
+			c := make(map[string]bool, len(keys))
+			for k := range keys {
+				c[k] = true
+			}
+			m.keys = append(m.keys, c)
+		}
+	}
+	s.marked = true
+	s.markOff = s.base + int64(keep)
+	s.markSeq = m.seq
+	return m
+}
+
+// Rewind returns the scanner to the state recorded by m. The mark is released.
+// Rewind returns an error if m is not the scanner's current mark, if the mark
+// is released or if the scanner encountered an error other than the end of
+// input.
+//
+// Rewind does not undo strings consumed with StringReader. Do not call Mark
+// while streaming a string.
+func (s *Scanner) Rewind(m Mark) error {
+	if !s.marked || m.seq != s.markSeq {
+		return errStaleMark
+	}
+	if s.err != nil && s.err != io.EOF {
+		return s.err
+	}
+	s.rewind(m)
+	return nil
+}
+
+func (s *Scanner) rewind(m Mark) {
+	s.marked = false
+	s.states = append(s.states[:0], m.states...)
+	s.pending = m.pending
+	s.resume = m.resume
+	s.commentEOF = m.commentEOF
+	s.quote = m.quote
+	s.literal = m.literal
+	s.isName = m.isName
+	s.cook = m.cook
+	s.eofOK = m.eofOK
+	s.kind = m.kind
+	for i, d := range m.data {
+		s.data[i].pos = -1
+		if d.off >= 0 {
+			s.data[i].pos = int(d.off - s.base)
+			s.data[i].end = int(d.end - s.base)
+			s.data[i].cook = d.cook
+		}
+	}
+	s.path = append(s.path[:0], m.path...)
+	s.pathArray = append(s.pathArray[:0], m.pathArray...)
+	s.pathLen = m.pathLen
+	s.counts = append(s.counts[:0], m.counts...)
+	if s.dupKeys {
+		for s.nkeys > 0 {
+			s.popKeys()
+		}
+		for _, keys := range m.keys {
+			s.pushKeys()
+			for k := range keys {
+				s.keys[s.nkeys-1][k] = true
+			}
+		}
+	}
+	s.rawStarts = s.rawStarts[:0]
+	for _, off := range m.rawStarts {
+		s.rawStarts = append(s.rawStarts, int(off-s.base))
+	}
+	s.rawPos = int(m.rawPos - s.base)
+	s.rawEnd = int(m.rawEnd - s.base)
+	s.pos = int(m.off - s.base)
+	s.line = m.line
+	s.lstart = m.lstart
+	s.lpos = s.pos
+	s.streaming = false
+	s.streamed = m.streamed
+}
+
+// Unmark releases the scanner's current mark without rewinding.
+func (s *Scanner) Unmark() {
+	s.marked = false
+}
+
+// Peek returns the kind of the next element without advancing the scanner.
+// The current element remains available through the Kind, Name and Value
+// methods. At the end of the input, Peek returns io.EOF. In push mode, Peek
+// returns ErrNeedInput if the next element is not completely written.
+func (s *Scanner) Peek() (Kind, error) {
+	if s.streaming && !s.skipString() {
+		return -1, s.err
+	}
+	marked, markOff, markSeq := s.marked, s.markOff, s.markSeq
+	m := s.Mark()
+	if marked && markOff < s.markOff {
+		s.markOff = markOff
+	}
+	ok := s.scan()
+	kind, err := s.kind, s.err
+	s.rewind(m)
+	s.marked, s.markOff, s.markSeq = marked, markOff, markSeq
+	switch {
+	case ok:
+		return kind, nil
+	case err == nil:
+		return -1, ErrNeedInput
+	}
+	return -1, err
+}
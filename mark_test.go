@@ -0,0 +1,201 @@
+// Copyright 2014 Gary Burd. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package json
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+const markInput = `[
+	{"name": "a", "radius": 1.5, "type": "circle"},
+	{"type": "rect", "name": "bé", "w": 2, "h": 3}
+]`
+
+type markShape struct {
+	Type   string  `json:"type"`
+	Name   string  `json:"name"`
+	Radius float64 `json:"radius"`
+	W      int     `json:"w"`
+	H      int     `json:"h"`
+}
+
+// scanShapes decodes markInput by peeking at the type member of each object
+// and then rewinding to decode the whole object.
+func scanShapes(s *Scanner) ([]markShape, error) {
+	var shapes []markShape
+	if !s.Scan() || s.Kind() != Array {
+		return nil, s.Err()
+	}
+	for s.Scan() && s.Kind() != End {
+		m := s.Mark()
+		var typ string
+		for s.Scan() && s.Kind() != End {
+			if s.NameIs("type") {
+				typ = string(s.Value())
+			}
+			s.Skip()
+		}
+		if err := s.Rewind(m); err != nil {
+			return nil, err
+		}
+		var shape markShape
+		if err := Unmarshal(s, &shape); err != nil {
+			return nil, err
+		}
+		if shape.Type != typ {
+			shape.Type = "mismatch " + typ
+		}
+		shapes = append(shapes, shape)
+	}
+	return shapes, s.Err()
+}
+
+func TestMarkRewind(t *testing.T) {
+	want := []markShape{
+		{Type: "circle", Name: "a", Radius: 1.5},
+		{Type: "rect", Name: "bé", W: 2, H: 3},
+	}
+	small := NewScanner(readerOnly{strings.NewReader(markInput)})
+	small.Buffer(make([]byte, 16), 1024)
+	for i, s := range []*Scanner{
+		NewScannerBytes([]byte(markInput)),
+		NewScanner(readerOnly{strings.NewReader(markInput)}),
+		NewScanner(iotest.OneByteReader(strings.NewReader(markInput))),
+		small,
+	} {
+		s.TrackPath(true)
+		got, err := scanShapes(s)
+		if err != nil {
+			t.Errorf("%d: unexpected error %v", i, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%d: got %+v, want %+v", i, got, want)
+		}
+	}
+}
+
+func TestMarkLines(t *testing.T) {
+	s := NewScanner(iotest.OneByteReader(strings.NewReader("[\n1,\n2,\nx]")))
+	s.Scan()
+	m := s.Mark()
+	for s.Scan() {
+	}
+	if err := s.Rewind(m); err == nil {
+		t.Fatal("rewind after syntax error did not fail")
+	}
+
+	s = NewScanner(iotest.OneByteReader(strings.NewReader("[\n1,\n2,\n3]")))
+	s.Scan()
+	m = s.Mark()
+	for s.Scan() {
+	}
+	if err := s.Rewind(m); err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	for s.Scan() {
+		line, column := s.Position()
+		fmt.Fprintf(&out, "%s:%d:%d ", s.Value(), line, column)
+	}
+	if got, want := out.String(), "1:2:2 2:3:2 3:4:2 :4:3 "; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestStaleMark(t *testing.T) {
+	s := NewScannerBytes([]byte(`[1, 2, 3]`))
+	s.Scan()
+	m1 := s.Mark()
+	s.Scan()
+	m2 := s.Mark()
+	if err := s.Rewind(m1); err == nil {
+		t.Error("rewind to replaced mark did not fail")
+	}
+	if err := s.Rewind(m2); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Rewind(m2); err == nil {
+		t.Error("rewind to released mark did not fail")
+	}
+	if !s.Scan() || string(s.Value()) != "2" {
+		t.Errorf("got %s, want 2", s.Value())
+	}
+	s.Unmark()
+}
+
+func TestPeek(t *testing.T) {
+	const input = `{"a": [], "b": ["x", {"c": null}], "d": true}`
+	want := []Kind{Object, Array, End, Array, String, Object, Null, End, End, Bool, End}
+	for i, s := range []*Scanner{
+		NewScannerBytes([]byte(input)),
+		NewScanner(iotest.OneByteReader(strings.NewReader(input))),
+	} {
+		s.TrackPath(true)
+		var got []Kind
+		for {
+			k, err := s.Peek()
+			if err != nil {
+				if err != io.EOF {
+					t.Errorf("%d: unexpected error %v", i, err)
+				}
+				break
+			}
+			// Peek does not change the current element.
+			name, value, path := string(s.Name()), string(s.Value()), s.PathString()
+			if _, err := s.Peek(); err != nil {
+				t.Fatal(err)
+			}
+			if string(s.Name()) != name || string(s.Value()) != value || s.PathString() != path {
+				t.Errorf("%d: current element changed by Peek", i)
+			}
+			if !s.Scan() || s.Kind() != k {
+				t.Errorf("%d: Peek returned %v, Scan returned %v", i, k, s.Kind())
+				break
+			}
+			got = append(got, k)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%d: got %v, want %v", i, got, want)
+		}
+	}
+
+	s := NewScannerBytes([]byte(`[1, x]`))
+	s.Scan()
+	s.Scan()
+	if _, err := s.Peek(); err == nil {
+		t.Error("no syntax error")
+	}
+	if string(s.Value()) != "1" {
+		t.Errorf("got value %s, want 1", s.Value())
+	}
+}
+
+func TestPeekPush(t *testing.T) {
+	s := NewScannerPush()
+	s.Write([]byte(`[1, "ab`))
+	s.Scan()
+	if _, err := s.Peek(); err != nil {
+		t.Fatal(err)
+	}
+	s.Scan()
+	if _, err := s.Peek(); err != ErrNeedInput {
+		t.Errorf("Peek of incomplete element returned %v, want ErrNeedInput", err)
+	}
+	s.Write([]byte(`c"]`))
+	s.CloseInput()
+	if k, err := s.Peek(); k != String || err != nil {
+		t.Errorf("Peek returned %v, %v, want string", k, err)
+	}
+	if !s.Scan() || string(s.Value()) != "abc" {
+		t.Errorf("got %s, want abc", s.Value())
+	}
+}
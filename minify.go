@@ -0,0 +1,61 @@
+// Copyright 2014 Gary Burd. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package json
+
+import (
+	"io"
+)
+
+// MinifyOptions specifies options for MinifyWith.
+type MinifyOptions struct {
+	// DropKeys is a list of object member names. Members with these names
+	// are removed from objects at all nesting levels.
+	DropKeys []string
+}
+
+// Minify reads a JSON value from src and writes the value to dst with all
+// insignificant whitespace removed. Strings are written with minimal
+// escaping.
+func Minify(dst io.Writer, src io.Reader) error {
+	return MinifyWith(dst, src, MinifyOptions{})
+}
+
+// MinifyWith minifies a JSON value as Minify does using the specified
+// options.
+func MinifyWith(dst io.Writer, src io.Reader, opts MinifyOptions) error {
+	drop := make(map[string]bool, len(opts.DropKeys))
+	for _, k := range opts.DropKeys {
+		drop[k] = true
+	}
+	w := NewWriter(dst)
+	w.SetEscapeHTML(false)
+	return Transform(w, NewScanner(src), func(path string, s *Scanner, w *Writer) (bool, error) {
+		if len(drop) == 0 {
+			return false, nil
+		}
+		p := s.Path()
+		if len(p) == 0 || p[len(p)-1].Index >= 0 || !drop[string(s.Name())] {
+			return false, nil
+		}
+		if s.Kind() == Object || s.Kind() == Array {
+			return true, s.Skip()
+		}
+		return true, nil
+	})
+}
+
+// Indent reads a JSON value from src and writes the value to dst with each
+// element in an object or array on a separate line beginning with prefix
+// followed by one or more copies of indent according to the nesting level.
+// Indent reads and writes the value incrementally. Strings are written with
+// minimal escaping.
+func Indent(dst io.Writer, src io.Reader, prefix, indent string) error {
+	w := NewWriter(dst)
+	w.SetEscapeHTML(false)
+	w.SetIndent(prefix, indent)
+	return Transform(w, NewScanner(src), func(path string, s *Scanner, w *Writer) (bool, error) {
+		return false, nil
+	})
+}
@@ -0,0 +1,70 @@
+// Copyright 2014 Gary Burd. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package json
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+var minifyTests = []struct {
+	in   string
+	drop []string
+	want string
+}{
+	{" 1 ", nil, "1"},
+	{"\"a<\\u0042\\n\"", nil, `"a<B\n"`},
+	{"{ \"a\" : [ 1 , 2.50 , true , null ] ,\n\t\"b\" : { } }", nil, `{"a":[1,2.50,true,null],"b":{}}`},
+	{`{"a": 1, "secret": {"x": [1]}, "b": [{"secret": 2, "c": "secret"}]}`, []string{"secret"}, `{"a":1,"b":[{"c":"secret"}]}`},
+	{`["secret", {"secret": null}]`, []string{"secret"}, `["secret",{}]`},
+}
+
+func TestMinify(t *testing.T) {
+	for _, tt := range minifyTests {
+		var buf bytes.Buffer
+		err := MinifyWith(&buf, strings.NewReader(tt.in), MinifyOptions{DropKeys: tt.drop})
+		if err != nil {
+			t.Errorf("%s: %v", tt.in, err)
+			continue
+		}
+		if got := buf.String(); got != tt.want {
+			t.Errorf("%s: got %s, want %s", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestMinifyError(t *testing.T) {
+	for _, in := range []string{``, `[1,]`, `{"a":1} x`} {
+		var buf bytes.Buffer
+		if err := Minify(&buf, strings.NewReader(in)); err == nil {
+			t.Errorf("%q: no error", in)
+		}
+	}
+}
+
+func TestIndent(t *testing.T) {
+	const in = `{"a": [1, "<x>", {}], "b": {"c": true}}`
+	const want = `{
+>	"a": [
+>		1,
+>		"<x>",
+>		{}
+>	],
+>	"b": {
+>		"c": true
+>	}
+>}`
+	var buf bytes.Buffer
+	if err := Indent(&buf, strings.NewReader(in), ">", "\t"); err != nil {
+		t.Fatal(err)
+	}
+	if got := buf.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+	if err := Indent(&buf, strings.NewReader(`[1,`), "", "\t"); err == nil {
+		t.Error("no error for truncated input")
+	}
+}
@@ -0,0 +1,410 @@
+// Copyright 2014 Gary Burd. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package json
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+)
+
+// JSONToMsgpack copies the JSON values in src to dst encoded as MessagePack.
+// Integers that fit in 64 bits are encoded as MessagePack integers. Other
+// numbers are encoded as float 64.
+//
+// MessagePack arrays and maps are prefixed with their length. JSONToMsgpack
+// encodes each top-level value to a buffer and writes the buffer to dst at
+// the end of the value.
+func JSONToMsgpack(dst io.Writer, src *Scanner) error {
+	var (
+		buf    []byte
+		frames []msgpackFrame // open containers
+	)
+	for src.Scan() {
+		if len(frames) > 0 && src.Kind() != End {
+			f := &frames[len(frames)-1]
+			f.count++
+			if f.object {
+				buf = appendMsgpackString(buf, src.Name())
+			}
+		}
+		switch src.Kind() {
+		case Array, Object:
+			frames = append(frames, msgpackFrame{start: len(buf), object: src.Kind() == Object})
+			// Reserve space for the largest header.
+			buf = append(buf, 0, 0, 0, 0, 0)
+			continue
+		case End:
+			f := frames[len(frames)-1]
+			frames = frames[:len(frames)-1]
+			buf = f.fixHeader(buf)
+		case Null:
+			buf = append(buf, 0xc0)
+		case Bool:
+			if src.Value()[0] == 't' {
+				buf = append(buf, 0xc3)
+			} else {
+				buf = append(buf, 0xc2)
+			}
+		case String:
+			buf = appendMsgpackString(buf, src.Value())
+		case Number:
+			buf = appendMsgpackNumber(buf, src)
+		}
+		if len(frames) == 0 {
+			if _, err := dst.Write(buf); err != nil {
+				return err
+			}
+			buf = buf[:0]
+		}
+	}
+	return src.Err()
+}
+
+// msgpackFrame is an open array or object in JSONToMsgpack.
+type msgpackFrame struct {
+	start  int // position in buf of the reserved header
+	count  int // number of elements or members
+	object bool
+}
+
+// fixHeader writes the container's header to the space reserved at f.start
+// and removes the unused space.
+func (f *msgpackFrame) fixHeader(buf []byte) []byte {
+	var h []byte
+	n := f.count
+	switch {
+	case n < 16 && f.object:
+		h = []byte{0x80 | byte(n)}
+	case n < 16:
+		h = []byte{0x90 | byte(n)}
+	case n <= math.MaxUint16 && f.object:
+		h = []byte{0xde, byte(n >> 8), byte(n)}
+	case n <= math.MaxUint16:
+		h = []byte{0xdc, byte(n >> 8), byte(n)}
+	case f.object:
+		h = []byte{0xdf, byte(n >> 24), byte(n >> 16), byte(n >> 8), byte(n)}
+	default:
+		h = []byte{0xdd, byte(n >> 24), byte(n >> 16), byte(n >> 8), byte(n)}
+	}
+	copy(buf[f.start:], h)
+	if len(h) < 5 {
+		n := copy(buf[f.start+len(h):], buf[f.start+5:])
+		buf = buf[:f.start+len(h)+n]
+	}
+	return buf
+}
+
+func appendMsgpackString(buf []byte, p []byte) []byte {
+	n := len(p)
+	switch {
+	case n < 32:
+		buf = append(buf, 0xa0|byte(n))
+	case n <= math.MaxUint8:
+		buf = append(buf, 0xd9, byte(n))
+	case n <= math.MaxUint16:
+		buf = append(buf, 0xda, byte(n>>8), byte(n))
+	default:
+		buf = append(buf, 0xdb, byte(n>>24), byte(n>>16), byte(n>>8), byte(n))
+	}
+	return append(buf, p...)
+}
+
+func appendMsgpackNumber(buf []byte, s *Scanner) []byte {
+	if i, err := s.Int64(); err == nil {
+		switch {
+		case i >= 0 && i < 128:
+			return append(buf, byte(i))
+		case i < 0 && i >= -32:
+			return append(buf, byte(i))
+		case i >= math.MinInt8 && i <= math.MaxInt8:
+			return append(buf, 0xd0, byte(i))
+		case i >= math.MinInt16 && i <= math.MaxInt16:
+			return append(buf, 0xd1, byte(i>>8), byte(i))
+		case i >= math.MinInt32 && i <= math.MaxInt32:
+			return append(buf, 0xd2, byte(i>>24), byte(i>>16), byte(i>>8), byte(i))
+		}
+		return appendUint64(append(buf, 0xd3), uint64(i))
+	}
+	if u, err := s.Uint64(); err == nil {
+		return appendUint64(append(buf, 0xcf), u)
+	}
+	// Float64 returns ±Inf for numbers out of range.
+	f, _ := s.Float64()
+	return appendUint64(append(buf, 0xcb), math.Float64bits(f))
+}
+
+// appendUint64 appends the big-endian encoding of u to buf.
+func appendUint64(buf []byte, u uint64) []byte {
+	return append(buf, byte(u>>56), byte(u>>48), byte(u>>40), byte(u>>32), byte(u>>24), byte(u>>16), byte(u>>8), byte(u))
+}
+
+var errMsgpackKey = errors.New("msgpack map key is not a string or integer")
+
+// MsgpackToJSON copies the MessagePack values in src to w. Binary values are
+// written as base64 strings. Map keys must be strings or integers. Integer
+// keys are written as decimal strings. Extension types and NaN and infinite
+// floats are not supported.
+//
+// MsgpackToJSON calls w.EndDocument after each top-level value, so a stream
+// of MessagePack values is written as newline-delimited JSON.
+func MsgpackToJSON(w *Writer, src io.Reader) error {
+	br, ok := src.(*bufio.Reader)
+	if !ok {
+		br = bufio.NewReader(src)
+	}
+	r := msgpackReader{br: br}
+	var stack []msgpackOpen // open containers
+	for {
+		key := false
+		if n := len(stack); n > 0 {
+			f := &stack[n-1]
+			if f.remaining == 0 {
+				stack = stack[:n-1]
+				var err error
+				if f.object {
+					err = w.EndObject()
+				} else {
+					err = w.EndArray()
+				}
+				if err != nil {
+					return err
+				}
+				if len(stack) == 0 {
+					if err := w.EndDocument(); err != nil {
+						return err
+					}
+				}
+				continue
+			}
+			if f.object && !f.value {
+				key = true
+				f.value = true
+			} else {
+				f.remaining--
+				f.value = false
+			}
+		}
+		b, err := r.br.ReadByte()
+		if err == io.EOF && len(stack) == 0 {
+			return nil
+		}
+		if err != nil {
+			return unexpectedEOF(err)
+		}
+		if key {
+			if err := r.key(w, b); err != nil {
+				return err
+			}
+			continue
+		}
+		open, err := r.value(w, b)
+		if err != nil {
+			return err
+		}
+		if open.remaining >= 0 {
+			stack = append(stack, open)
+		} else if len(stack) == 0 {
+			if err := w.EndDocument(); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// msgpackOpen is an open array or map in MsgpackToJSON.
+type msgpackOpen struct {
+	remaining int  // number of elements or members to read
+	object    bool // if true, the container is a map
+	value     bool // if true, the member name was read
+}
+
+type msgpackReader struct {
+	br      *bufio.Reader
+	scratch [8]byte
+}
+
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// uint reads a big-endian unsigned integer with n bytes.
+func (r *msgpackReader) uint(n int) (uint64, error) {
+	p := r.scratch[:n]
+	if _, err := io.ReadFull(r.br, p); err != nil {
+		return 0, unexpectedEOF(err)
+	}
+	var u uint64
+	for _, b := range p {
+		u = u<<8 | uint64(b)
+	}
+	return u, nil
+}
+
+// bytes reads n bytes. The buffer grows as the bytes are read to avoid
+// allocating a large buffer for a corrupt length.
+func (r *msgpackReader) bytes(n uint64) ([]byte, error) {
+	var buf bytes.Buffer
+	if m, err := buf.ReadFrom(io.LimitReader(r.br, int64(n))); err != nil {
+		return nil, err
+	} else if uint64(m) < n {
+		return nil, io.ErrUnexpectedEOF
+	}
+	return buf.Bytes(), nil
+}
+
+// length reads a 1, 2 or 4 byte length for type byte b equal to base,
+// base+1 or base+2.
+func (r *msgpackReader) length(b, base byte) (uint64, error) {
+	switch b - base {
+	case 0:
+		return r.uint(1)
+	case 1:
+		return r.uint(2)
+	default:
+		return r.uint(4)
+	}
+}
+
+// key writes the map key with type byte b as an object member name.
+func (r *msgpackReader) key(w *Writer, b byte) error {
+	var (
+		n   uint64
+		err error
+	)
+	switch {
+	case b&0xe0 == 0xa0:
+		n = uint64(b & 0x1f)
+	case b >= 0xd9 && b <= 0xdb:
+		n, err = r.length(b, 0xd9)
+	default:
+		var i int64
+		switch {
+		case b < 0x80:
+			i = int64(b)
+		case b >= 0xe0:
+			i = int64(int8(b))
+		case b >= 0xcc && b <= 0xd3:
+			i, err = r.int(b)
+		default:
+			return errMsgpackKey
+		}
+		if err != nil {
+			return err
+		}
+		if b == 0xcf && i < 0 {
+			return w.Name(strconv.FormatUint(uint64(i), 10))
+		}
+		return w.Name(strconv.FormatInt(i, 10))
+	}
+	if err != nil {
+		return err
+	}
+	p, err := r.bytes(n)
+	if err != nil {
+		return err
+	}
+	return w.NameBytes(p)
+}
+
+// int reads an integer with type byte b in the range 0xcc through 0xd3.
+// Unsigned 64 bit integers are returned as the int64 with the same bits.
+func (r *msgpackReader) int(b byte) (int64, error) {
+	size := 1 << ((b - 0xcc) & 3)
+	u, err := r.uint(size)
+	if err != nil || b <= 0xcf {
+		return int64(u), err
+	}
+	shift := 64 - 8*uint(size)
+	return int64(u<<shift) >> shift, nil
+}
+
+// value writes the value with type byte b. If the value is an array or map,
+// then value starts the container and returns the container's length.
+// Otherwise, value returns a remaining count of -1.
+func (r *msgpackReader) value(w *Writer, b byte) (msgpackOpen, error) {
+	none := msgpackOpen{remaining: -1}
+	var (
+		n   uint64
+		err error
+	)
+	switch {
+	case b < 0x80:
+		return none, w.Int(int64(b))
+	case b >= 0xe0:
+		return none, w.Int(int64(int8(b)))
+	case b&0xf0 == 0x80:
+		return msgpackOpen{remaining: int(b & 0x0f), object: true}, w.StartObject()
+	case b&0xf0 == 0x90:
+		return msgpackOpen{remaining: int(b & 0x0f)}, w.StartArray()
+	case b&0xe0 == 0xa0:
+		n = uint64(b & 0x1f)
+	case b == 0xc0:
+		return none, w.Null()
+	case b == 0xc2 || b == 0xc3:
+		return none, w.Bool(b == 0xc3)
+	case b >= 0xc4 && b <= 0xc6:
+		if n, err = r.length(b, 0xc4); err != nil {
+			return none, err
+		}
+		p, err := r.bytes(n)
+		if err != nil {
+			return none, err
+		}
+		return none, w.Bytes(p)
+	case b == 0xca:
+		u, err := r.uint(4)
+		if err != nil {
+			return none, err
+		}
+		return none, w.Float(float64(math.Float32frombits(uint32(u))))
+	case b == 0xcb:
+		u, err := r.uint(8)
+		if err != nil {
+			return none, err
+		}
+		return none, w.Float(math.Float64frombits(u))
+	case b >= 0xcc && b <= 0xd3:
+		i, err := r.int(b)
+		if err != nil {
+			return none, err
+		}
+		if b == 0xcf && i < 0 {
+			return none, w.Uint(uint64(i))
+		}
+		return none, w.Int(i)
+	case b >= 0xd9 && b <= 0xdb:
+		n, err = r.length(b, 0xd9)
+	case b == 0xdc || b == 0xdd:
+		n, err = r.length(b, 0xdb)
+		if err != nil {
+			return none, err
+		}
+		return msgpackOpen{remaining: int(n)}, w.StartArray()
+	case b == 0xde || b == 0xdf:
+		n, err = r.length(b, 0xdd)
+		if err != nil {
+			return none, err
+		}
+		return msgpackOpen{remaining: int(n), object: true}, w.StartObject()
+	default:
+		return none, fmt.Errorf("unsupported msgpack type 0x%02x", b)
+	}
+	if err != nil {
+		return none, err
+	}
+	p, err := r.bytes(n)
+	if err != nil {
+		return none, err
+	}
+	return none, w.StringBytes(p)
+}
@@ -0,0 +1,158 @@
+// Copyright 2014 Gary Burd. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package json
+
+import (
+	"bytes"
+	hexenc "encoding/hex"
+	"strings"
+	"testing"
+)
+
+var msgpackTests = []struct {
+	json    string
+	msgpack string // hex
+}{
+	{`null`, "c0"},
+	{`true`, "c3"},
+	{`false`, "c2"},
+	{`0`, "00"},
+	{`127`, "7f"},
+	{`-32`, "e0"},
+	{`-33`, "d0df"},
+	{`200`, "d100c8"},
+	{`-129`, "d1ff7f"},
+	{`70000`, "d200011170"},
+	{`5000000000`, "d3000000012a05f200"},
+	{`18446744073709551615`, "cfffffffffffffffff"},
+	{`1.5`, "cb3ff8000000000000"},
+	{`""`, "a0"},
+	{`"abc"`, "a3616263"},
+	{`[]`, "90"},
+	{`[1,[2]]`, "9201" + "9102"},
+	{`{}`, "80"},
+	{`{"a":{"b":null}}`, "81a161" + "81a162c0"},
+	{`"` + strings.Repeat("x", 32) + `"`, "d920" + strings.Repeat("78", 32)},
+	{`[` + strings.Repeat("0,", 15) + `0]`, "dc0010" + strings.Repeat("00", 16)},
+}
+
+func TestJSONToMsgpack(t *testing.T) {
+	for _, tt := range msgpackTests {
+		var buf bytes.Buffer
+		if err := JSONToMsgpack(&buf, NewScannerBytes([]byte(tt.json))); err != nil {
+			t.Errorf("%s: unexpected error %v", tt.json, err)
+			continue
+		}
+		if got := hexenc.EncodeToString(buf.Bytes()); got != tt.msgpack {
+			t.Errorf("%s: got %s, want %s", tt.json, got, tt.msgpack)
+		}
+	}
+}
+
+func TestMsgpackToJSON(t *testing.T) {
+	for _, tt := range msgpackTests {
+		p, _ := hexenc.DecodeString(tt.msgpack)
+		var buf bytes.Buffer
+		if err := MsgpackToJSON(NewWriter(&buf), bytes.NewReader(p)); err != nil {
+			t.Errorf("%s: unexpected error %v", tt.json, err)
+			continue
+		}
+		if got := buf.String(); got != tt.json+"\n" {
+			t.Errorf("%s: got %s", tt.json, got)
+		}
+	}
+}
+
+var msgpackToJSONTests = []struct {
+	msgpack string // hex
+	json    string
+}{
+	{"ca3fc00000", `1.5`},
+	{"cc80", `128`},
+	{"cd0100", `256`},
+	{"ce00010000", `65536`},
+	{"cf0000000000000001", `1`},
+	{"d080", `-128`},
+	{"d38000000000000000", `-9223372036854775808`},
+	{"c403010203", `"AQID"`},
+	{"da0001" + "61", `"a"`},
+	{"db00000001" + "61", `"a"`},
+	{"dd00000001" + "01", `[1]`},
+	{"de0001" + "01" + "02", `{"1":2}`},
+	{"df00000001" + "ff" + "02", `{"-1":2}`},
+	{"81" + "cfffffffffffffffff" + "c0", `{"18446744073709551615":null}`},
+}
+
+func TestMsgpackToJSONTypes(t *testing.T) {
+	for _, tt := range msgpackToJSONTests {
+		p, _ := hexenc.DecodeString(tt.msgpack)
+		var buf bytes.Buffer
+		if err := MsgpackToJSON(NewWriter(&buf), bytes.NewReader(p)); err != nil {
+			t.Errorf("%s: unexpected error %v", tt.msgpack, err)
+			continue
+		}
+		if got := buf.String(); got != tt.json+"\n" {
+			t.Errorf("%s: got %s, want %s", tt.msgpack, got, tt.json)
+		}
+	}
+}
+
+func TestMsgpackToJSONError(t *testing.T) {
+	for _, s := range []string{
+		"91",           // missing element
+		"a2",           // short string
+		"d9ff",         // short string
+		"81c0c0",       // null key
+		"c10a",         // never used
+		"d40101",       // fixext 1
+		"cb7ff8000000", // short float
+	} {
+		p, _ := hexenc.DecodeString(s)
+		var buf bytes.Buffer
+		if err := MsgpackToJSON(NewWriter(&buf), bytes.NewReader(p)); err == nil {
+			t.Errorf("%s: no error", s)
+		}
+	}
+}
+
+func TestMsgpackToJSONStream(t *testing.T) {
+	p, _ := hexenc.DecodeString("01" + "02" + "9103" + "80")
+	var buf bytes.Buffer
+	if err := MsgpackToJSON(NewWriter(&buf), bytes.NewReader(p)); err != nil {
+		t.Fatal(err)
+	}
+	const want = "1\n2\n[3]\n{}\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	var mp bytes.Buffer
+	s := NewScannerBytes(buf.Bytes())
+	s.AllowMultiple()
+	if err := JSONToMsgpack(&mp, s); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(mp.Bytes(), p) {
+		t.Errorf("round trip got %x, want %x", mp.Bytes(), p)
+	}
+}
+
+func TestMsgpackRoundTrip(t *testing.T) {
+	const input = `{"name":"é\n","list":[1,-2,3.25,true,null,{"x":[]}],"big":12345678901234567890}` + "\n" + `[2]` + "\n"
+	var mp bytes.Buffer
+	s := NewScannerBytes([]byte(input))
+	s.AllowMultiple()
+	if err := JSONToMsgpack(&mp, s); err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	w := NewWriter(&out)
+	w.SetFraming(FrameLines)
+	if err := MsgpackToJSON(w, &mp); err != nil {
+		t.Fatal(err)
+	}
+	if got := out.String(); got != input {
+		t.Errorf("got %s, want %s", got, input)
+	}
+}
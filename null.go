@@ -0,0 +1,155 @@
+// Copyright 2014 Gary Burd. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package json
+
+import "reflect"
+
+// IsNull returns true if the current value is JSON null.
+func (s *Scanner) IsNull() bool {
+	return s.kind == Null
+}
+
+// NullInt64 is like Int64, but returns ok false for JSON null.
+func (s *Scanner) NullInt64() (v int64, ok bool, err error) {
+	if s.kind == Null {
+		return 0, false, nil
+	}
+	v, err = s.Int64()
+	return v, err == nil, err
+}
+
+// NullUint64 is like Uint64, but returns ok false for JSON null.
+func (s *Scanner) NullUint64() (v uint64, ok bool, err error) {
+	if s.kind == Null {
+		return 0, false, nil
+	}
+	v, err = s.Uint64()
+	return v, err == nil, err
+}
+
+// NullFloat64 is like Float64, but returns ok false for JSON null.
+func (s *Scanner) NullFloat64() (v float64, ok bool, err error) {
+	if s.kind == Null {
+		return 0, false, nil
+	}
+	v, err = s.Float64()
+	return v, err == nil, err
+}
+
+// NullString returns the value of the current string. NullString returns ok
+// false for JSON null.
+func (s *Scanner) NullString() (v string, ok bool, err error) {
+	switch s.kind {
+	case Null:
+		return "", false, nil
+	case String:
+		return string(s.Value()), true, nil
+	}
+	return "", false, &UnmarshalTypeError{s.kind.String(), stringType}
+}
+
+// NullBool returns the value of the current bool. NullBool returns ok false
+// for JSON null.
+func (s *Scanner) NullBool() (v bool, ok bool, err error) {
+	switch s.kind {
+	case Null:
+		return false, false, nil
+	case Bool:
+		return s.Value()[0] == 't', true, nil
+	}
+	return false, false, &UnmarshalTypeError{s.kind.String(), boolType}
+}
+
+var (
+	stringType = reflect.TypeOf("")
+	boolType   = reflect.TypeOf(false)
+)
+
+// The optional types distinguish an object member that is absent from a
+// member with value null. Unmarshal sets Present when the member is in the
+// object and sets Null when the member's value is null. Use a pointer field
+// when absent and null do not need to be distinguished.
+//
+//  type Patch struct {
+//      Name  json.OptionalString `json:"name"`
+//      Count json.OptionalInt64  `json:"count"`
+//  }
+
+// OptionalString is a string that may be absent or null.
+type OptionalString struct {
+	Value   string
+	Present bool
+	Null    bool
+}
+
+// OptionalInt64 is an int64 that may be absent or null.
+type OptionalInt64 struct {
+	Value   int64
+	Present bool
+	Null    bool
+}
+
+// OptionalFloat64 is a float64 that may be absent or null.
+type OptionalFloat64 struct {
+	Value   float64
+	Present bool
+	Null    bool
+}
+
+// OptionalBool is a bool that may be absent or null.
+type OptionalBool struct {
+	Value   bool
+	Present bool
+	Null    bool
+}
+
+// nullUnmarshaler is implemented by Unmarshalers that also decode JSON null.
+type nullUnmarshaler interface {
+	Unmarshaler
+	decodesNull()
+}
+
+func (*OptionalString) decodesNull()  {}
+func (*OptionalInt64) decodesNull()   {}
+func (*OptionalFloat64) decodesNull() {}
+func (*OptionalBool) decodesNull()    {}
+
+// DecodeJSON implements the Unmarshaler interface.
+func (o *OptionalString) DecodeJSON(s *Scanner) error {
+	v, _, err := s.NullString()
+	*o = OptionalString{Value: v, Present: true, Null: s.kind == Null}
+	return optionalError(s, err)
+}
+
+// DecodeJSON implements the Unmarshaler interface.
+func (o *OptionalInt64) DecodeJSON(s *Scanner) error {
+	v, _, err := s.NullInt64()
+	*o = OptionalInt64{Value: v, Present: true, Null: s.kind == Null}
+	return optionalError(s, err)
+}
+
+// DecodeJSON implements the Unmarshaler interface.
+func (o *OptionalFloat64) DecodeJSON(s *Scanner) error {
+	v, _, err := s.NullFloat64()
+	*o = OptionalFloat64{Value: v, Present: true, Null: s.kind == Null}
+	return optionalError(s, err)
+}
+
+// DecodeJSON implements the Unmarshaler interface.
+func (o *OptionalBool) DecodeJSON(s *Scanner) error {
+	v, _, err := s.NullBool()
+	*o = OptionalBool{Value: v, Present: true, Null: s.kind == Null}
+	return optionalError(s, err)
+}
+
+// optionalError skips the current value if it was not decoded.
+func optionalError(s *Scanner, err error) error {
+	if err != nil {
+		if e := s.Skip(); e != nil {
+			return e
+		}
+	}
+	return err
+}
@@ -0,0 +1,92 @@
+// Copyright 2014 Gary Burd. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package json
+
+import (
+	"testing"
+)
+
+func TestNullAccessors(t *testing.T) {
+	s := NewScannerBytes([]byte(`[null, 12, "a", true, 1.5]`))
+	s.Scan()
+
+	s.Scan()
+	if !s.IsNull() {
+		t.Error("IsNull() = false, want true")
+	}
+	if v, ok, err := s.NullInt64(); v != 0 || ok || err != nil {
+		t.Errorf("NullInt64() = %v, %v, %v", v, ok, err)
+	}
+	if v, ok, err := s.NullString(); v != "" || ok || err != nil {
+		t.Errorf("NullString() = %v, %v, %v", v, ok, err)
+	}
+
+	s.Scan()
+	if s.IsNull() {
+		t.Error("IsNull() = true, want false")
+	}
+	if v, ok, err := s.NullInt64(); v != 12 || !ok || err != nil {
+		t.Errorf("NullInt64() = %v, %v, %v", v, ok, err)
+	}
+	if v, ok, err := s.NullUint64(); v != 12 || !ok || err != nil {
+		t.Errorf("NullUint64() = %v, %v, %v", v, ok, err)
+	}
+	if _, ok, err := s.NullString(); ok || err == nil {
+		t.Errorf("NullString() = %v, %v, want error", ok, err)
+	}
+
+	s.Scan()
+	if v, ok, err := s.NullString(); v != "a" || !ok || err != nil {
+		t.Errorf("NullString() = %v, %v, %v", v, ok, err)
+	}
+
+	s.Scan()
+	if v, ok, err := s.NullBool(); !v || !ok || err != nil {
+		t.Errorf("NullBool() = %v, %v, %v", v, ok, err)
+	}
+
+	s.Scan()
+	if v, ok, err := s.NullFloat64(); v != 1.5 || !ok || err != nil {
+		t.Errorf("NullFloat64() = %v, %v, %v", v, ok, err)
+	}
+	if _, ok, err := s.NullInt64(); ok || err == nil {
+		t.Errorf("NullInt64() = %v, %v, want error", ok, err)
+	}
+}
+
+type nullPatch struct {
+	Name  OptionalString  `json:"name"`
+	Count OptionalInt64   `json:"count"`
+	Ratio OptionalFloat64 `json:"ratio"`
+	On    OptionalBool    `json:"on"`
+	Note  *string         `json:"note"`
+}
+
+func TestOptional(t *testing.T) {
+	var p nullPatch
+	note := "x"
+	p.Note = &note
+	s := NewScannerBytes([]byte(`{"name": null, "count": 3, "on": false, "note": null}`))
+	s.Scan()
+	if err := Unmarshal(s, &p); err != nil {
+		t.Fatal(err)
+	}
+	want := nullPatch{
+		Name:  OptionalString{Present: true, Null: true},
+		Count: OptionalInt64{Value: 3, Present: true},
+		On:    OptionalBool{Present: true},
+	}
+	if p != want {
+		t.Errorf("got %+v, want %+v", p, want)
+	}
+
+	s = NewScannerBytes([]byte(`{"count": "3", "name": "a"}`))
+	s.Scan()
+	p = nullPatch{}
+	err := Unmarshal(s, &p)
+	if _, ok := err.(*UnmarshalTypeError); !ok {
+		t.Errorf("got error %v, want type error", err)
+	}
+}
@@ -0,0 +1,313 @@
+// Copyright 2014 Gary Burd. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package json
+
+import (
+	"errors"
+	"math/big"
+	"strconv"
+)
+
+// Int64 returns the value of the current number as an int64. The number is
+// parsed from the scanner's buffer without allocating memory.
+func (s *Scanner) Int64() (int64, error) {
+	if s.kind != Number {
+		return 0, &UnmarshalTypeError{s.kind.String(), int64Type}
+	}
+	p := s.Value()
+	neg := p[0] == '-'
+	if neg {
+		p = p[1:]
+	}
+	u, ok := parseUint64(p)
+	switch {
+	case !ok:
+	case neg && u <= 1<<63:
+		return -int64(u), nil
+	case !neg && u < 1<<63:
+		return int64(u), nil
+	}
+	i, err := strconv.ParseInt(string(s.Value()), 10, 64)
+	if err != nil {
+		err = s.numberError("int64", err)
+	}
+	return i, err
+}
+
+// Uint64 returns the value of the current number as a uint64. The number is
+// parsed from the scanner's buffer without allocating memory.
+func (s *Scanner) Uint64() (uint64, error) {
+	if s.kind != Number {
+		return 0, &UnmarshalTypeError{s.kind.String(), uint64Type}
+	}
+	if u, ok := parseUint64(s.Value()); ok {
+		return u, nil
+	}
+	u, err := strconv.ParseUint(string(s.Value()), 10, 64)
+	if err != nil {
+		err = s.numberError("uint64", err)
+	}
+	return u, err
+}
+
+// Float64 returns the value of the current number as a float64. Integers
+// with up to 15 digits are converted without allocating memory.
+func (s *Scanner) Float64() (float64, error) {
+	if s.kind != Number {
+		return 0, &UnmarshalTypeError{s.kind.String(), float64Type}
+	}
+	p := s.Value()
+	neg := p[0] == '-'
+	if neg {
+		p = p[1:]
+	}
+	if len(p) <= 15 {
+		// All integers with 15 or fewer digits are exactly representable
+		// as a float64.
+		if u, ok := parseUint64(p); ok {
+			f := float64(u)
+			if neg {
+				f = -f
+			}
+			return f, nil
+		}
+	}
+	f, err := strconv.ParseFloat(string(s.Value()), 64)
+	if err != nil {
+		err = s.numberError("float64", err)
+	}
+	return f, err
+}
+
+// Int returns the value of the current number as a signed integer with the
+// given bit size. A bit size of 0 corresponds to int. If the number does not
+// fit in the bit size, then Int returns a *NumberError that wraps
+// strconv.ErrRange.
+func (s *Scanner) Int(bitSize int) (int64, error) {
+	typ := "int"
+	if bitSize == 0 {
+		bitSize = strconv.IntSize
+	} else {
+		typ += strconv.Itoa(bitSize)
+	}
+	i, err := s.Int64()
+	if isRangeError(err) {
+		return 0, s.rangeError("ParseInt", typ)
+	}
+	if err != nil {
+		return 0, err
+	}
+	if bitSize < 64 && (i < -1<<uint(bitSize-1) || i >= 1<<uint(bitSize-1)) {
+		return 0, s.rangeError("ParseInt", typ)
+	}
+	return i, nil
+}
+
+// Uint returns the value of the current number as an unsigned integer with
+// the given bit size. A bit size of 0 corresponds to uint. If the number does
+// not fit in the bit size, then Uint returns a *NumberError that wraps
+// strconv.ErrRange.
+func (s *Scanner) Uint(bitSize int) (uint64, error) {
+	typ := "uint"
+	if bitSize == 0 {
+		bitSize = strconv.IntSize
+	} else {
+		typ += strconv.Itoa(bitSize)
+	}
+	u, err := s.Uint64()
+	if isRangeError(err) {
+		return 0, s.rangeError("ParseUint", typ)
+	}
+	if err != nil {
+		if _, e := s.Int64(); e == nil {
+			// Negative integer.
+			return 0, s.rangeError("ParseUint", typ)
+		}
+		return 0, err
+	}
+	if bitSize < 64 && u >= 1<<uint(bitSize) {
+		return 0, s.rangeError("ParseUint", typ)
+	}
+	return u, nil
+}
+
+// Float returns the value of the current number as a floating-point number
+// with the given bit size, 32 or 64. If the number does not fit in the bit
+// size, then Float returns a *NumberError that wraps strconv.ErrRange.
+func (s *Scanner) Float(bitSize int) (float64, error) {
+	typ := "float" + strconv.Itoa(bitSize)
+	if bitSize != 32 || s.kind != Number {
+		f, err := s.Float64()
+		if isRangeError(err) {
+			return 0, s.rangeError("ParseFloat", typ)
+		}
+		return f, err
+	}
+	// Parse with bit size 32 to round once to the nearest float32.
+	f, err := strconv.ParseFloat(string(s.Value()), 32)
+	if isRangeError(err) {
+		return 0, s.rangeError("ParseFloat", typ)
+	}
+	if err != nil {
+		return 0, s.numberError(typ, err)
+	}
+	return f, nil
+}
+
+func isRangeError(err error) bool {
+	return errors.Is(err, strconv.ErrRange)
+}
+
+func (s *Scanner) numberError(typ string, err error) error {
+	offset, line, column := s.position(s.data[valueData].pos)
+	return &NumberError{
+		Value:  string(s.Value()),
+		Type:   typ,
+		Path:   s.PathString(),
+		Pos:    int(offset),
+		Line:   line,
+		Column: column,
+		Err:    err,
+	}
+}
+
+// rangeError returns a *NumberError for a number that is out of range for
+// typ. The error wraps a *strconv.NumError with strconv.ErrRange.
+func (s *Scanner) rangeError(fn, typ string) error {
+	return s.numberError(typ, &strconv.NumError{Func: fn, Num: string(s.Value()), Err: strconv.ErrRange})
+}
+
+// NumberError is returned by the Scanner's typed number accessors and by the
+// NumberValue conversion methods when the number cannot be converted to the
+// requested type. The error wraps the *strconv.NumError
+// from the conversion:
+//
+//  if errors.Is(err, strconv.ErrRange) {
+//      // handle out of range number
+//  }
+type NumberError struct {
+	Value  string // the number
+	Type   string // the requested type, int64 for example
+	Path   string // path to the number if the scanner tracks the path
+	Pos    int    // input offset of the number
+	Line   int    // line of the number, starting at one, or zero for a NumberValue
+	Column int    // byte column of the number, starting at one
+	Err    error  // the conversion error
+}
+
+func (e *NumberError) Error() string {
+	s := "cannot convert number " + e.Value + " to " + e.Type
+	if errors.Is(e.Err, strconv.ErrRange) {
+		s = "number " + e.Value + " out of range for " + e.Type
+	}
+	if e.Path != "" {
+		s += " at " + e.Path
+	}
+	if e.Line > 0 {
+		s += " (line " + strconv.Itoa(e.Line) + ", column " + strconv.Itoa(e.Column) + ")"
+	}
+	return s
+}
+
+// Unwrap returns the conversion error.
+func (e *NumberError) Unwrap() error {
+	return e.Err
+}
+
+// isValidNumber returns true if s matches the JSON number grammar.
+func isValidNumber(s string) bool {
+	i := 0
+	if i < len(s) && s[i] == '-' {
+		i++
+	}
+	switch {
+	case i < len(s) && s[i] == '0':
+		i++
+	case i < len(s) && '1' <= s[i] && s[i] <= '9':
+		for i < len(s) && isDecimalDigit(s[i]) {
+			i++
+		}
+	default:
+		return false
+	}
+	if i < len(s) && s[i] == '.' {
+		i++
+		if i >= len(s) || !isDecimalDigit(s[i]) {
+			return false
+		}
+		for i < len(s) && isDecimalDigit(s[i]) {
+			i++
+		}
+	}
+	if i < len(s) && (s[i] == 'e' || s[i] == 'E') {
+		i++
+		if i < len(s) && (s[i] == '+' || s[i] == '-') {
+			i++
+		}
+		if i >= len(s) || !isDecimalDigit(s[i]) {
+			return false
+		}
+		for i < len(s) && isDecimalDigit(s[i]) {
+			i++
+		}
+	}
+	return i == len(s)
+}
+
+// parseUint64 parses the decimal digits in p. The function returns false if p
+// contains a non-digit or the value overflows a uint64.
+func parseUint64(p []byte) (uint64, bool) {
+	if len(p) == 0 {
+		return 0, false
+	}
+	var u uint64
+	for _, b := range p {
+		if !isDecimalDigit(b) {
+			return 0, false
+		}
+		if u > (1<<64-1)/10 {
+			return 0, false
+		}
+		v := u*10 + uint64(b-'0')
+		if v < u {
+			return 0, false
+		}
+		u = v
+	}
+	return u, true
+}
+
+// isRelaxedNumber returns true if the number p was accepted by
+// AllowRelaxedNumbers and is not valid JSON.
+func isRelaxedNumber(p []byte) bool {
+	if p[0] == '-' {
+		p = p[1:]
+	}
+	return p[0] == '+' || p[0] == '.' || len(p) > 1 && (p[1] == 'x' || p[1] == 'X')
+}
+
+// normalizeNumber appends the JSON form of the number p accepted by
+// AllowRelaxedNumbers to dst.
+func normalizeNumber(dst, p []byte) []byte {
+	switch p[0] {
+	case '+':
+		p = p[1:]
+	case '-':
+		dst = append(dst, '-')
+		p = p[1:]
+	}
+	switch {
+	case len(p) > 1 && (p[1] == 'x' || p[1] == 'X'):
+		if u, err := strconv.ParseUint(string(p[2:]), 16, 64); err == nil {
+			return strconv.AppendUint(dst, u, 10)
+		}
+		var n big.Int
+		n.SetString(string(p[2:]), 16)
+		return n.Append(dst, 10)
+	case p[0] == '.':
+		dst = append(dst, '0')
+	}
+	return append(dst, p...)
+}
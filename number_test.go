@@ -0,0 +1,165 @@
+// Copyright 2014 Gary Burd. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package json
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"testing"
+)
+
+var scanNumberTests = []struct {
+	in  string
+	i   int64
+	iok bool
+	u   uint64
+	uok bool
+	f   float64
+	fok bool
+}{
+	{"0", 0, true, 0, true, 0, true},
+	{"-0", 0, true, 0, false, 0, true},
+	{"123", 123, true, 123, true, 123, true},
+	{"-123", -123, true, 0, false, -123, true},
+	{"1.5", 0, false, 0, false, 1.5, true},
+	{"1e3", 0, false, 0, false, 1000, true},
+	{"9223372036854775807", 9223372036854775807, true, 9223372036854775807, true, 9223372036854775807, true},
+	{"-9223372036854775808", -9223372036854775808, true, 0, false, -9223372036854775808, true},
+	{"9223372036854775808", 0, false, 9223372036854775808, true, 9223372036854775808, true},
+	{"18446744073709551615", 0, false, 18446744073709551615, true, 18446744073709551615, true},
+	{"18446744073709551616", 0, false, 0, false, 18446744073709551616, true},
+	{`"1"`, 0, false, 0, false, 0, false},
+}
+
+func TestScannerNumbers(t *testing.T) {
+	for _, tt := range scanNumberTests {
+		s := NewScannerBytes([]byte(tt.in))
+		s.Scan()
+		i, err := s.Int64()
+		if (err == nil) != tt.iok || (tt.iok && i != tt.i) {
+			t.Errorf("%s: Int64() = %d, %v", tt.in, i, err)
+		}
+		u, err := s.Uint64()
+		if (err == nil) != tt.uok || (tt.uok && u != tt.u) {
+			t.Errorf("%s: Uint64() = %d, %v", tt.in, u, err)
+		}
+		f, err := s.Float64()
+		if (err == nil) != tt.fok || (tt.fok && f != tt.f) {
+			t.Errorf("%s: Float64() = %g, %v", tt.in, f, err)
+		}
+	}
+}
+
+func TestScannerNumbersAllocs(t *testing.T) {
+	s := NewScannerBytes([]byte(`[-12345,67890]`))
+	s.Scan()
+	s.Scan()
+	if n := testing.AllocsPerRun(100, func() { s.Int64(); s.Float64() }); n != 0 {
+		t.Errorf("negative allocs = %g, want 0", n)
+	}
+	s.Scan()
+	if n := testing.AllocsPerRun(100, func() { s.Int64(); s.Uint64(); s.Float64() }); n != 0 {
+		t.Errorf("positive allocs = %g, want 0", n)
+	}
+}
+
+var scanTypedNumberTests = []struct {
+	in      string
+	bitSize int
+	i       interface{} // int64 result or path of range error
+	u       interface{} // uint64 result or path of range error
+	f       interface{} // float64 result or path of range error
+}{
+	{`{"a":[127]}`, 8, int64(127), uint64(127), float64(127)},
+	{`{"a":[128]}`, 8, "/a/0", uint64(128), float64(128)},
+	{`{"a":[-128]}`, 8, int64(-128), "/a/0", float64(-128)},
+	{`{"a":[256]}`, 8, "/a/0", "/a/0", float64(256)},
+	{`{"a":[-129]}`, 16, int64(-129), "/a/0", float64(-129)},
+	{`{"a":[4294967296]}`, 32, "/a/0", "/a/0", float64(4294967296)},
+	{`{"a":[1e39]}`, 32, nil, nil, "/a/0"},
+	{`{"a":[1e400]}`, 64, nil, nil, "/a/0"},
+	{`{"a":[99999999999999999999]}`, 64, "/a/0", "/a/0", float64(99999999999999999999)},
+	{`{"a":[1.5]}`, 32, nil, nil, float64(1.5)},
+	{`{"a":[3.4028235e38]}`, 32, nil, nil, float64(float32(math.MaxFloat32))},
+	{`{"a":[1.00000005960464477550]}`, 32, nil, nil, float64(float32(1.0000001))},
+	{`{"a":[7.038531e-26]}`, 32, nil, nil, float64(float32(7.038531e-26))},
+}
+
+func TestScannerTypedNumbers(t *testing.T) {
+	for _, tt := range scanTypedNumberTests {
+		s := NewScannerBytes([]byte(tt.in))
+		s.TrackPath(true)
+		for s.Scan() && s.Kind() != Number {
+		}
+		check := func(name string, v interface{}, err error, want interface{}) {
+			switch want := want.(type) {
+			case nil:
+				if err == nil {
+					t.Errorf("%s %s(%d): no error", tt.in, name, tt.bitSize)
+				}
+			case string:
+				e, ok := err.(*NumberError)
+				if !ok || !errors.Is(err, strconv.ErrRange) {
+					t.Errorf("%s %s(%d): got %v, %v, want range error", tt.in, name, tt.bitSize, v, err)
+				} else if e.Path != want || e.Column != 7 || e.Line != 1 {
+					t.Errorf("%s %s(%d): got error %+v", tt.in, name, tt.bitSize, e)
+				}
+			default:
+				if err != nil || v != want {
+					t.Errorf("%s %s(%d): got %v, %v, want %v", tt.in, name, tt.bitSize, v, err, want)
+				}
+			}
+		}
+		i, err := s.Int(tt.bitSize)
+		check("Int", i, err, tt.i)
+		u, err := s.Uint(tt.bitSize)
+		check("Uint", u, err, tt.u)
+		f, err := s.Float(tt.bitSize)
+		check("Float", f, err, tt.f)
+	}
+}
+
+func TestRangeError(t *testing.T) {
+	s := NewScannerBytes([]byte("[\n 300]"))
+	s.Scan()
+	s.Scan()
+	_, err := s.Int(8)
+	if got, want := err.Error(), "number 300 out of range for int8 (line 2, column 2)"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestNumberError(t *testing.T) {
+	s := NewScannerBytes([]byte("{\"a\":\n [1.5, 99999999999999999999]}"))
+	s.TrackPath(true)
+	for s.Scan() && s.Kind() != Number {
+	}
+	_, err := s.Int64()
+	if got, want := err.Error(), "cannot convert number 1.5 to int64 at /a/0 (line 2, column 3)"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if !errors.Is(err, strconv.ErrSyntax) {
+		t.Errorf("error %v does not match strconv.ErrSyntax", err)
+	}
+	s.Scan()
+	_, err = s.Int64()
+	e, ok := err.(*NumberError)
+	if !ok || e.Value != "99999999999999999999" || e.Type != "int64" || e.Pos != 13 || !errors.Is(err, strconv.ErrRange) {
+		t.Errorf("got %#v", err)
+	}
+
+	_, err = NumberValue("1e400").Float64()
+	if got, want := err.Error(), "number 1e400 out of range for float64"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	var ne *strconv.NumError
+	if !errors.As(err, &ne) {
+		t.Errorf("error %v does not wrap *strconv.NumError", err)
+	}
+	if _, err := NumberValue("12").Int(); err != nil {
+		t.Errorf("Int returned %v", err)
+	}
+}
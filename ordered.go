@@ -0,0 +1,135 @@
+// Copyright 2014 Gary Burd. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package json
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+)
+
+// OrderedObject is a JSON object that preserves the order of its members and
+// members with duplicate names. DecodeValueWith decodes objects as
+// *OrderedObject when the Ordered option is set.
+type OrderedObject struct {
+	Members []Member
+}
+
+// Member is a member of an OrderedObject.
+type Member struct {
+	Name  string
+	Value interface{}
+}
+
+// Get returns the value of the last member with the given name.
+func (o *OrderedObject) Get(name string) (interface{}, bool) {
+	for i := len(o.Members) - 1; i >= 0; i-- {
+		if o.Members[i].Name == name {
+			return o.Members[i].Value, true
+		}
+	}
+	return nil, false
+}
+
+// Set sets the value of the last member with the given name. If there is no
+// member with the name, then Set appends a member to the object.
+func (o *OrderedObject) Set(name string, v interface{}) {
+	for i := len(o.Members) - 1; i >= 0; i-- {
+		if o.Members[i].Name == name {
+			o.Members[i].Value = v
+			return
+		}
+	}
+	o.Members = append(o.Members, Member{name, v})
+}
+
+// DecodeJSON implements the Unmarshaler interface. Nested objects are
+// decoded as *OrderedObject.
+func (o *OrderedObject) DecodeJSON(s *Scanner) error {
+	if s.Kind() != Object {
+		err := fmt.Errorf("unexpected %v, expected object", s.Kind())
+		if e := s.Skip(); e != nil {
+			return e
+		}
+		return err
+	}
+	v, err := DecodeValueWith(s, DecodeOptions{Ordered: true})
+	if v, ok := v.(*OrderedObject); ok {
+		*o = *v
+	}
+	return err
+}
+
+// EncodeJSON implements the Marshaler interface. Member values must have one
+// of the types returned by DecodeValueWith or implement Marshaler.
+func (o *OrderedObject) EncodeJSON(w *Writer) error {
+	if err := w.StartObject(); err != nil {
+		return err
+	}
+	for _, m := range o.Members {
+		if err := w.Name(m.Name); err != nil {
+			return err
+		}
+		if err := writeDecoded(w, m.Value); err != nil {
+			return err
+		}
+	}
+	return w.EndObject()
+}
+
+// writeDecoded writes a value with one of the types returned by
+// DecodeValueWith or a value that implements Marshaler. Members of
+// map[string]interface{} are written sorted by name.
+func writeDecoded(w *Writer, v interface{}) error {
+	switch v := v.(type) {
+	case nil:
+		return w.Null()
+	case bool:
+		return w.Bool(v)
+	case string:
+		return w.String(v)
+	case NumberValue:
+		return w.Number(v)
+	case float64:
+		return w.Float(v)
+	case int64:
+		return w.Int(v)
+	case *big.Int:
+		return w.Number(NumberValue(v.String()))
+	case *big.Float:
+		return w.Number(NumberValue(v.Text('g', -1)))
+	case []interface{}:
+		if err := w.StartArray(); err != nil {
+			return err
+		}
+		for _, e := range v {
+			if err := writeDecoded(w, e); err != nil {
+				return err
+			}
+		}
+		return w.EndArray()
+	case map[string]interface{}:
+		names := make([]string, 0, len(v))
+		for name := range v {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		if err := w.StartObject(); err != nil {
+			return err
+		}
+		for _, name := range names {
+			if err := w.Name(name); err != nil {
+				return err
+			}
+			if err := writeDecoded(w, v[name]); err != nil {
+				return err
+			}
+		}
+		return w.EndObject()
+	case Marshaler:
+		return v.EncodeJSON(w)
+	}
+	return fmt.Errorf("unsupported type %T", v)
+}
@@ -0,0 +1,68 @@
+// Copyright 2014 Gary Burd. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package json
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestDecodeOrdered(t *testing.T) {
+	s := NewScannerBytes([]byte(`{"b":1,"a":[{"z":null,"y":true}],"b":"x"}`))
+	s.Scan()
+	v, err := DecodeValueWith(s, DecodeOptions{Ordered: true, Number: UseInt64})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &OrderedObject{[]Member{
+		{"b", int64(1)},
+		{"a", []interface{}{&OrderedObject{[]Member{{"z", nil}, {"y", true}}}}},
+		{"b", "x"},
+	}}
+	if !reflect.DeepEqual(v, want) {
+		t.Errorf("got %#v, want %#v", v, want)
+	}
+}
+
+var orderedRoundTripTests = []string{
+	`{}`,
+	`{"b":1,"a":2,"b":3}`,
+	`{"z":[1,{"y":"a","x":false}],"w":{"v":null}}`,
+}
+
+func TestOrderedRoundTrip(t *testing.T) {
+	for _, in := range orderedRoundTripTests {
+		var o OrderedObject
+		if err := UnmarshalBytes([]byte(in), &o); err != nil {
+			t.Errorf("%s: UnmarshalBytes returned %v", in, err)
+			continue
+		}
+		var buf bytes.Buffer
+		if err := o.EncodeJSON(NewWriter(&buf)); err != nil {
+			t.Errorf("%s: EncodeJSON returned %v", in, err)
+			continue
+		}
+		if got := buf.String(); got != in {
+			t.Errorf("got %s, want %s", got, in)
+		}
+	}
+}
+
+func TestOrderedGetSet(t *testing.T) {
+	o := &OrderedObject{[]Member{{"a", 1}, {"b", 2}, {"a", 3}}}
+	if v, ok := o.Get("a"); !ok || v != 3 {
+		t.Errorf("Get(a) = %v, %v, want 3, true", v, ok)
+	}
+	if _, ok := o.Get("c"); ok {
+		t.Error("Get(c) returned ok")
+	}
+	o.Set("a", 4)
+	o.Set("c", 5)
+	want := []Member{{"a", 1}, {"b", 2}, {"a", 4}, {"c", 5}}
+	if !reflect.DeepEqual(o.Members, want) {
+		t.Errorf("got %v, want %v", o.Members, want)
+	}
+}
@@ -0,0 +1,218 @@
+// Copyright 2014 Gary Burd. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package json
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"runtime"
+	"sync"
+)
+
+// batchSize is the approximate number of input bytes handed to a worker at
+// a time.
+const batchSize = 64 * 1024
+
+// ParallelLines scans newline-delimited JSON from r using a pool of
+// goroutines. Each non-blank line is scanned as a single JSON value. fn is
+// called concurrently with a scanner for each line and should scan the
+// complete value. If workers is less than one, then GOMAXPROCS workers are
+// used.
+//
+// ParallelLines stops at the first error returned by fn or from reading r
+// and returns that error. The positions in syntax, duplicate key and range
+// errors are adjusted to the position of the error in r.
+func ParallelLines(r io.Reader, workers int, fn func(*Scanner) error) error {
+	return parallelLines(r, workers, func(s *Scanner) (interface{}, error) {
+		return nil, fn(s)
+	}, nil)
+}
+
+// ParallelLinesOrdered is like ParallelLines, but preserves the order of the
+// input. fn is called concurrently and returns a result for each line. emit
+// is called with the results in input order from the goroutine that called
+// ParallelLinesOrdered. If emit returns an error, then ParallelLinesOrdered
+// stops and returns the error. Results are held for at most 2*workers batches
+// of input, so memory use does not grow with the input when a line is slow.
+func ParallelLinesOrdered(r io.Reader, workers int, fn func(*Scanner) (interface{}, error), emit func(interface{}) error) error {
+	return parallelLines(r, workers, fn, emit)
+}
+
+// lineBatch is a run of complete lines scanned by one worker.
+type lineBatch struct {
+	seq     int
+	data    []byte
+	line    int   // line number of data[0]
+	offset  int64 // input offset of data[0]
+	results []interface{}
+	err     error
+}
+
+func parallelLines(r io.Reader, workers int, fn func(*Scanner) (interface{}, error), emit func(interface{}) error) error {
+	if workers < 1 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	batches := make(chan *lineBatch, workers)
+	done := make(chan *lineBatch, workers)
+	stop := make(chan struct{})
+
+	// Bound the batches read and not yet emitted so that results waiting on
+	// a slow batch do not accumulate without limit.
+	sem := make(chan struct{}, 2*workers)
+
+	var readErr error
+	go func() {
+		readErr = readBatches(r, batches, sem, stop)
+		close(batches)
+	}()
+
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer wg.Done()
+			s := NewScannerBytes(nil)
+			for b := range batches {
+				select {
+				case <-stop:
+				default:
+					b.scan(s, fn, emit != nil)
+				}
+				done <- b
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	var err error
+	pending := make(map[int]*lineBatch)
+	next := 0
+	for b := range done {
+		if err != nil {
+			continue
+		}
+		if emit == nil {
+			<-sem
+			err = b.err
+		} else {
+			pending[b.seq] = b
+			for err == nil && pending[next] != nil {
+				b := pending[next]
+				delete(pending, next)
+				next++
+				<-sem
+				for _, v := range b.results {
+					if err = emit(v); err != nil {
+						break
+					}
+				}
+				if err == nil {
+					err = b.err
+				}
+			}
+		}
+		if err != nil {
+			close(stop)
+		}
+	}
+	if err == nil {
+		err = readErr
+	}
+	return err
+}
+
+// readBatches splits the input into batches of complete lines. A batch is
+// sent after acquiring a slot in sem.
+func readBatches(r io.Reader, batches chan<- *lineBatch, sem chan<- struct{}, stop <-chan struct{}) error {
+	br := bufio.NewReaderSize(r, batchSize)
+	b := &lineBatch{line: 1}
+	for {
+		p, err := br.ReadSlice('\n')
+		b.data = append(b.data, p...)
+		if err == bufio.ErrBufferFull {
+			continue
+		}
+		if len(b.data) >= batchSize || (err != nil && len(b.data) > 0) {
+			next := &lineBatch{
+				seq:    b.seq + 1,
+				line:   b.line + bytes.Count(b.data, []byte{'\n'}),
+				offset: b.offset + int64(len(b.data)),
+			}
+			select {
+			case sem <- struct{}{}:
+			case <-stop:
+				return nil
+			}
+			select {
+			case batches <- b:
+			case <-stop:
+				return nil
+			}
+			b = next
+		}
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+}
+
+// scan calls fn for each non-blank line in the batch.
+func (b *lineBatch) scan(s *Scanner, fn func(*Scanner) (interface{}, error), keep bool) {
+	p := b.data
+	line := b.line
+	offset := b.offset
+	for len(p) > 0 {
+		n := bytes.IndexByte(p, '\n') + 1
+		if n == 0 {
+			n = len(p)
+		}
+		if !isBlank(p[:n]) {
+			s.ResetBytes(p[:n])
+			v, err := fn(s)
+			if err != nil {
+				b.err = lineError(err, line, offset)
+				return
+			}
+			if keep {
+				b.results = append(b.results, v)
+			}
+		}
+		p = p[n:]
+		line++
+		offset += int64(n)
+	}
+}
+
+func isBlank(p []byte) bool {
+	for _, b := range p {
+		if !isWhiteSpace(b) {
+			return false
+		}
+	}
+	return true
+}
+
+// lineError adjusts the position in err from the start of a line to the
+// start of the input.
+func lineError(err error, line int, offset int64) error {
+	switch e := err.(type) {
+	case *SyntaxError:
+		e.Pos += int(offset)
+		e.Line = line
+	case *DuplicateKeyError:
+		e.Pos += int(offset)
+		e.Line = line
+	case *NumberError:
+		e.Pos += int(offset)
+		e.Line = line
+	}
+	return err
+}
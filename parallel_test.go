@@ -0,0 +1,147 @@
+// Copyright 2014 Gary Burd. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package json
+
+import (
+	"bytes"
+	"errors"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func parallelInput(n int) []byte {
+	var buf bytes.Buffer
+	for i := 0; i < n; i++ {
+		buf.WriteString(`{"n": ` + strconv.Itoa(i) + "}\n")
+		if i%100 == 0 {
+			buf.WriteString(" \n")
+		}
+	}
+	return buf.Bytes()
+}
+
+func scanN(s *Scanner) (int64, error) {
+	for s.Scan() {
+		if string(s.Name()) == "n" {
+			return s.Int(64)
+		}
+	}
+	if err := s.Err(); err != nil {
+		return 0, err
+	}
+	return 0, errors.New("n not found")
+}
+
+func TestParallelLines(t *testing.T) {
+	const n = 20000
+	var sum, count int64
+	err := ParallelLines(bytes.NewReader(parallelInput(n)), 4, func(s *Scanner) error {
+		v, err := scanN(s)
+		atomic.AddInt64(&sum, v)
+		atomic.AddInt64(&count, 1)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != n || sum != n*(n-1)/2 {
+		t.Errorf("count, sum = %d, %d, want %d, %d", count, sum, n, n*(n-1)/2)
+	}
+}
+
+func TestParallelLinesOrdered(t *testing.T) {
+	const n = 20000
+	var got []int64
+	err := ParallelLinesOrdered(bytes.NewReader(parallelInput(n)), 4, func(s *Scanner) (interface{}, error) {
+		return scanN(s)
+	}, func(v interface{}) error {
+		got = append(got, v.(int64))
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != n {
+		t.Fatalf("got %d results, want %d", len(got), n)
+	}
+	for i, v := range got {
+		if v != int64(i) {
+			t.Fatalf("got[%d] = %d", i, v)
+		}
+	}
+}
+
+func TestParallelLinesOrderedBound(t *testing.T) {
+	// While the first line is blocked, the other worker scans at most the
+	// batches that fit in the bound of 2*workers.
+	const (
+		n       = 100000
+		workers = 2
+		max     = (2*workers - 1) * (batchSize/10 + 1)
+	)
+	var count int64
+	var got int64 = -1
+	err := ParallelLinesOrdered(bytes.NewReader(parallelInput(n)), workers, func(s *Scanner) (interface{}, error) {
+		v, err := scanN(s)
+		if v != 0 {
+			atomic.AddInt64(&count, 1)
+			return v, err
+		}
+		// Wait for the other worker to stop making progress.
+		last := int64(-1)
+		for i := 0; i < 200; i++ {
+			time.Sleep(10 * time.Millisecond)
+			c := atomic.LoadInt64(&count)
+			if c == last {
+				break
+			}
+			last = c
+		}
+		got = last
+		return v, err
+	}, func(v interface{}) error {
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got > max {
+		t.Errorf("scanned %d lines while the first line was blocked, want at most %d", got, max)
+	}
+}
+
+func TestParallelLinesError(t *testing.T) {
+	input := append(parallelInput(5000), `{"n": x}`+"\n"...)
+	input = append(input, parallelInput(10)...)
+	err := ParallelLinesOrdered(bytes.NewReader(input), 4, func(s *Scanner) (interface{}, error) {
+		return scanN(s)
+	}, func(v interface{}) error {
+		return nil
+	})
+	e, ok := err.(*SyntaxError)
+	if !ok {
+		t.Fatalf("got error %v, want syntax error", err)
+	}
+	const line = 5000 + 50 + 1
+	pos := bytes.Index(input, []byte("x"))
+	if e.Line != line || e.Pos != pos || e.Column != 7 {
+		t.Errorf("got line %d, pos %d, column %d, want %d, %d, 7", e.Line, e.Pos, e.Column, line, pos)
+	}
+
+	errEmit := errors.New("emit")
+	err = ParallelLinesOrdered(bytes.NewReader(parallelInput(1000)), 4, func(s *Scanner) (interface{}, error) {
+		return scanN(s)
+	}, func(v interface{}) error {
+		if v.(int64) == 500 {
+			return errEmit
+		}
+		return nil
+	})
+	if err != errEmit {
+		t.Errorf("got error %v, want %v", err, errEmit)
+	}
+}
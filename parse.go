@@ -0,0 +1,80 @@
+// Copyright 2014 Gary Burd. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package json
+
+import (
+	"io"
+)
+
+// Handler receives the elements of a JSON document from Parse. The slices
+// passed to the handler methods are valid only until the method returns. If
+// a method returns an error, then parsing stops and Parse returns the error.
+type Handler interface {
+	// OnObjectStart is called at the start of an object.
+	OnObjectStart() error
+
+	// OnArrayStart is called at the start of an array.
+	OnArrayStart() error
+
+	// OnEnd is called at the end of an object or array.
+	OnEnd() error
+
+	// OnKey is called with the name of an object member before the member
+	// value.
+	OnKey(name []byte) error
+
+	// OnString is called with the unescaped value of a string.
+	OnString(value []byte) error
+
+	// OnNumber is called with the text of a number.
+	OnNumber(value []byte) error
+
+	// OnBool is called with the value of a boolean.
+	OnBool(value bool) error
+
+	// OnNull is called for null.
+	OnNull() error
+}
+
+// Parse parses the JSON document read from r and calls the methods of h for
+// each element of the document.
+func Parse(r io.Reader, h Handler) error {
+	return ParseScanner(NewScanner(r), h)
+}
+
+// ParseScanner scans the remaining elements from s and calls the methods of
+// h for each element.
+func ParseScanner(s *Scanner, h Handler) error {
+	for s.Scan() {
+		var err error
+		if s.Kind() != End {
+			if name := s.Name(); name != nil {
+				if err := h.OnKey(name); err != nil {
+					return err
+				}
+			}
+		}
+		switch s.Kind() {
+		case Object:
+			err = h.OnObjectStart()
+		case Array:
+			err = h.OnArrayStart()
+		case End:
+			err = h.OnEnd()
+		case String:
+			err = h.OnString(s.Value())
+		case Number:
+			err = h.OnNumber(s.Value())
+		case Bool:
+			err = h.OnBool(s.Value()[0] == 't')
+		case Null:
+			err = h.OnNull()
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return s.Err()
+}
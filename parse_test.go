@@ -0,0 +1,60 @@
+// Copyright 2014 Gary Burd. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package json
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+type recordingHandler struct {
+	events []string
+	stopAt string
+}
+
+func (h *recordingHandler) record(e string) error {
+	h.events = append(h.events, e)
+	if e == h.stopAt {
+		return errors.New("stop")
+	}
+	return nil
+}
+
+func (h *recordingHandler) OnObjectStart() error    { return h.record("{") }
+func (h *recordingHandler) OnArrayStart() error     { return h.record("[") }
+func (h *recordingHandler) OnEnd() error            { return h.record("end") }
+func (h *recordingHandler) OnKey(name []byte) error { return h.record("key " + string(name)) }
+func (h *recordingHandler) OnString(value []byte) error {
+	return h.record(strconv.Quote(string(value)))
+}
+func (h *recordingHandler) OnNumber(value []byte) error { return h.record(string(value)) }
+func (h *recordingHandler) OnBool(value bool) error     { return h.record(strconv.FormatBool(value)) }
+func (h *recordingHandler) OnNull() error               { return h.record("null") }
+
+func TestParse(t *testing.T) {
+	var h recordingHandler
+	err := Parse(strings.NewReader(`{"a": [1, "b", true, null], "c": {}}`), &h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"{", "key a", "[", "1", `"b"`, "true", "null", "end", "key c", "{", "end", "end"}
+	if !reflect.DeepEqual(h.events, want) {
+		t.Errorf("got  %q\nwant %q", h.events, want)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	h := recordingHandler{stopAt: "key c"}
+	if err := Parse(strings.NewReader(`{"a": 1, "c": 2}`), &h); err == nil || err.Error() != "stop" {
+		t.Errorf("got error %v, want stop", err)
+	}
+	h = recordingHandler{}
+	if err := Parse(strings.NewReader(`[1, x]`), &h); err == nil {
+		t.Error("expected syntax error")
+	}
+}
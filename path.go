@@ -0,0 +1,87 @@
+// Copyright 2014 Gary Burd. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package json
+
+import (
+	"strconv"
+	"strings"
+)
+
+// PathElement is an element in the path from the root of a document to a
+// value.
+type PathElement struct {
+	Name  string // object member name
+	Index int    // array index or -1 for an object member
+}
+
+// TrackPath sets whether the scanner maintains the path to the current
+// element for the Path and PathString methods. This method must be called
+// before scanning the input.
+func (s *Scanner) TrackPath(track bool) {
+	s.trackPath = track
+}
+
+func (s *Scanner) updatePath() {
+	n := len(s.states) - 1
+	switch s.kind {
+	case End:
+		s.path = s.path[:n]
+		s.pathArray = s.pathArray[:n]
+		s.pathLen = n
+		return
+	case Array, Object:
+		n--
+	}
+	if n > 0 {
+		e := &s.path[n-1]
+		if s.pathArray[n-1] {
+			e.Index++
+		} else if name := s.cookedData(nameData); e.Name != string(name) {
+			e.Name = string(name)
+		}
+	}
+	s.pathLen = n
+	switch s.kind {
+	case Array:
+		s.path = append(s.path, PathElement{Index: -1})
+		s.pathArray = append(s.pathArray, true)
+	case Object:
+		s.path = append(s.path, PathElement{Index: -1})
+		s.pathArray = append(s.pathArray, false)
+	}
+}
+
+// Path returns the path from the root of the document to the current
+// element. At an End element, Path returns the path to the object or array
+// that ended. Path returns nil if the scanner is not tracking the path. The
+// underlying array may be overwritten by a subsequent call to Scan.
+func (s *Scanner) Path() []PathElement {
+	if !s.trackPath {
+		return nil
+	}
+	return s.path[:s.pathLen]
+}
+
+// PathString returns the path to the current element as an RFC 6901 JSON
+// Pointer.
+func (s *Scanner) PathString() string {
+	return pathString(s.Path())
+}
+
+// pathString returns path as an RFC 6901 JSON Pointer.
+func pathString(path []PathElement) string {
+	var buf []byte
+	for _, e := range path {
+		buf = append(buf, '/')
+		if e.Index >= 0 {
+			buf = strconv.AppendInt(buf, int64(e.Index), 10)
+		} else if strings.ContainsAny(e.Name, "~/") {
+			buf = append(buf, pointerEscaper.Replace(e.Name)...)
+		} else {
+			buf = append(buf, e.Name...)
+		}
+	}
+	return string(buf)
+}
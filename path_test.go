@@ -0,0 +1,56 @@
+// Copyright 2014 Gary Burd. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package json
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPath(t *testing.T) {
+	const doc = `{"orders": [{"amount": 1}, {"amount": 2, "a/b~": [[], 3]}], "x": {}}`
+	want := []string{
+		"",
+		"/orders",
+		"/orders/0",
+		"/orders/0/amount",
+		"/orders/0",
+		"/orders/1",
+		"/orders/1/amount",
+		"/orders/1/a~1b~0",
+		"/orders/1/a~1b~0/0",
+		"/orders/1/a~1b~0/0",
+		"/orders/1/a~1b~0/1",
+		"/orders/1/a~1b~0",
+		"/orders/1",
+		"/orders",
+		"/x",
+		"/x",
+		"",
+	}
+	s := NewScannerBytes([]byte(doc))
+	s.TrackPath(true)
+	var got []string
+	for s.Scan() {
+		got = append(got, s.PathString())
+	}
+	if err := s.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got  %q\nwant %q", got, want)
+	}
+}
+
+func TestPathElements(t *testing.T) {
+	s := NewScannerBytes([]byte(`[{"a": [0, 1, true]}]`))
+	s.TrackPath(true)
+	for s.Scan() && s.Kind() != Bool {
+	}
+	want := []PathElement{{Index: 0}, {Name: "a", Index: -1}, {Index: 2}}
+	if got := s.Path(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,83 @@
+// Copyright 2014 Gary Burd. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package json
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// Seek advances the scanner to the element addressed by the RFC 6901 JSON
+// Pointer, skipping over all other elements. The pointer is evaluated
+// relative to the current value. Call Scan before Seek to evaluate the
+// pointer relative to the root of the document.
+//
+// Seek returns false if the addressed element does not exist or an error is
+// encountered. The Err method returns the error if any. When Seek returns
+// false without an error, the scanner is positioned at the end of the value
+// that does not contain the addressed element.
+func (s *Scanner) Seek(pointer string) bool {
+	if pointer == "" {
+		return s.kind >= 0
+	}
+	if pointer[0] != '/' {
+		s.err = errors.New("invalid JSON pointer " + strconv.Quote(pointer))
+		return false
+	}
+	for _, tok := range strings.Split(pointer[1:], "/") {
+		if strings.IndexByte(tok, '~') >= 0 {
+			tok = pointerUnescaper.Replace(tok)
+		}
+		if !s.seekToken(tok) {
+			return false
+		}
+	}
+	return true
+}
+
+var (
+	pointerEscaper   = strings.NewReplacer("~", "~0", "/", "~1")
+	pointerUnescaper = strings.NewReplacer("~1", "/", "~0", "~")
+)
+
+func (s *Scanner) seekToken(tok string) bool {
+	n := s.NestingLevel()
+	switch s.kind {
+	case Object:
+		for s.ScanAtLevel(n) {
+			if string(s.cookedData(nameData)) == tok {
+				return true
+			}
+		}
+	case Array:
+		i, ok := parseArrayIndex(tok)
+		if !ok {
+			s.Skip()
+			return false
+		}
+		for s.ScanAtLevel(n) {
+			if i == 0 {
+				return true
+			}
+			i--
+		}
+	}
+	return false
+}
+
+// parseArrayIndex parses an array index as specified in RFC 6901.
+func parseArrayIndex(tok string) (int, bool) {
+	if tok == "" || (tok[0] == '0' && len(tok) > 1) {
+		return 0, false
+	}
+	for i := 0; i < len(tok); i++ {
+		if !isDecimalDigit(tok[i]) {
+			return 0, false
+		}
+	}
+	i, err := strconv.Atoi(tok)
+	return i, err == nil
+}
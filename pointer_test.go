@@ -0,0 +1,84 @@
+// Copyright 2014 Gary Burd. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package json
+
+import (
+	"testing"
+)
+
+const seekDoc = `{
+	"items": [
+		{"name": "a"},
+		{"name": "b", "tags": ["x", "y"]},
+		{"name": "c"}
+	],
+	"a/b": 1,
+	"m~n": 2,
+	"": 3,
+	"last": true
+}`
+
+var seekTests = []struct {
+	pointer string
+	ok      bool
+	kind    Kind
+	value   string
+}{
+	{"", true, Object, ""},
+	{"/items", true, Array, ""},
+	{"/items/0", true, Object, ""},
+	{"/items/2/name", true, String, "c"},
+	{"/items/1/tags/1", true, String, "y"},
+	{"/a~1b", true, Number, "1"},
+	{"/m~0n", true, Number, "2"},
+	{"/", true, Number, "3"},
+	{"/last", true, Bool, "true"},
+	{"/items/3", false, 0, ""},
+	{"/items/01", false, 0, ""},
+	{"/items/-", false, 0, ""},
+	{"/items/0/name/x", false, 0, ""},
+	{"/missing", false, 0, ""},
+}
+
+func TestSeek(t *testing.T) {
+	for _, tt := range seekTests {
+		s := NewScannerBytes([]byte(seekDoc))
+		s.Scan()
+		ok := s.Seek(tt.pointer)
+		if err := s.Err(); err != nil {
+			t.Errorf("%q: unexpected error %v", tt.pointer, err)
+			continue
+		}
+		if ok != tt.ok {
+			t.Errorf("%q: got %v, want %v", tt.pointer, ok, tt.ok)
+			continue
+		}
+		if ok && (s.Kind() != tt.kind || string(s.Value()) != tt.value) {
+			t.Errorf("%q: got %v %q, want %v %q", tt.pointer, s.Kind(), s.Value(), tt.kind, tt.value)
+		}
+	}
+}
+
+func TestSeekContinue(t *testing.T) {
+	s := NewScannerBytes([]byte(seekDoc))
+	s.Scan()
+	if !s.Seek("/items/1") {
+		t.Fatal("Seek(/items/1) = false")
+	}
+	if !s.Seek("/tags/0") || string(s.Value()) != "x" {
+		t.Fatalf("Seek(/tags/0) = %q, want x", s.Value())
+	}
+}
+
+func TestSeekInvalid(t *testing.T) {
+	s := NewScannerBytes([]byte(seekDoc))
+	s.Scan()
+	if s.Seek("items") {
+		t.Fatal("Seek(items) = true")
+	}
+	if s.Err() == nil {
+		t.Fatal("expected error")
+	}
+}
@@ -0,0 +1,108 @@
+// Copyright 2014 Gary Burd. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package json
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// Project copies the JSON values in src to dst, keeping only the elements
+// selected by the include paths. The paths are RFC 6901 JSON Pointers
+// relative to the root of each value. The token "*" in a path matches every
+// member of an object and every element of an array.
+//
+// An element is copied with all of its contents if an include path addresses
+// the element. Objects and arrays that contain an addressed element are
+// copied with only the selected contents. Other elements are dropped.
+// Project enables path tracking on src and must be called before src is
+// scanned.
+func Project(dst *Writer, src *Scanner, include []string) error {
+	paths, err := parsePaths(include)
+	if err != nil {
+		return err
+	}
+	return Transform(dst, src, func(_ string, s *Scanner, w *Writer) (bool, error) {
+		path := s.Path()
+		ancestor := false
+		for _, p := range paths {
+			if !matchPath(p, path) {
+				continue
+			}
+			if len(p) <= len(path) {
+				return false, nil
+			}
+			ancestor = true
+		}
+		if ancestor && (s.Kind() == Object || s.Kind() == Array) {
+			return false, nil
+		}
+		return true, s.Skip()
+	})
+}
+
+// ProjectExclude copies the JSON values in src to dst, dropping the elements
+// addressed by the exclude paths and their contents. The paths are specified
+// as for Project. ProjectExclude enables path tracking on src and must be
+// called before src is scanned.
+func ProjectExclude(dst *Writer, src *Scanner, exclude []string) error {
+	paths, err := parsePaths(exclude)
+	if err != nil {
+		return err
+	}
+	return Transform(dst, src, func(_ string, s *Scanner, w *Writer) (bool, error) {
+		path := s.Path()
+		for _, p := range paths {
+			if len(p) <= len(path) && matchPath(p, path) {
+				return true, s.Skip()
+			}
+		}
+		return false, nil
+	})
+}
+
+// parsePaths splits the JSON Pointers in pointers into unescaped tokens.
+func parsePaths(pointers []string) ([][]string, error) {
+	paths := make([][]string, len(pointers))
+	for i, pointer := range pointers {
+		if pointer == "" {
+			paths[i] = []string{}
+			continue
+		}
+		if pointer[0] != '/' {
+			return nil, errors.New("invalid JSON pointer " + strconv.Quote(pointer))
+		}
+		toks := strings.Split(pointer[1:], "/")
+		for j, tok := range toks {
+			if strings.IndexByte(tok, '~') >= 0 {
+				toks[j] = pointerUnescaper.Replace(tok)
+			}
+		}
+		paths[i] = toks
+	}
+	return paths, nil
+}
+
+// matchPath returns true if the tokens in p match the elements of path up to
+// the length of the shorter of the two.
+func matchPath(p []string, path []PathElement) bool {
+	for i, tok := range p {
+		if i == len(path) {
+			break
+		}
+		e := path[i]
+		switch {
+		case tok == "*":
+		case e.Index >= 0:
+			if n, ok := parseArrayIndex(tok); !ok || n != e.Index {
+				return false
+			}
+		case tok != e.Name:
+			return false
+		}
+	}
+	return true
+}
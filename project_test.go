@@ -0,0 +1,65 @@
+// Copyright 2014 Gary Burd. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package json
+
+import (
+	"bytes"
+	"testing"
+)
+
+const projectInput = `{"id":1,"name":"x","a/b":2,"internal":{"k":"v"},"items":[{"id":2,"secret":"s"},{"id":3,"tags":["t"]}],"meta":{"page":1,"debug":true}}`
+
+var projectTests = []struct {
+	paths []string
+	want  string
+}{
+	{nil, ``},
+	{[]string{""}, projectInput},
+	{[]string{"/id", "/a~1b", "/missing"}, `{"id":1,"a/b":2}`},
+	{[]string{"/meta/page", "/items/1"}, `{"items":[{"id":3,"tags":["t"]}],"meta":{"page":1}}`},
+	{[]string{"/items/*/id"}, `{"items":[{"id":2},{"id":3}]}`},
+	{[]string{"/*/id"}, `{"internal":{},"items":[],"meta":{}}`},
+}
+
+func TestProject(t *testing.T) {
+	for _, tt := range projectTests {
+		var buf bytes.Buffer
+		if err := Project(NewWriter(&buf), NewScannerBytes([]byte(projectInput)), tt.paths); err != nil {
+			t.Errorf("%q: Project returned %v", tt.paths, err)
+			continue
+		}
+		if got := buf.String(); got != tt.want {
+			t.Errorf("%q:\n got %s\nwant %s", tt.paths, got, tt.want)
+		}
+	}
+}
+
+var projectExcludeTests = []struct {
+	paths []string
+	want  string
+}{
+	{nil, projectInput},
+	{[]string{"/internal", "/meta/debug", "/items/*/secret"}, `{"id":1,"name":"x","a/b":2,"items":[{"id":2},{"id":3,"tags":["t"]}],"meta":{"page":1}}`},
+	{[]string{"/items/0", "/a~1b", "/id/x"}, `{"id":1,"name":"x","internal":{"k":"v"},"items":[{"id":3,"tags":["t"]}],"meta":{"page":1,"debug":true}}`},
+}
+
+func TestProjectExclude(t *testing.T) {
+	for _, tt := range projectExcludeTests {
+		var buf bytes.Buffer
+		if err := ProjectExclude(NewWriter(&buf), NewScannerBytes([]byte(projectInput)), tt.paths); err != nil {
+			t.Errorf("%q: ProjectExclude returned %v", tt.paths, err)
+			continue
+		}
+		if got := buf.String(); got != tt.want {
+			t.Errorf("%q:\n got %s\nwant %s", tt.paths, got, tt.want)
+		}
+	}
+}
+
+func TestProjectInvalidPath(t *testing.T) {
+	if err := Project(NewWriter(&bytes.Buffer{}), NewScannerBytes([]byte(`{}`)), []string{"id"}); err == nil {
+		t.Error("Project returned nil error for invalid path")
+	}
+}
@@ -0,0 +1,159 @@
+// Copyright 2014 Gary Burd. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package json
+
+import (
+	"errors"
+	"io"
+)
+
+// ErrNeedInput is returned by Peek and by functions that read a complete
+// value when a push mode scanner does not have all of the input. Write more
+// input and call the function again.
+var ErrNeedInput = errors.New("need input")
+
+var (
+	errNotPush     = errors.New("scanner not created with NewScannerPush")
+	errInputClosed = errors.New("write after CloseInput")
+)
+
+// pushInput is the reader for a push mode scanner.
+type pushInput struct {
+	buf    []byte
+	off    int
+	closed bool
+}
+
+func (p *pushInput) Read(b []byte) (int, error) {
+	if p.off == len(p.buf) {
+		p.buf = p.buf[:0]
+		p.off = 0
+		if p.closed {
+			return 0, io.EOF
+		}
+		return 0, ErrNeedInput
+	}
+	n := copy(b, p.buf[p.off:])
+	p.off += n
+	return n, nil
+}
+
+// NewScannerPush allocates and initializes a new scanner in push mode. The
+// application writes input to the scanner with the Write method and calls
+// CloseInput at the end of the input.
+//
+// When the scanner has consumed all input written so far, Scan returns false
+// and Err returns nil. The application should write more input and call Scan
+// again. Scanning resumes where it stopped, including in the middle of a
+// token.
+//
+//  s := json.NewScannerPush()
+//  s.SetFraming(json.FrameLines)
+//  for frame := range frames {
+//      s.Write(frame)
+//      for s.Scan() {
+//          // process element
+//      }
+//      if err := s.Err(); err != nil {
+//          // handle error
+//      }
+//  }
+//  s.CloseInput()
+//  for s.Scan() {
+//      // process element
+//  }
+//
+// Functions that read a complete value from the scanner, such as
+// DecodeValue, Unmarshal, Skip and SkipRaw, return ErrNeedInput if the end
+// of the value is not written. The scanner is returned to the current
+// element so that the function can be called again after more input is
+// written. StreamStrings is not supported in push mode.
+func NewScannerPush() *Scanner {
+	return NewScanner(&pushInput{})
+}
+
+// Write appends p to the input of a push mode scanner. Write does not
+// retain p.
+func (s *Scanner) Write(p []byte) (int, error) {
+	in, ok := s.rd.(*pushInput)
+	if !ok {
+		return 0, errNotPush
+	}
+	if in.closed {
+		return 0, errInputClosed
+	}
+	in.buf = append(in.buf, p...)
+	return len(p), nil
+}
+
+// CloseInput marks the end of the input to a push mode scanner. After
+// CloseInput, the scanner reports io.ErrUnexpectedEOF for an incomplete
+// value as it does for other inputs.
+func (s *Scanner) CloseInput() error {
+	in, ok := s.rd.(*pushInput)
+	if !ok {
+		return errNotPush
+	}
+	in.closed = true
+	return nil
+}
+
+// valueMark is the state saved by startValue.
+type valueMark struct {
+	push    bool
+	m       Mark
+	marked  bool
+	markOff int64
+	markSeq int
+}
+
+// startValue prepares to read the complete current value in push mode. The
+// caller passes the result to endValue after reading the value.
+func (s *Scanner) startValue() valueMark {
+	if _, ok := s.rd.(*pushInput); !ok {
+		return valueMark{}
+	}
+	v := valueMark{push: true, marked: s.marked, markOff: s.markOff, markSeq: s.markSeq}
+	v.m = s.Mark()
+	if v.marked && v.markOff < s.markOff {
+		s.markOff = v.markOff
+	}
+	return v
+}
+
+// endValue returns err or ErrNeedInput if the value started with startValue
+// is not completely written. On ErrNeedInput, the scanner is returned to the
+// element where the value starts.
+func (s *Scanner) endValue(v valueMark, err error) error {
+	if !v.push {
+		return err
+	}
+	needInput := err == ErrNeedInput || s.err == nil && s.pending != nil
+	if needInput {
+		s.rewind(v.m)
+	}
+	s.marked, s.markOff, s.markSeq = v.marked, v.markOff, v.markSeq
+	if needInput {
+		return ErrNeedInput
+	}
+	return err
+}
+
+// stateSkipLine discards input through the next newline. Resync uses the
+// state to finish skipping a record in push mode.
+func (s *Scanner) stateSkipLine(b byte) stateFunc {
+	if b == '\n' {
+		return s.states[len(s.states)-1]
+	}
+	return (*Scanner).stateSkipLine
+}
+
+// stateSkipSeq discards input up to the next record separator.
+func (s *Scanner) stateSkipSeq(b byte) stateFunc {
+	if b == recordSeparator {
+		return s.states[len(s.states)-1](s, b)
+	}
+	return (*Scanner).stateSkipSeq
+}
@@ -0,0 +1,160 @@
+// Copyright 2014 Gary Burd. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package json
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// pushScans writes input to a push mode scanner in chunks of n bytes and
+// returns the elements scanned.
+func pushScans(s *Scanner, input string, n int) []scan {
+	var scans []scan
+	next := func() bool {
+		if !s.Scan() {
+			if err := s.Err(); err != nil {
+				scans = append(scans, scan{k: -1, e: err.Error()})
+				return false
+			}
+			return false
+		}
+		scans = append(scans, scan{k: s.Kind(), n: string(s.Name()), v: string(s.Value())})
+		return true
+	}
+	for i := 0; i < len(input); i += n {
+		j := i + n
+		if j > len(input) {
+			j = len(input)
+		}
+		s.Write([]byte(input[i:j]))
+		for next() {
+		}
+		if s.Err() != nil {
+			return scans
+		}
+	}
+	s.CloseInput()
+	for next() {
+	}
+	if s.Err() == nil {
+		scans = append(scans, eof)
+	}
+	return scans
+}
+
+func TestScannerPush(t *testing.T) {
+	for _, n := range []int{1, 3, 1024} {
+		for _, tt := range scannerTests {
+			s := NewScannerPush()
+			s.AllowMultiple()
+			got := pushScans(s, tt.s, n)
+			if !reflect.DeepEqual(got, tt.scans) {
+				t.Errorf("%q, n=%d: got %v, want %v", tt.s, n, got, tt.scans)
+			}
+		}
+	}
+}
+
+func TestScannerPushResync(t *testing.T) {
+	const doc = "{\"a\": 1}\n{\"a\": x, \"b\": [1, 2]}\n{\"a\": 3}\n"
+	s := NewScannerPush()
+	s.ExpectLines()
+	var got []string
+	for i := 0; i < len(doc); i++ {
+		s.Write([]byte{doc[i]})
+		for {
+			if s.Scan() {
+				if s.Kind() == Number {
+					got = append(got, string(s.Value()))
+				}
+				continue
+			}
+			if s.Err() == nil {
+				break
+			}
+			if !s.Resync() {
+				t.Fatalf("Resync() = false, error %v", s.Err())
+			}
+		}
+	}
+	s.CloseInput()
+	for s.Scan() {
+	}
+	if err := s.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"1", "3"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestScannerPushErrors(t *testing.T) {
+	s := NewScannerPush()
+	s.CloseInput()
+	if _, err := s.Write([]byte("1")); err == nil {
+		t.Error("Write after CloseInput did not return error")
+	}
+	s = NewScannerBytes([]byte("1"))
+	if _, err := s.Write([]byte("1")); err == nil {
+		t.Error("Write to bytes scanner did not return error")
+	}
+}
+
+var pushValueTests = []struct {
+	name string
+	fn   func(s *Scanner) (string, error)
+	want string
+}{
+	{"Skip", func(s *Scanner) (string, error) {
+		err := s.Skip()
+		return string(s.RawValue()), err
+	}, `{"b":[1,2]}`},
+	{"SkipRaw", func(s *Scanner) (string, error) {
+		p, err := s.SkipRaw()
+		return string(p), err
+	}, `{"b":[1,2]}`},
+	{"DecodeValue", func(s *Scanner) (string, error) {
+		v, err := DecodeValue(s)
+		return fmt.Sprint(v), err
+	}, `map[b:[1 2]]`},
+	{"Unmarshal", func(s *Scanner) (string, error) {
+		var v struct {
+			B []int `json:"b"`
+		}
+		err := Unmarshal(s, &v)
+		return fmt.Sprint(v.B), err
+	}, `[1 2]`},
+}
+
+func TestPushValue(t *testing.T) {
+	for _, tt := range pushValueTests {
+		s := NewScannerPush()
+		s.RecordRawValues(true)
+		s.Write([]byte(`[{"b":[1,2`))
+		s.Scan()
+		s.Scan()
+		if _, err := tt.fn(s); err != ErrNeedInput {
+			t.Errorf("%s: returned %v, want ErrNeedInput", tt.name, err)
+			continue
+		}
+		if s.Kind() != Object {
+			t.Errorf("%s: kind after ErrNeedInput = %v, want object", tt.name, s.Kind())
+		}
+		s.Write([]byte(`]}]`))
+		s.CloseInput()
+		got, err := tt.fn(s)
+		if err != nil || got != tt.want {
+			t.Errorf("%s: got %s, %v, want %s", tt.name, got, err, tt.want)
+		}
+		if !s.Scan() || s.Kind() != End {
+			t.Errorf("%s: got %v, %v, want end of array", tt.name, s.Kind(), s.Err())
+		}
+		if s.Scan() || s.Err() != nil {
+			t.Errorf("%s: got %v, %v, want end of input", tt.name, s.Kind(), s.Err())
+		}
+	}
+}
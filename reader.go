@@ -0,0 +1,89 @@
+// Copyright 2014 Gary Burd. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package json
+
+import (
+	"fmt"
+)
+
+// ObjectReader reads the members of an object and dispatches the member
+// values to functions registered by name.
+//
+//  r := json.NewObjectReader(s)
+//  r.Field("id", func(s *json.Scanner) (err error) { id, err = s.Int64(); return })
+//  r.Field("name", func(s *json.Scanner) error { name = string(s.Value()); return nil })
+//  err := r.Read()
+type ObjectReader struct {
+	s      *Scanner
+	fields map[string]func(*Scanner) error
+}
+
+// NewObjectReader returns a reader for the object at the scanner's current
+// element.
+func NewObjectReader(s *Scanner) *ObjectReader {
+	return &ObjectReader{s: s, fields: make(map[string]func(*Scanner) error)}
+}
+
+// Field registers fn to handle the value of the member with the given name.
+// The function is called with the scanner positioned on the value. If the
+// value is an object or array, then the function does not need to consume
+// the value; the reader skips the rest of the value when the function returns.
+func (r *ObjectReader) Field(name string, fn func(s *Scanner) error) {
+	r.fields[name] = fn
+}
+
+// Read reads the object through its End element, calling the registered
+// functions for the members with registered names and skipping all other
+// members. Read stops at the first error returned by a function.
+func (r *ObjectReader) Read() error {
+	s := r.s
+	if s.Kind() != Object {
+		return fmt.Errorf("expected object, found %v", s.Kind())
+	}
+	n := s.NestingLevel()
+	for s.ScanAtLevel(n) {
+		if fn := r.fields[string(s.Name())]; fn != nil {
+			if err := fn(s); err != nil {
+				return err
+			}
+		}
+	}
+	return s.Err()
+}
+
+// ArrayReader reads the elements of an array.
+//
+//  r := json.NewArrayReader(s)
+//  err := r.Read(func(i int, s *json.Scanner) error {
+//      // handle element i
+//  })
+type ArrayReader struct {
+	s *Scanner
+}
+
+// NewArrayReader returns a reader for the array at the scanner's current
+// element.
+func NewArrayReader(s *Scanner) *ArrayReader {
+	return &ArrayReader{s: s}
+}
+
+// Read reads the array through its End element, calling fn with the index of
+// each element and the scanner positioned on the element. If the element is
+// an object or array, then the function does not need to consume the
+// element; the reader skips the rest of the element when the function
+// returns. Read stops at the first error returned by fn.
+func (r *ArrayReader) Read(fn func(i int, s *Scanner) error) error {
+	s := r.s
+	if s.Kind() != Array {
+		return fmt.Errorf("expected array, found %v", s.Kind())
+	}
+	n := s.NestingLevel()
+	for i := 0; s.ScanAtLevel(n); i++ {
+		if err := fn(i, s); err != nil {
+			return err
+		}
+	}
+	return s.Err()
+}
@@ -0,0 +1,116 @@
+// Copyright 2014 Gary Burd. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package json
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestObjectReader(t *testing.T) {
+	s := NewScannerBytes([]byte(`{"id": 7, "tags": ["a", "b"], "skip": {"x": [1]}, "meta": {"n": "x"}, "name": "gopher"}`))
+	s.Scan()
+	var (
+		id   int64
+		name string
+		meta string
+	)
+	r := NewObjectReader(s)
+	r.Field("id", func(s *Scanner) (err error) { id, err = s.Int64(); return })
+	r.Field("name", func(s *Scanner) error { name = string(s.Value()); return nil })
+	r.Field("meta", func(s *Scanner) error {
+		// Consume part of the object and let the reader skip the rest.
+		s.Scan()
+		meta = string(s.Value())
+		return nil
+	})
+	if err := r.Read(); err != nil {
+		t.Fatal(err)
+	}
+	if id != 7 || name != "gopher" || meta != "x" {
+		t.Errorf("got id=%d name=%q meta=%q", id, name, meta)
+	}
+	if s.Kind() != End || s.NestingLevel() != 1 {
+		t.Errorf("reader did not stop at End of object")
+	}
+}
+
+func TestObjectReaderErrors(t *testing.T) {
+	s := NewScannerBytes([]byte(`[]`))
+	s.Scan()
+	if err := NewObjectReader(s).Read(); err == nil {
+		t.Error("expected error for array")
+	}
+
+	errTest := errors.New("test")
+	s = NewScannerBytes([]byte(`{"a": 1}`))
+	s.Scan()
+	r := NewObjectReader(s)
+	r.Field("a", func(s *Scanner) error { return errTest })
+	if err := r.Read(); err != errTest {
+		t.Errorf("got error %v, want %v", err, errTest)
+	}
+
+	s = NewScannerBytes([]byte(`{"a": 1,`))
+	s.Scan()
+	if err := NewObjectReader(s).Read(); err == nil {
+		t.Error("expected syntax error")
+	}
+}
+
+func TestArrayReader(t *testing.T) {
+	s := NewScannerBytes([]byte(`[10, [1, [2]], {"a": 1}, 13]`))
+	s.Scan()
+	var got []int
+	var kinds []Kind
+	err := NewArrayReader(s).Read(func(i int, s *Scanner) error {
+		got = append(got, i)
+		kinds = append(kinds, s.Kind())
+		if s.Kind() == Array {
+			s.Scan()
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []int{0, 1, 2, 3}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got indices %v, want %v", got, want)
+	}
+	if want := []Kind{Number, Array, Object, Number}; !reflect.DeepEqual(kinds, want) {
+		t.Errorf("got kinds %v, want %v", kinds, want)
+	}
+	if s.Kind() != End {
+		t.Errorf("reader did not stop at End of array")
+	}
+}
+
+func TestArrayReaderErrors(t *testing.T) {
+	s := NewScannerBytes([]byte(`{}`))
+	s.Scan()
+	if err := NewArrayReader(s).Read(func(int, *Scanner) error { return nil }); err == nil {
+		t.Error("expected error for object")
+	}
+
+	errTest := errors.New("test")
+	s = NewScannerBytes([]byte(`[1, 2]`))
+	s.Scan()
+	err := NewArrayReader(s).Read(func(i int, s *Scanner) error {
+		if i == 1 {
+			return errTest
+		}
+		return nil
+	})
+	if err != errTest {
+		t.Errorf("got error %v, want %v", err, errTest)
+	}
+
+	s = NewScannerBytes([]byte(`[1, }`))
+	s.Scan()
+	if err := NewArrayReader(s).Read(func(int, *Scanner) error { return nil }); err == nil {
+		t.Error("expected syntax error")
+	}
+}
@@ -5,8 +5,13 @@
 package json
 
 import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
 	"io"
 	"strconv"
+	"strings"
 	"unicode"
 	"unicode/utf16"
 	"unicode/utf8"
@@ -44,6 +49,8 @@ func (k Kind) String() string {
 		return "null"
 	case Bool:
 		return "bool"
+	case String:
+		return "string"
 	case Number:
 		return "number"
 	case Array:
@@ -70,15 +77,81 @@ func (k Kind) String() string {
 //
 // When scanning strings, invalid UTF-8 or invalid UTF-16 surrogate pairs are
 // not treated as an error. Instead, they are replaced by the Unicode
-// replacement character U+FFFD.
+// replacement character U+FFFD. See StrictUTF8 to treat them as errors.
 type Scanner struct {
 	cook   bool        // if true, current name or value contains non-ASCII byte.
 	pos    int         // write position in buf.
 	buf    []byte      // input buffer
+	shared bool        // if true, buf is owned by the caller and must not be modified.
+	cbuf   [2][]byte   // cooked name and value when buf must not be modified
+	base   int64       // input offset of buf[0], valid for buf[pos:]
+	line   int         // number of newlines before buf[lpos]
+	lpos   int         // position in buf up to which newlines are counted
+	lstart int64       // input offset of start of current line
 	states []stateFunc // stack of state functions
 	isName bool        // if true, then the current string is an boject member name.
 	err    error       // permanent error
 	eofOK  bool        // if true, then EOF is expected in the input.
+	lines  bool        // if true, values are newline delimited.
+
+	multiple bool // if true, multiple top-level values are allowed.
+	seq      bool // if true, values are RFC 7464 JSON text sequences.
+
+	pending stateFunc // state to resume when more input is written in push mode
+
+	tee    io.Writer // if not nil, consumed input is copied to tee.
+	teePos int       // position in buf up to which input is copied to tee
+
+	marked  bool  // if true, input is retained from markOff.
+	markOff int64 // input offset of the start of the mark
+	markSeq int   // sequence number of the current mark
+
+	stats    *ScannerStats // statistics if collection is enabled
+	hook     StatsHook     // receives statistics at the end of the input
+	hookDone bool          // if true, the statistics were sent to hook
+
+	comments   bool      // if true, comments are allowed.
+	resume     stateFunc // state to resume after a comment
+	commentEOF bool      // value of eofOK before a block comment
+
+	trailingCommas bool   // if true, trailing commas are allowed.
+	relaxedSpace   bool   // if true, NBSP, vertical tab and form feed are whitespace.
+	singleQuotes   bool   // if true, single-quoted strings are allowed.
+	nonFinite      bool   // if true, NaN, Infinity and -Infinity are allowed.
+	relaxedNumbers bool   // if true, hex, leading plus and leading dot numbers are allowed.
+	quote          byte   // quote character of the current string
+	literal        string // remaining bytes of the current NaN or Infinity token
+	rawStrings     bool   // if true, Name and Value do not unescape strings.
+	strictUTF8     bool   // if true, invalid UTF-8 and surrogates are errors.
+
+	streamStrings bool // if true, string values are streamed.
+	streaming     bool // if true, the current string value is not read.
+	streamed      bool // if true, the current string value is in cbuf.
+
+	trackPath bool          // if true, the path to the current element is maintained.
+	path      []PathElement // path to current element and children of open containers
+	pathArray []bool        // pathArray[i] is true if path[i] is an array index
+	pathLen   int           // length of the path to the current element
+
+	maxDepth int   // maximum nesting depth, see depthLimit
+	maxBytes int64 // maximum input size, no limit if zero
+	maxBuf   int   // maximum buffer size, no limit if zero
+
+	maxStringLen int          // maximum string length, no limit if zero
+	maxMembers   int          // maximum members in an object, no limit if zero
+	maxElements  int          // maximum elements in an array, no limit if zero
+	counts       []countFrame // open containers if a count limit is set
+
+	intern map[string]string // interned member names if interning is enabled
+
+	dupKeys bool              // if true, duplicate object keys are rejected.
+	keys    []map[string]bool // stack of member names seen in objects
+	nkeys   int               // number of objects in keys
+
+	raw       bool  // if true, raw values are recorded.
+	rawStarts []int // positions in buf of open objects and arrays
+	rawPos    int   // position in buf of the object or array ended by End
+	rawEnd    int
 
 	kind Kind // kind of the current element
 	data [2]struct {
@@ -96,34 +169,613 @@ const (
 
 type stateFunc func(*Scanner, byte) stateFunc
 
+const defaultBufSize = 1024
+
 // NewScanner allocates and initializes a new scanner.
+//
+// The scanner copies the remaining contents of a *bytes.Reader or
+// *strings.Reader to its buffer with a single read when the scanner is
+// created. Other readers, including *bytes.Buffer, are read incrementally as
+// the input is scanned. The scanner does not retain references to the
+// reader's storage. When reading from a *bufio.Reader, the scanner sizes its
+// buffer so that large reads bypass the bufio.Reader's buffer.
 func NewScanner(rd io.Reader) *Scanner {
+	return NewScannerSize(rd, defaultBufSize)
+}
+
+// NewScannerSize allocates and initializes a new scanner with an input
+// buffer of the specified initial capacity. The buffer grows as needed to
+// hold a token.
+func NewScannerSize(rd io.Reader, size int) *Scanner {
+	if size <= 0 {
+		size = defaultBufSize
+	}
+	s := &Scanner{states: []stateFunc{(*Scanner).stateSingleStart}}
+	s.rd, s.buf, s.err = input(rd, make([]byte, 0, size))
+	return s
+}
+
+// input returns the reader, initial buffer and error for scanning rd. The
+// buffer buf is used if possible. The contents of readers that cannot change
+// after the scanner is created are read to the buffer immediately.
+func input(rd io.Reader, buf []byte) (io.Reader, []byte, error) {
+	var n int
+	switch r := rd.(type) {
+	case *bytes.Reader:
+		n = r.Len()
+	case *strings.Reader:
+		n = r.Len()
+	case *bufio.Reader:
+		// The bufio.Reader reads directly to the caller's slice when the
+		// slice is larger than the reader's buffer.
+		if size := 2 * r.Size(); cap(buf) < size {
+			buf = make([]byte, 0, size)
+		}
+		return rd, buf[:0], nil
+	default:
+		return rd, buf[:0], nil
+	}
+	if cap(buf) < n {
+		buf = make([]byte, n)
+	}
+	buf = buf[:n]
+	_, err := io.ReadFull(rd, buf)
+	if err == nil {
+		err = io.EOF
+	}
+	return nil, buf, err
+}
+
+// NewScannerBytes allocates and initializes a new scanner that reads the JSON
+// document from data. The scanner does not copy data. The slices returned by
+// Name and Value point into data when no unescaping is required. The
+// scanner does not modify data.
+func NewScannerBytes(data []byte) *Scanner {
 	return &Scanner{
-		rd:     rd,
-		buf:    make([]byte, 0, 1024),
+		buf:    data,
+		shared: true,
+		err:    io.EOF,
 		states: []stateFunc{(*Scanner).stateSingleStart},
 	}
 }
 
-// AllowMultple enables scanning multiple JSON values. If this method is not
+// DefaultMaxDepth is the maximum nesting depth of objects and arrays when
+// the limit is not set with SetMaxDepth.
+const DefaultMaxDepth = 10000
+
+// ErrTooDeep matches the *LimitError returned when the nesting depth of the
+// input exceeds the scanner's limit:
+//
+//  if errors.Is(s.Err(), json.ErrTooDeep) {
+//      // handle deeply nested input
+//  }
+var ErrTooDeep = errors.New("nesting depth exceeds limit")
+
+// SetMaxDepth sets the maximum nesting depth of objects and arrays. Scan
+// fails with a *LimitError if the nesting depth exceeds n. A value of zero
+// sets the limit to DefaultMaxDepth. A negative value removes the limit.
+//
+// The limit bounds the scanner's state stack and the recursion in functions
+// that decode values from the scanner, such as Unmarshal.
+func (s *Scanner) SetMaxDepth(n int) {
+	s.maxDepth = n
+}
+
+// depthLimit returns the maximum nesting depth or zero if there is no limit.
+func (s *Scanner) depthLimit() int {
+	switch {
+	case s.maxDepth == 0:
+		return DefaultMaxDepth
+	case s.maxDepth < 0:
+		return 0
+	}
+	return s.maxDepth
+}
+
+// SetMaxBytes sets the maximum size of the input in bytes. Scan fails with a
+// *LimitError if the input exceeds n bytes. A value of zero removes the
+// limit.
+func (s *Scanner) SetMaxBytes(n int64) {
+	s.maxBytes = n
+	s.limitBytes()
+}
+
+// SetMaxStringLen sets the maximum length in bytes of unescaped strings,
+// including object member names. Scan fails with a *LimitError if a string
+// exceeds n bytes. A value of zero removes the limit. The limit is not
+// applied to streamed strings.
+func (s *Scanner) SetMaxStringLen(n int) {
+	s.maxStringLen = n
+}
+
+// SetMaxMembers sets the maximum number of members in an object. Scan fails
+// with a *LimitError if an object has more than n members. A value of zero
+// removes the limit. This method must be called before scanning the input.
+func (s *Scanner) SetMaxMembers(n int) {
+	s.maxMembers = n
+}
+
+// SetMaxElements sets the maximum number of elements in an array. Scan fails
+// with a *LimitError if an array has more than n elements. A value of zero
+// removes the limit. This method must be called before scanning the input.
+func (s *Scanner) SetMaxElements(n int) {
+	s.maxElements = n
+}
+
+// countFrame is an open container when counting members and elements.
+type countFrame struct {
+	n      int
+	object bool
+}
+
+// checkLimits checks the per-value limits for the current element and sets
+// the permanent error if a limit is exceeded.
+func (s *Scanner) checkLimits() bool {
+	if s.maxMembers > 0 || s.maxElements > 0 {
+		if s.kind == End {
+			s.counts = s.counts[:len(s.counts)-1]
+		} else if n := len(s.counts); n > 0 {
+			f := &s.counts[n-1]
+			f.n++
+			switch {
+			case f.object && s.maxMembers > 0 && f.n > s.maxMembers:
+				s.err = &LimitError{"object members", int64(s.maxMembers)}
+				return false
+			case !f.object && s.maxElements > 0 && f.n > s.maxElements:
+				s.err = &LimitError{"array elements", int64(s.maxElements)}
+				return false
+			}
+		}
+		if s.kind == Array || s.kind == Object {
+			s.counts = append(s.counts, countFrame{object: s.kind == Object})
+		}
+	}
+	if s.maxStringLen > 0 {
+		for i := range s.data {
+			d := &s.data[i]
+			// The unescaped string is not longer than the input.
+			if (i == nameData || s.kind == String && !s.streaming) && d.pos >= 0 && d.end-d.pos > s.maxStringLen &&
+				len(s.cookedData(i)) > s.maxStringLen {
+				s.err = &LimitError{"string length", int64(s.maxStringLen)}
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// ErrTokenTooLong is returned by Scan when a token does not fit in the
+// maximum buffer size set with Buffer.
+var ErrTokenTooLong = errors.New("token too long")
+
+// Buffer sets the initial buffer to use when reading and the maximum size of
+// buffer that may be allocated during scanning. The maximum token size is
+// the larger of max and cap(buf). Scan fails with ErrTokenTooLong if a token
+// does not fit in the buffer. When recording raw values, the complete
+// outermost object or array must fit in the buffer. The input following a
+// mark must also fit in the buffer.
+//
+// Buffer must be called before the first call to Scan. The initial buffer is
+// not used when the scanner reads input that is already in memory.
+func (s *Scanner) Buffer(buf []byte, max int) {
+	if s.rd != nil && !s.shared && s.base == 0 && len(s.buf) == 0 {
+		s.buf = buf[:0]
+	}
+	if max < cap(buf) {
+		max = cap(buf)
+	}
+	s.maxBuf = max
+}
+
+// limitBytes truncates buf and sets the permanent error if the input read so
+// far exceeds the maximum input size.
+func (s *Scanner) limitBytes() {
+	if s.maxBytes <= 0 || s.base+int64(len(s.buf)) <= s.maxBytes {
+		return
+	}
+	n := s.maxBytes - s.base
+	if n < int64(s.pos) {
+		n = int64(s.pos)
+	}
+	s.buf = s.buf[:n]
+	if s.err == nil || s.err == io.EOF {
+		s.err = &LimitError{"document size", s.maxBytes}
+	}
+}
+
+// RejectDuplicateKeys sets whether Scan fails with a *DuplicateKeyError when
+// an object contains more than one member with the same name. This method
+// must be called before scanning the input.
+func (s *Scanner) RejectDuplicateKeys(reject bool) {
+	s.dupKeys = reject
+}
+
+func (s *Scanner) pushKeys() {
+	if s.nkeys == len(s.keys) {
+		s.keys = append(s.keys, make(map[string]bool))
+	}
+	s.nkeys++
+}
+
+func (s *Scanner) popKeys() {
+	s.nkeys--
+	m := s.keys[s.nkeys]
+	for k := range m {
+		delete(m, k)
+	}
+}
+
+// checkKey records the current member name and sets the permanent error if
+// the name was seen before in the current object.
+func (s *Scanner) checkKey() bool {
+	m := s.keys[s.nkeys-1]
+	name := s.cookedData(nameData)
+	if m[string(name)] {
+		offset, line, column := s.position(s.pos)
+		s.err = &DuplicateKeyError{Key: string(name), Pos: int(offset), Line: line, Column: column}
+		return false
+	}
+	m[string(name)] = true
+	return true
+}
+
+// RecordRawValues sets whether the scanner records the input bytes of
+// values for the RawValue method. When recording, the scanner retains the
+// input of the outermost open object or array in memory. This method must be
+// called before scanning the input.
+func (s *Scanner) RecordRawValues(record bool) {
+	s.raw = record
+}
+
+// RawValue returns the input bytes of the current value. At an End element,
+// RawValue returns the input of the entire object or array, from the opening
+// delimiter to the closing delimiter. At an Array or Object element,
+// RawValue returns the opening delimiter. Use Skip followed by RawValue to
+// get the input of an entire object or array.
+//
+// RawValue returns nil if the scanner is not recording raw values. The
+// underlying array may point to data that will be overwritten by a
+// subsequent call to Scan.
+func (s *Scanner) RawValue() []byte {
+	if !s.raw {
+		return nil
+	}
+	return s.rawValue()
+}
+
+// SkipRaw skips over the current value as Skip does and returns the input
+// bytes of the value. SkipRaw does not require RecordRawValues. Call SkipRaw
+// before calling Value on a string because Value may unescape the string in
+// place. SkipRaw returns nil for a string streamed with StreamStrings. The
+// underlying array may point to data that will be overwritten by a
+// subsequent call to Scan.
+func (s *Scanner) SkipRaw() ([]byte, error) {
+	switch {
+	case s.streaming:
+		s.skipString()
+		return nil, s.Err()
+	case s.kind == End && !s.raw:
+		return nil, s.Err()
+	case s.kind == String && !s.raw && !s.shared:
+		// The opening quote is not retained when the buffer is filled.
+		data := &s.data[valueData]
+		p := make([]byte, 0, data.end-data.pos+2)
+		p = append(p, s.quote)
+		p = append(p, s.buf[data.pos:data.end]...)
+		return append(p, s.quote), s.Err()
+	case (s.kind == Array || s.kind == Object) && !s.raw:
+		// Record the value's input while skipping. The opening delimiter
+		// is the last byte scanned.
+		s.raw = true
+		s.rawStarts = append(s.rawStarts[:0], s.pos-1)
+		err := s.Skip()
+		s.raw = false
+		s.rawStarts = s.rawStarts[:0]
+		if err != nil {
+			return nil, err
+		}
+	default:
+		if err := s.Skip(); err != nil {
+			return nil, err
+		}
+	}
+	return s.rawValue(), nil
+}
+
+func (s *Scanner) rawValue() []byte {
+	data := &s.data[valueData]
+	switch s.kind {
+	case End:
+		return s.buf[s.rawPos:s.rawEnd]
+	case Array, Object:
+		pos := s.rawStarts[len(s.rawStarts)-1]
+		return s.buf[pos : pos+1]
+	case String:
+		return s.buf[data.pos-1 : data.end+1]
+	case Number, Bool, Null:
+		return s.buf[data.pos:data.end]
+	default:
+		return nil
+	}
+}
+
+// ExpectLines configures the scanner to read newline-delimited JSON (also
+// known as JSON Lines and NDJSON). Each top-level value must be on a single
+// line and must be followed by a newline or the end of the input. Empty lines
+// are ignored. Use the Position method to get the line of the current value.
+func (s *Scanner) ExpectLines() {
+	s.lines = true
+	s.top((*Scanner).stateLines)
+}
+
+// Resync recovers from an error in a stream of top-level values by
+// discarding the input through the next newline or, with FrameSeq framing,
+// to the next record separator. Use Resync with ExpectLines, AllowMultiple
+// or SetFraming to skip a corrupt record in a stream of records. Resync
+// can also be called without an error to abandon the current record.
+//
+// Resync returns false if the scanner cannot recover from the error. Only
+// syntax errors and duplicate key errors are recoverable.
+func (s *Scanner) Resync() bool {
+	skip := true
+	switch err := s.err.(type) {
+	case nil:
+	case *SyntaxError:
+		// Do not skip the next record if the error was at the record
+		// delimiter.
+		if s.seq {
+			skip = err.b != recordSeparator
+		} else {
+			skip = err.b != '\n'
+		}
+		s.err = nil
+	case *DuplicateKeyError:
+		s.err = nil
+	default:
+		if err != io.EOF {
+			return false
+		}
+	}
+	if s.rd == nil {
+		s.err = io.EOF
+	}
+
+	s.kind = -1
+	s.data[nameData].pos = -1
+	s.data[valueData].pos = -1
+	s.states = s.states[:1]
+	for s.nkeys > 0 {
+		s.popKeys()
+	}
+	s.rawStarts = s.rawStarts[:0]
+	s.path = s.path[:0]
+	s.pathArray = s.pathArray[:0]
+	s.pathLen = 0
+	s.counts = s.counts[:0]
+	delim := byte('\n')
+	switch {
+	case s.lines:
+		s.top((*Scanner).stateLines)
+	case s.seq && !skip:
+		// The record separator was consumed with the error.
+		s.top((*Scanner).stateSeqValue)
+	case s.seq:
+		s.top((*Scanner).stateSeq)
+		delim = recordSeparator
+	default:
+		s.top((*Scanner).stateMultiple)
+	}
+	s.eofOK = true
+
+	for skip {
+		if i := bytes.IndexByte(s.buf[s.pos:], delim); i >= 0 {
+			s.pos += i
+			if !s.seq {
+				// Consume the newline. The record separator is
+				// consumed by stateSeq.
+				s.pos++
+			}
+			skip = false
+			break
+		}
+		s.pos = len(s.buf)
+		if s.err != nil {
+			break
+		}
+		s.fill()
+	}
+	s.pending = nil
+	if s.err == ErrNeedInput {
+		// Finish skipping the record when more input is written.
+		s.err = nil
+		if skip {
+			if s.seq {
+				s.pending = (*Scanner).stateSkipSeq
+			} else {
+				s.pending = (*Scanner).stateSkipLine
+			}
+		}
+	}
+	return s.err == nil || s.err == io.EOF
+}
+
+// AllowComments sets whether the scanner accepts // line comments and /* */
+// block comments. Comments are treated as whitespace.
+func (s *Scanner) AllowComments(allow bool) {
+	s.comments = allow
+}
+
+// AllowTrailingCommas sets whether the scanner accepts a comma after the
+// last element of an array or the last member of an object.
+func (s *Scanner) AllowTrailingCommas(allow bool) {
+	s.trailingCommas = allow
+}
+
+// AllowSingleQuotes sets whether the scanner accepts strings and member
+// names enclosed in single quotes. A single-quoted string may contain
+// unescaped double quotes and the escape \'. The scanner returns the
+// contents of single-quoted strings as it does for double-quoted strings.
+// Single-quoted strings are not streamed by StreamStrings.
+func (s *Scanner) AllowSingleQuotes(allow bool) {
+	s.singleQuotes = allow
+}
+
+// AllowNonFiniteNumbers sets whether the scanner accepts the tokens NaN,
+// Infinity and -Infinity as written by Python's json module and JavaScript.
+// The tokens are returned as Number values with the token as the value.
+// Float64 converts the values to the corresponding float64 values.
+func (s *Scanner) AllowNonFiniteNumbers(allow bool) {
+	s.nonFinite = allow
+}
+
+// AllowRelaxedNumbers sets whether the scanner accepts hexadecimal integers
+// (0x1F), numbers with a leading plus sign (+5) and numbers with a leading
+// decimal point (.5 and -.5). Value returns these numbers in JSON form (31, 5,
+// 0.5 and -0.5). With AllowNonFiniteNumbers, the scanner also accepts
+// +Infinity.
+func (s *Scanner) AllowRelaxedNumbers(allow bool) {
+	s.relaxedNumbers = allow
+}
+
+// AllowRelaxedWhitespace sets whether the scanner accepts no-break space
+// (U+00A0), vertical tab and form feed as whitespace between tokens.
+func (s *Scanner) AllowRelaxedWhitespace(allow bool) {
+	s.relaxedSpace = allow
+}
+
+// Reset discards the scanner's state and switches the scanner to read from
+// rd. Options set on the scanner are retained and the scanner's buffers are
+// reused. Reset allows scanners to be pooled with sync.Pool.
+func (s *Scanner) Reset(rd io.Reader) {
+	buf := s.buf[:0]
+	if s.shared {
+		buf = make([]byte, 0, defaultBufSize)
+	}
+	rd, buf, err := input(rd, buf)
+	s.reset(rd, buf, false, err)
+}
+
+// ResetBytes is like Reset, but switches the scanner to read the JSON input
+// in data. See NewScannerBytes for details.
+func (s *Scanner) ResetBytes(data []byte) {
+	s.reset(nil, data, true, io.EOF)
+}
+
+func (s *Scanner) reset(rd io.Reader, buf []byte, shared bool, err error) {
+	for s.nkeys > 0 {
+		s.popKeys()
+	}
+	stats := s.stats
+	*s = Scanner{
+		rd:     rd,
+		buf:    buf,
+		shared: shared,
+		err:    err,
+		cbuf:   s.cbuf,
+		states: append(s.states[:0], (*Scanner).stateSingleStart),
+
+		lines:          s.lines,
+		multiple:       s.multiple,
+		seq:            s.seq,
+		comments:       s.comments,
+		trailingCommas: s.trailingCommas,
+		relaxedSpace:   s.relaxedSpace,
+		singleQuotes:   s.singleQuotes,
+		nonFinite:      s.nonFinite,
+		relaxedNumbers: s.relaxedNumbers,
+		rawStrings:     s.rawStrings,
+		strictUTF8:     s.strictUTF8,
+		streamStrings:  s.streamStrings,
+		trackPath:      s.trackPath,
+		path:           s.path[:0],
+		pathArray:      s.pathArray[:0],
+		maxDepth:       s.maxDepth,
+		maxBytes:       s.maxBytes,
+		maxBuf:         s.maxBuf,
+		maxStringLen:   s.maxStringLen,
+		maxMembers:     s.maxMembers,
+		maxElements:    s.maxElements,
+		counts:         s.counts[:0],
+		intern:         s.intern,
+		dupKeys:        s.dupKeys,
+		keys:           s.keys,
+		raw:            s.raw,
+		rawStarts:      s.rawStarts[:0],
+		markSeq:        s.markSeq,
+		hook:           s.hook,
+	}
+	if stats != nil {
+		s.stats = &ScannerStats{}
+	}
+	switch {
+	case s.lines:
+		s.top((*Scanner).stateLines)
+	case s.seq:
+		s.top((*Scanner).stateSeq)
+	case s.multiple:
+		s.top((*Scanner).stateMultiple)
+	}
+	s.limitBytes()
+}
+
+// AllowMultiple enables scanning multiple JSON values. If this method is not
 // called, then the scanner expects to find exactly one JSON value.
-func (s *Scanner) AllowMultple() {
+func (s *Scanner) AllowMultiple() {
+	s.multiple = true
 	s.top((*Scanner).stateMultiple)
 }
 
+// AllowMultple is the original misspelled name of AllowMultiple.
+//
+// Deprecated: Use AllowMultiple.
+func (s *Scanner) AllowMultple() {
+	s.AllowMultiple()
+}
+
 // Scan advances the Scanner to the next element, which will then be available
 // through the Kind and Value methods. Scan returns false if there are no more
 // elements in the input or an error is encountered. The Err method returns the
 // error if any.
 func (s *Scanner) Scan() bool {
-	s.kind = -1
-	s.data[nameData].pos = -1
-	s.data[valueData].pos = -1
-	state := s.states[len(s.states)-1]
+	if s.streaming && !s.skipString() {
+		return false
+	}
+	s.streamed = false
+	ok := s.scan()
+	if s.tee != nil && !s.flushTee() {
+		ok = false
+	}
+	if s.stats != nil {
+		s.countScan(ok)
+	}
+	if !ok {
+		return false
+	}
+	if (s.maxStringLen > 0 || s.maxMembers > 0 || s.maxElements > 0) && !s.checkLimits() {
+		return false
+	}
+	if s.trackPath {
+		s.updatePath()
+	}
+	return true
+}
+
+func (s *Scanner) scan() bool {
+	state := s.pending
+	if state != nil {
+		s.pending = nil
+	} else {
+		s.kind = -1
+		s.data[nameData].pos = -1
+		s.data[valueData].pos = -1
+		state = s.states[len(s.states)-1]
+	}
 
 	for {
-		for _, b := range s.buf[s.pos:] {
-			state = state(s, b)
+		// States may advance pos past runs of bytes that do not change the
+		// state. See stateString.
+		for s.pos < len(s.buf) {
+			state = state(s, s.buf[s.pos])
 			s.pos += 1
 			if state == nil {
 				return s.kind >= 0
@@ -135,6 +787,11 @@ func (s *Scanner) Scan() bool {
 				continue
 			}
 		}
+		if s.err == ErrNeedInput {
+			s.err = nil
+			s.pending = state
+			return false
+		}
 		if s.err != io.EOF {
 			return false
 		}
@@ -151,53 +808,173 @@ func (s *Scanner) Scan() bool {
 }
 
 func (s *Scanner) fill() {
+	if s.tee != nil && !s.flushTee() {
+		return
+	}
+	// Count the newlines before the input is moved below.
+	s.countLines(s.pos)
+
+	// When recording raw values, retain all input from the start of the
+	// outermost open object or array. Retain all input from the mark.
+	keep := -1
+	if len(s.rawStarts) > 0 {
+		keep = s.rawStarts[0]
+	}
+	if s.marked {
+		if m := int(s.markOff - s.base); keep < 0 || m < keep {
+			keep = m
+		}
+	}
+
 	n := 0
-	for i := range s.data {
-		if pos := s.data[i].pos; pos >= 0 {
-			end := s.data[i].end
-			if end < 0 {
-				end = s.pos
+	if keep >= 0 {
+		n = s.pos - keep
+	} else {
+		for i := range s.data {
+			if pos := s.data[i].pos; pos >= 0 {
+				end := s.data[i].end
+				if end < 0 {
+					end = s.pos
+				}
+				n += end - pos
 			}
-			n += end - pos
 		}
 	}
 
 	buf := s.buf[:cap(s.buf)]
 	const minRead = 512
 	if len(buf)-n < minRead {
-		buf = make([]byte, 2*len(buf)+minRead)
+		size := 2*len(buf) + minRead
+		if s.maxBuf > 0 && size > s.maxBuf {
+			size = s.maxBuf
+		}
+		if size > len(buf) {
+			buf = make([]byte, size)
+			if s.stats != nil {
+				s.stats.BufferGrowths++
+			}
+		} else if n >= len(buf) {
+			s.err = ErrTokenTooLong
+			return
+		}
 	}
 
-	n = 0
-	for i := range s.data {
-		if pos := s.data[i].pos; pos >= 0 {
-			end := s.data[i].end
-			if end < 0 {
-				end = s.pos
-			} else {
-				s.data[i].end = n + end - pos
+	if keep >= 0 {
+		n = copy(buf, s.buf[keep:s.pos])
+		for i := range s.data {
+			if s.data[i].pos >= 0 {
+				s.data[i].pos -= keep
+				if s.data[i].end >= 0 {
+					s.data[i].end -= keep
+				}
 			}
-			s.data[i].pos = n
-			n += copy(buf[n:], s.buf[pos:end])
 		}
+		for i := range s.rawStarts {
+			s.rawStarts[i] -= keep
+		}
+	} else {
+		n = 0
+		for i := range s.data {
+			if pos := s.data[i].pos; pos >= 0 {
+				end := s.data[i].end
+				if end < 0 {
+					end = s.pos
+				} else {
+					s.data[i].end = n + end - pos
+				}
+				s.data[i].pos = n
+				n += copy(buf[n:], s.buf[pos:end])
+			}
+		}
+	}
+
+	if s.tee != nil {
+		s.teePos -= s.pos - n
 	}
+	s.base += int64(s.pos - n)
+	s.lpos = n
 
 	var nn int
 	nn, s.err = s.rd.Read(buf[n:])
 	s.buf = buf[:n+nn]
 	s.pos = n
+	s.limitBytes()
+}
+
+// countLines updates the line count with the newlines in buf[lpos:end].
+// Streamed strings can move the position before lpos; there are no newlines
+// to count in that case.
+func (s *Scanner) countLines(end int) {
+	if end < s.lpos {
+		return
+	}
+	p := s.buf[s.lpos:end]
+	if i := bytes.LastIndexByte(p, '\n'); i >= 0 {
+		s.line += bytes.Count(p, []byte{'\n'})
+		s.lstart = s.base + int64(s.lpos+i+1)
+	}
+	s.lpos = end
+}
+
+// position returns the input offset, line and column of buf[pos].
+func (s *Scanner) position(pos int) (offset int64, line, column int) {
+	s.countLines(pos)
+	offset = s.base + int64(pos)
+	return offset, s.line + 1, int(offset-s.lstart) + 1
+}
+
+// Position returns the line and column of the next byte to be scanned. Lines
+// and columns are numbered from one. Columns are counted in bytes.
+func (s *Scanner) Position() (line, column int) {
+	_, line, column = s.position(s.pos)
+	return line, column
 }
 
 func (s *Scanner) stateSingleStart(b byte) stateFunc {
+	if b == 0xef && s.atStart() {
+		return s.startBOM((*Scanner).stateSingleStart)
+	}
 	s.top((*Scanner).stateSingleEnd)
 	return s.stateValue(b)
 }
 
+// atStart returns true if the current byte is the first byte of the input.
+func (s *Scanner) atStart() bool {
+	return s.base+int64(s.pos) == 0
+}
+
+// startBOM starts scanning a UTF-8 byte order mark. The scanner resumes
+// with the given state after the byte order mark.
+func (s *Scanner) startBOM(resume stateFunc) stateFunc {
+	s.resume = resume
+	return (*Scanner).stateBOM1
+}
+
+func (s *Scanner) stateBOM1(b byte) stateFunc {
+	if b != 0xbb {
+		return s.syntaxError(b, expectBOM)
+	}
+	return (*Scanner).stateBOM2
+}
+
+func (s *Scanner) stateBOM2(b byte) stateFunc {
+	if b != 0xbf {
+		return s.syntaxError(b, expectBOM)
+	}
+	return s.resume
+}
+
 func (s *Scanner) stateSingleEnd(b byte) stateFunc {
 	switch {
-	case isWhiteSpace(b):
+	case isWhiteSpace(b) || s.isRelaxedSpace(b):
 		s.eofOK = true
 		return (*Scanner).stateSingleEnd
+	case b == 0xc2 && s.relaxedSpace:
+		s.eofOK = true
+		return s.startNBSP((*Scanner).stateSingleEnd)
+	case b == '/' && s.comments:
+		s.eofOK = true
+		return s.startComment((*Scanner).stateSingleEnd)
 	default:
 		return s.syntaxError(b, expectWhitespace)
 	}
@@ -205,20 +982,101 @@ func (s *Scanner) stateSingleEnd(b byte) stateFunc {
 
 func (s *Scanner) stateMultiple(b byte) stateFunc {
 	switch {
-	case isWhiteSpace(b):
+	case b == 0xef && s.atStart():
+		return s.startBOM((*Scanner).stateMultiple)
+	case isWhiteSpace(b) || s.isRelaxedSpace(b):
 		s.eofOK = true
 		return (*Scanner).stateMultiple
+	case b == 0xc2 && s.relaxedSpace:
+		s.eofOK = true
+		return s.startNBSP((*Scanner).stateMultiple)
+	case b == '/' && s.comments:
+		s.eofOK = true
+		return s.startComment((*Scanner).stateMultiple)
 	default:
 		s.eofOK = false
 		return s.stateValue(b)
 	}
 }
 
-func (s *Scanner) stateValue(b byte) stateFunc {
+func (s *Scanner) stateLines(b byte) stateFunc {
+	switch {
+	case b == 0xef && s.atStart():
+		return s.startBOM((*Scanner).stateLines)
+	case isWhiteSpace(b) || s.isRelaxedSpace(b):
+		s.eofOK = true
+		return (*Scanner).stateLines
+	case b == 0xc2 && s.relaxedSpace:
+		s.eofOK = true
+		return s.startNBSP((*Scanner).stateLines)
+	case b == '/' && s.comments:
+		s.eofOK = true
+		return s.startComment((*Scanner).stateLines)
+	default:
+		s.eofOK = false
+		s.top((*Scanner).stateLinesEnd)
+		return s.stateValue(b)
+	}
+}
+
+func (s *Scanner) stateLinesEnd(b byte) stateFunc {
+	switch {
+	case b == '\n':
+		s.eofOK = true
+		s.top((*Scanner).stateLines)
+		return (*Scanner).stateLines
+	case s.isSpace(b):
+		s.eofOK = true
+		return (*Scanner).stateLinesEnd
+	case b == '/' && s.comments:
+		s.eofOK = true
+		return s.startComment((*Scanner).stateLinesEnd)
+	case b == 0xc2 && s.relaxedSpace:
+		s.eofOK = true
+		return s.startNBSP((*Scanner).stateLinesEnd)
+	default:
+		return s.syntaxError(b, expectNewline)
+	}
+}
+
+func (s *Scanner) stateSeq(b byte) stateFunc {
 	switch {
+	case b == recordSeparator:
+		s.eofOK = true
+		return (*Scanner).stateSeqValue
 	case isWhiteSpace(b):
+		s.eofOK = true
+		return (*Scanner).stateSeq
+	default:
+		return s.syntaxError(b, expectRecordSeparator)
+	}
+}
+
+func (s *Scanner) stateSeqValue(b byte) stateFunc {
+	switch {
+	case b == recordSeparator || isWhiteSpace(b):
+		return (*Scanner).stateSeqValue
+	default:
+		s.eofOK = false
+		s.top((*Scanner).stateSeq)
+		return s.stateValue(b)
+	}
+}
+
+func (s *Scanner) stateValue(b byte) stateFunc {
+	switch {
+	case s.isSpace(b):
 		return (*Scanner).stateValue
-	case b == '"':
+	case b == '/' && s.comments:
+		return s.startComment((*Scanner).stateValue)
+	case b == 0xc2 && s.relaxedSpace:
+		return s.startNBSP((*Scanner).stateValue)
+	case b == '"' && s.streamStrings:
+		s.streaming = true
+		s.kind = String
+		return nil
+	case b == '"' || b == '\'' && s.singleQuotes:
+		s.quote = b
 		s.isName = false
 		s.cook = false
 		s.data[valueData].pos = s.pos + 1
@@ -231,7 +1089,14 @@ func (s *Scanner) stateValue(b byte) stateFunc {
 	case b == '0':
 		s.data[valueData].pos = s.pos
 		s.data[valueData].end = -1
-		return (*Scanner).stateNumberDotOrExp
+		return (*Scanner).stateNumberZero
+	case (b == '+' || b == '.') && s.relaxedNumbers:
+		s.data[valueData].pos = s.pos
+		s.data[valueData].end = -1
+		if b == '.' {
+			return (*Scanner).stateNumberFrac
+		}
+		return (*Scanner).stateNumberPlus
 	case '1' <= b && b <= '9':
 		s.data[valueData].pos = s.pos
 		s.data[valueData].end = -1
@@ -248,11 +1113,33 @@ func (s *Scanner) stateValue(b byte) stateFunc {
 		s.data[valueData].pos = s.pos
 		s.data[valueData].end = -1
 		return (*Scanner).stateNu
+	case b == 'N' && s.nonFinite:
+		s.data[valueData].pos = s.pos
+		s.data[valueData].end = -1
+		s.literal = "aN"
+		return (*Scanner).stateLiteral
+	case b == 'I' && s.nonFinite:
+		s.data[valueData].pos = s.pos
+		s.data[valueData].end = -1
+		s.literal = "nfinity"
+		return (*Scanner).stateLiteral
+	case (b == '[' || b == '{') && s.depthLimit() > 0 && len(s.states) > s.depthLimit():
+		s.err = &LimitError{"nesting depth", int64(s.depthLimit())}
+		return nil
 	case b == '[':
+		if s.raw {
+			s.rawStarts = append(s.rawStarts, s.pos)
+		}
 		s.push((*Scanner).stateArrayElementOrClose)
 		s.kind = Array
 		return nil
 	case b == '{':
+		if s.dupKeys {
+			s.pushKeys()
+		}
+		if s.raw {
+			s.rawStarts = append(s.rawStarts, s.pos)
+		}
 		s.push((*Scanner).stateObjectKeyOrClose)
 		s.kind = Object
 		return nil
@@ -263,8 +1150,12 @@ func (s *Scanner) stateValue(b byte) stateFunc {
 
 func (s *Scanner) stateArrayElementOrClose(b byte) stateFunc {
 	switch {
-	case isWhiteSpace(b):
+	case s.isSpace(b):
 		return (*Scanner).stateArrayElementOrClose
+	case b == '/' && s.comments:
+		return s.startComment((*Scanner).stateArrayElementOrClose)
+	case b == 0xc2 && s.relaxedSpace:
+		return s.startNBSP((*Scanner).stateArrayElementOrClose)
 	case b == ']':
 		s.pop()
 		s.kind = End
@@ -277,9 +1168,16 @@ func (s *Scanner) stateArrayElementOrClose(b byte) stateFunc {
 
 func (s *Scanner) stateArrayCommaOrClose(b byte) stateFunc {
 	switch {
-	case isWhiteSpace(b):
+	case s.isSpace(b):
 		return (*Scanner).stateArrayCommaOrClose
+	case b == '/' && s.comments:
+		return s.startComment((*Scanner).stateArrayCommaOrClose)
+	case b == 0xc2 && s.relaxedSpace:
+		return s.startNBSP((*Scanner).stateArrayCommaOrClose)
 	case b == ',':
+		if s.trailingCommas {
+			return (*Scanner).stateArrayElementOrClose
+		}
 		return (*Scanner).stateValue
 	case b == ']':
 		s.pop()
@@ -292,14 +1190,22 @@ func (s *Scanner) stateArrayCommaOrClose(b byte) stateFunc {
 
 func (s *Scanner) stateObjectKeyOrClose(b byte) stateFunc {
 	switch {
-	case isWhiteSpace(b):
+	case s.isSpace(b):
 		return (*Scanner).stateObjectKeyOrClose
+	case b == '/' && s.comments:
+		return s.startComment((*Scanner).stateObjectKeyOrClose)
+	case b == 0xc2 && s.relaxedSpace:
+		return s.startNBSP((*Scanner).stateObjectKeyOrClose)
 	case b == '}':
+		if s.dupKeys {
+			s.popKeys()
+		}
 		s.pop()
 		s.kind = End
 		return nil
-	case b == '"':
+	case b == '"' || b == '\'' && s.singleQuotes:
 		s.top((*Scanner).stateObjectCommaOrClose)
+		s.quote = b
 		s.cook = false
 		s.isName = true
 		s.data[nameData].pos = s.pos + 1
@@ -312,8 +1218,12 @@ func (s *Scanner) stateObjectKeyOrClose(b byte) stateFunc {
 
 func (s *Scanner) stateObjectColon(b byte) stateFunc {
 	switch {
-	case isWhiteSpace(b):
+	case s.isSpace(b):
 		return (*Scanner).stateObjectColon
+	case b == '/' && s.comments:
+		return s.startComment((*Scanner).stateObjectColon)
+	case b == 0xc2 && s.relaxedSpace:
+		return s.startNBSP((*Scanner).stateObjectColon)
 	case b == ':':
 		return (*Scanner).stateValue
 	default:
@@ -323,11 +1233,21 @@ func (s *Scanner) stateObjectColon(b byte) stateFunc {
 
 func (s *Scanner) stateObjectCommaOrClose(b byte) stateFunc {
 	switch {
-	case isWhiteSpace(b):
+	case s.isSpace(b):
 		return (*Scanner).stateObjectCommaOrClose
+	case b == '/' && s.comments:
+		return s.startComment((*Scanner).stateObjectCommaOrClose)
+	case b == 0xc2 && s.relaxedSpace:
+		return s.startNBSP((*Scanner).stateObjectCommaOrClose)
 	case b == ',':
+		if s.trailingCommas {
+			return (*Scanner).stateObjectKeyOrClose
+		}
 		return (*Scanner).stateObjectKey
 	case b == '}':
+		if s.dupKeys {
+			s.popKeys()
+		}
 		s.pop()
 		s.kind = End
 		return nil
@@ -338,9 +1258,14 @@ func (s *Scanner) stateObjectCommaOrClose(b byte) stateFunc {
 
 func (s *Scanner) stateObjectKey(b byte) stateFunc {
 	switch {
-	case isWhiteSpace(b):
+	case s.isSpace(b):
 		return (*Scanner).stateObjectKey
-	case b == '"':
+	case b == '/' && s.comments:
+		return s.startComment((*Scanner).stateObjectKey)
+	case b == 0xc2 && s.relaxedSpace:
+		return s.startNBSP((*Scanner).stateObjectKey)
+	case b == '"' || b == '\'' && s.singleQuotes:
+		s.quote = b
 		s.cook = false
 		s.isName = true
 		s.data[nameData].pos = s.pos + 1
@@ -351,6 +1276,68 @@ func (s *Scanner) stateObjectKey(b byte) stateFunc {
 	}
 }
 
+// startNBSP starts scanning a no-break space. The scanner resumes with the
+// given state after the space.
+func (s *Scanner) startNBSP(resume stateFunc) stateFunc {
+	s.resume = resume
+	return (*Scanner).stateNBSP
+}
+
+func (s *Scanner) stateNBSP(b byte) stateFunc {
+	if b != 0xa0 {
+		return s.syntaxError(b, expectNBSP)
+	}
+	return s.resume
+}
+
+func (s *Scanner) startComment(resume stateFunc) stateFunc {
+	s.resume = resume
+	return (*Scanner).stateCommentStart
+}
+
+func (s *Scanner) stateCommentStart(b byte) stateFunc {
+	switch {
+	case b == '/':
+		return (*Scanner).stateCommentLine
+	case b == '*':
+		s.commentEOF = s.eofOK
+		s.eofOK = false
+		return (*Scanner).stateCommentBlock
+	default:
+		return s.syntaxError(b, expectComment)
+	}
+}
+
+func (s *Scanner) stateCommentLine(b byte) stateFunc {
+	switch {
+	case b == '\n':
+		return s.resume(s, b)
+	default:
+		return (*Scanner).stateCommentLine
+	}
+}
+
+func (s *Scanner) stateCommentBlock(b byte) stateFunc {
+	switch {
+	case b == '*':
+		return (*Scanner).stateCommentBlockStar
+	default:
+		return (*Scanner).stateCommentBlock
+	}
+}
+
+func (s *Scanner) stateCommentBlockStar(b byte) stateFunc {
+	switch {
+	case b == '/':
+		s.eofOK = s.commentEOF
+		return s.resume
+	case b == '*':
+		return (*Scanner).stateCommentBlockStar
+	default:
+		return (*Scanner).stateCommentBlock
+	}
+}
+
 func (s *Scanner) stateNu(b byte) stateFunc {
 	switch {
 	case b == 'u':
@@ -452,10 +1439,16 @@ func (s *Scanner) stateFalse(b byte) stateFunc {
 
 func (s *Scanner) stateString(b byte) stateFunc {
 	switch {
-	case b == '"':
+	case b == s.quote:
+		if s.cook && s.strictUTF8 && !s.checkUTF8() {
+			return nil
+		}
 		if s.isName {
 			s.data[nameData].end = s.pos
 			s.data[nameData].cook = s.cook
+			if s.dupKeys && !s.checkKey() {
+				return nil
+			}
 			return (*Scanner).stateObjectColon
 		}
 		s.data[valueData].end = s.pos
@@ -468,17 +1461,109 @@ func (s *Scanner) stateString(b byte) stateFunc {
 	case b < ' ':
 		return s.syntaxError(b, expectStringNotControl)
 	case b < utf8.RuneSelf:
+		s.skipStringRun()
 		return (*Scanner).stateString
 	default:
 		s.cook = true
+		s.skipStringRun()
 		return (*Scanner).stateString
 	}
 }
 
+// skipStringRun advances pos to the last byte in the run of string bytes
+// following pos that do not end the string, start an escape or require
+// validation. The run is scanned eight bytes at a time.
+func (s *Scanner) skipStringRun() {
+	const (
+		lo = 0x0101010101010101
+		hi = 0x8080808080808080
+	)
+	p := s.buf
+	i := s.pos + 1
+	quote := s.quote
+	var high uint64 // bitwise or of the skipped bytes
+	for ; i+8 <= len(p); i += 8 {
+		// The expression is non-zero if a byte is less than ' ' or equal
+		// to the quote or a backslash.
+		w := binary.LittleEndian.Uint64(p[i:])
+		q := w ^ (lo * uint64(quote))
+		e := w ^ (lo * '\\')
+		if ((w-lo*' ')&^w|(q-lo)&^q|(e-lo)&^e)&hi != 0 {
+			break
+		}
+		high |= w
+	}
+	for i < len(p) {
+		b := p[i]
+		if b < ' ' || b == quote || b == '\\' {
+			break
+		}
+		high |= uint64(b)
+		i++
+	}
+	if high&hi != 0 {
+		s.cook = true
+	}
+	s.pos = i - 1
+}
+
+// checkUTF8 sets the permanent error if the current string contains invalid
+// UTF-8 or an invalid UTF-16 surrogate pair.
+func (s *Scanner) checkUTF8() bool {
+	start := s.data[valueData].pos
+	if s.isName {
+		start = s.data[nameData].pos
+	}
+	i := invalidStringIndex(s.buf[start:s.pos])
+	if i < 0 {
+		return true
+	}
+	s.pos = start + i
+	s.syntaxError(s.buf[s.pos], expectValidString)
+	return false
+}
+
+// invalidStringIndex returns the index of the first invalid UTF-8 sequence or
+// the first escape of an invalid UTF-16 surrogate pair in the JSON string
+// contents p. If the contents are valid, invalidStringIndex returns -1.
+func invalidStringIndex(p []byte) int {
+	for i := 0; i < len(p); {
+		switch b := p[i]; {
+		case b == '\\':
+			if p[i+1] != 'u' {
+				i += 2
+				continue
+			}
+			r := parseHex(p[i+2 : i+6])
+			if utf16.IsSurrogate(r) {
+				if r >= 0xdc00 || i+12 > len(p) || p[i+6] != '\\' || p[i+7] != 'u' {
+					return i
+				}
+				if r2 := parseHex(p[i+8 : i+12]); r2 < 0xdc00 || r2 > 0xdfff {
+					return i
+				}
+				i += 6
+			}
+			i += 6
+		case b < utf8.RuneSelf:
+			i++
+		default:
+			r, size := utf8.DecodeRune(p[i:])
+			if r == utf8.RuneError && size == 1 {
+				return i
+			}
+			i += size
+		}
+	}
+	return -1
+}
+
 func (s *Scanner) stateStringEscape(b byte) stateFunc {
 	switch {
 	case b == '"' || b == '\\' || b == 'b' || b == 'f' || b == 'n' || b == 'r' || b == 't' || b == '/':
 		return (*Scanner).stateString
+	case b == '\'' && s.singleQuotes:
+		return (*Scanner).stateString
 	case b == 'u':
 		return (*Scanner).stateStringUnicodeEscape1
 	default:
@@ -525,14 +1610,77 @@ func (s *Scanner) stateStringUnicodeEscape4(b byte) stateFunc {
 func (s *Scanner) stateNumberNeg(b byte) stateFunc {
 	switch {
 	case b == '0':
-		return (*Scanner).stateNumberDotOrExp
+		return (*Scanner).stateNumberZero
 	case '1' <= b && b <= '9':
 		return (*Scanner).stateNumberDigits
+	case b == '.' && s.relaxedNumbers:
+		return (*Scanner).stateNumberFrac
+	case b == 'I' && s.nonFinite:
+		s.literal = "nfinity"
+		return (*Scanner).stateLiteral
 	default:
 		return s.syntaxError(b, expectNumberNeg)
 	}
 }
 
+func (s *Scanner) stateNumberPlus(b byte) stateFunc {
+	switch {
+	case b == '0':
+		return (*Scanner).stateNumberZero
+	case '1' <= b && b <= '9':
+		return (*Scanner).stateNumberDigits
+	case b == '.':
+		return (*Scanner).stateNumberFrac
+	case b == 'I' && s.nonFinite:
+		s.literal = "nfinity"
+		return (*Scanner).stateLiteral
+	default:
+		return s.syntaxError(b, expectNumberPlus)
+	}
+}
+
+func (s *Scanner) stateNumberZero(b byte) stateFunc {
+	switch {
+	case (b == 'x' || b == 'X') && s.relaxedNumbers:
+		return (*Scanner).stateNumberHex
+	default:
+		return s.stateNumberDotOrExp(b)
+	}
+}
+
+func (s *Scanner) stateNumberHex(b byte) stateFunc {
+	switch {
+	case isHexDigit(b):
+		return (*Scanner).stateNumberHexDigits
+	default:
+		return s.syntaxError(b, expectNumberHex)
+	}
+}
+
+func (s *Scanner) stateNumberHexDigits(b byte) stateFunc {
+	switch {
+	case isHexDigit(b):
+		return (*Scanner).stateNumberHexDigits
+	default:
+		return s.finishNumber()
+	}
+}
+
+// stateLiteral matches the remaining bytes of a NaN or Infinity token.
+func (s *Scanner) stateLiteral(b byte) stateFunc {
+	if b != s.literal[0] {
+		return s.syntaxError(b, expectNonFinite)
+	}
+	s.literal = s.literal[1:]
+	if s.literal != "" {
+		return (*Scanner).stateLiteral
+	}
+	s.data[valueData].end = s.pos + 1
+	s.data[valueData].cook = s.buf[s.data[valueData].pos] == '+'
+	s.kind = Number
+	return nil
+}
+
 func (s *Scanner) stateNumberDigits(b byte) stateFunc {
 	switch {
 	case isDecimalDigit(b):
@@ -604,7 +1752,9 @@ func (s *Scanner) stateNumberExpDigits(b byte) stateFunc {
 
 func (s *Scanner) finishNumber() stateFunc {
 	s.kind = Number
-	s.data[valueData].end = s.pos
+	data := &s.data[valueData]
+	data.end = s.pos
+	data.cook = s.relaxedNumbers && isRelaxedNumber(s.buf[data.pos:data.end])
 	s.pos -= 1
 	return nil
 }
@@ -619,21 +1769,62 @@ func (s *Scanner) push(f stateFunc) {
 
 func (s *Scanner) pop() {
 	s.states = s.states[:len(s.states)-1]
+	if s.raw {
+		n := len(s.rawStarts) - 1
+		s.rawPos = s.rawStarts[n]
+		s.rawEnd = s.pos + 1
+		s.rawStarts = s.rawStarts[:n]
+	}
 }
 
-// NestingLevel returns the scanner's current nesting level for objects and array.
+// NestingLevel returns the scanner's current nesting level for objects and
+// arrays. The level increases by one after an Array or Object element and
+// decreases by one after an End element. Record the level at an Array or
+// Object element and pass it to ScanAtLevel to iterate over the elements of
+// the array or object:
+//
+//  n := s.NestingLevel()
+//  for s.ScanAtLevel(n) {
+//      // handle element
+//  }
 func (s *Scanner) NestingLevel() int { return len(s.states) }
 
 // ScanAtLevel advances to the next element at the current nesting level,
 // possibly skipping over nested elements. ScanAtLevel returns false at the End
 // element for the current level or if an error is encountered.
 func (s *Scanner) ScanAtLevel(nestingLevel int) bool {
+	return s.ScanAboveLevel(nestingLevel) && s.Kind() != End
+}
+
+// ScanAboveLevel advances to the next element at nesting level nestingLevel
+// or lower, skipping over the subtrees of elements nested more deeply. Unlike
+// ScanAtLevel, ScanAboveLevel returns true at the End element for the level
+// and scanning continues with the elements of the enclosing levels.
+// ScanAboveLevel returns false at the end of input or if an error is
+// encountered.
+func (s *Scanner) ScanAboveLevel(nestingLevel int) bool {
 	for len(s.states) > nestingLevel {
 		if !s.Scan() {
 			return false
 		}
 	}
-	return s.Scan() && s.Kind() != End
+	return s.Scan()
+}
+
+// Skip skips over the current value. If the current value is an array or
+// object, then Skip scans to the matching End element.
+func (s *Scanner) Skip() error {
+	if s.kind == Array || s.kind == Object {
+		v := s.startValue()
+		n := len(s.states)
+		for len(s.states) >= n {
+			if !s.Scan() {
+				break
+			}
+		}
+		return s.endValue(v, s.Err())
+	}
+	return s.Err()
 }
 
 // Kind returns the kind of the current value.
@@ -654,16 +1845,144 @@ func (s *Scanner) Err() error {
 // array may point to data that will be overwritten by a subsequent call to
 // Scan.
 func (s *Scanner) Name() []byte {
+	if s.rawStrings {
+		return s.rawData(nameData)
+	}
 	return s.cookedData(nameData)
 }
 
+// NameString returns the object member name of the current value as a
+// string. If key interning is enabled, repeated names share the same string.
+func (s *Scanner) NameString() string {
+	p := s.Name()
+	if s.intern == nil {
+		return string(p)
+	}
+	if name, ok := s.intern[string(p)]; ok {
+		return name
+	}
+	name := string(p)
+	if len(s.intern) < maxInterned {
+		s.intern[name] = name
+	}
+	return name
+}
+
+// NameIs returns true if the object member name of the current value is
+// equal to name. NameIs compares the input bytes directly when the name
+// does not contain escapes or invalid UTF-8.
+func (s *Scanner) NameIs(name string) bool {
+	data := &s.data[nameData]
+	if data.pos < 0 {
+		return false
+	}
+	p := s.rawData(nameData)
+	if data.cook && !s.rawStrings && (bytes.IndexByte(p, '\\') >= 0 || !utf8.Valid(p)) {
+		p = s.cookedData(nameData)
+	}
+	return string(p) == name
+}
+
+// NameEqualFold returns true if the object member name of the current value
+// is equal to name under Unicode case-folding.
+func (s *Scanner) NameEqualFold(name string) bool {
+	if s.data[nameData].pos < 0 {
+		return false
+	}
+	return equalFold(s.Name(), name)
+}
+
+// equalFold is like bytes.EqualFold, but compares a byte slice to a string.
+func equalFold(p []byte, s string) bool {
+	for len(p) > 0 && len(s) > 0 {
+		r1, n1 := utf8.DecodeRune(p)
+		r2, n2 := utf8.DecodeRuneInString(s)
+		p, s = p[n1:], s[n2:]
+		if r1 == r2 {
+			continue
+		}
+		if r2 < r1 {
+			r1, r2 = r2, r1
+		}
+		if r2 < utf8.RuneSelf {
+			if 'A' <= r1 && r1 <= 'Z' && r2 == r1+'a'-'A' {
+				continue
+			}
+			return false
+		}
+		// General case. SimpleFold(x) returns the next equivalent rune > x
+		// or wraps around to smaller values.
+		r := unicode.SimpleFold(r1)
+		for r != r1 && r < r2 {
+			r = unicode.SimpleFold(r)
+		}
+		if r != r2 {
+			return false
+		}
+	}
+	return len(p) == 0 && len(s) == 0
+}
+
+// maxInterned is the maximum number of interned member names. The limit
+// bounds memory use when the input has many distinct names.
+const maxInterned = 4096
+
+// SetKeyInterning sets whether NameString returns a shared string for
+// repeated object member names. DecodeValue, Unmarshal and Token use
+// NameString to create map keys and member names. Interning reduces
+// allocations when scanning a stream of records with the same keys. The
+// interned names are retained by Reset.
+func (s *Scanner) SetKeyInterning(intern bool) {
+	switch {
+	case !intern:
+		s.intern = nil
+	case s.intern == nil:
+		s.intern = make(map[string]string)
+	}
+}
+
 // Value returns the bytes of the current string or number value. The
 // underlying array may point to data that will be overwritten by a
 // subsequent call to Scan.
 func (s *Scanner) Value() []byte {
+	if s.streaming || s.streamed {
+		return s.streamValue()
+	}
+	if s.kind == Number && s.data[valueData].cook {
+		s.cbuf[valueData] = normalizeNumber(s.cbuf[valueData][:0], s.rawData(valueData))
+		return s.cbuf[valueData]
+	}
+	if s.rawStrings {
+		return s.rawData(valueData)
+	}
 	return s.cookedData(valueData)
 }
 
+// StrictUTF8 sets whether invalid UTF-8 and invalid UTF-16 surrogate pairs
+// in strings are syntax errors. By default, the invalid data is replaced with
+// the Unicode replacement character U+FFFD. Use StrictUTF8 to enforce I-JSON
+// (RFC 7493).
+func (s *Scanner) StrictUTF8(strict bool) {
+	s.strictUTF8 = strict
+}
+
+// SetRawStrings sets whether Name and Value return strings as they appear in
+// the input, without the quotes and with escapes intact. Use raw strings to
+// avoid the cost of unescaping strings that are copied verbatim to other JSON
+// text. Duplicate key detection, path tracking and Seek use the unescaped
+// names.
+func (s *Scanner) SetRawStrings(raw bool) {
+	s.rawStrings = raw
+}
+
+func (s *Scanner) rawData(dataIndex int) []byte {
+	data := &s.data[dataIndex]
+	if data.pos < 0 {
+		return nil
+	}
+	return s.buf[data.pos:data.end]
+}
+
 func (s *Scanner) cookedData(dataIndex int) []byte {
 	data := &s.data[dataIndex]
 	if data.pos < 0 {
@@ -673,10 +1992,37 @@ func (s *Scanner) cookedData(dataIndex int) []byte {
 	if !data.cook {
 		return rbuf
 	}
+	if s.stats != nil {
+		s.stats.StringsCooked++
+	}
 
+	wbuf := rbuf
+	if s.shared || s.raw || s.rawStrings || s.marked {
+		if cap(s.cbuf[dataIndex]) < len(rbuf) {
+			s.cbuf[dataIndex] = make([]byte, len(rbuf))
+		}
+		wbuf = s.cbuf[dataIndex][:len(rbuf)]
+	}
+	p := unescape(wbuf, rbuf)
+	if len(p) > 0 && &p[0] == &rbuf[0] {
+		// Cooked in place. Record the result so that the data is not
+		// cooked again on the next call.
+		data.end = data.pos + len(p)
+		data.cook = false
+	} else if s.shared || s.raw || s.rawStrings || s.marked {
+		s.cbuf[dataIndex] = p[:cap(p)]
+	}
+	return p
+}
+
+// unescape decodes the escapes in the JSON string contents rbuf to wbuf and
+// replaces invalid UTF-8 and invalid UTF-16 surrogate pairs with U+FFFD. The
+// length of wbuf must be at least the length of rbuf. The buffers can be the
+// same. If wbuf is too short for the result, then unescape allocates a new
+// buffer.
+func unescape(wbuf, rbuf []byte) []byte {
 	r := 0
 	w := 0
-	wbuf := rbuf
 	for r < len(rbuf) {
 		switch b := rbuf[r]; {
 		case b == '\\':
@@ -720,9 +2066,7 @@ func (s *Scanner) cookedData(dataIndex int) []byte {
 		default:
 			c, n := utf8.DecodeRune(rbuf[r:])
 			r += n
-			if c == utf8.RuneError && n < 3 &&
-				((&wbuf[0] == &rbuf[0] && w+3 >= r) ||
-					(w+3 >= len(wbuf))) {
+			if c == utf8.RuneError && n < 3 && len(wbuf)-w < 3+len(rbuf)-r {
 				buf := make([]byte, w+3+(4*(len(rbuf)-r))/3)
 				copy(buf, wbuf[:w])
 				wbuf = buf
@@ -734,12 +2078,44 @@ func (s *Scanner) cookedData(dataIndex int) []byte {
 }
 
 func (s *Scanner) syntaxError(b byte, expect string) stateFunc {
-	s.err = &SyntaxError{s.pos, b, expect}
+	offset, line, column := s.position(s.pos)
+	s.err = &SyntaxError{Pos: int(offset), Line: line, Column: column, b: b, expect: expect}
 	return nil
 }
 
+// LimitError is returned when the input exceeds a limit set on the Scanner.
+type LimitError struct {
+	Limit string // description of the limit
+	Max   int64  // value of the limit
+}
+
+func (e *LimitError) Error() string {
+	return e.Limit + " exceeds limit of " + strconv.FormatInt(e.Max, 10)
+}
+
+// Is returns true if target is ErrTooDeep and e is a nesting depth error.
+func (e *LimitError) Is(target error) bool {
+	return target == ErrTooDeep && e.Limit == "nesting depth"
+}
+
+// DuplicateKeyError is returned when an object contains more than one member
+// with the same name and the scanner rejects duplicate keys.
+type DuplicateKeyError struct {
+	Key    string // the duplicate member name
+	Pos    int    // input offset of the end of the duplicate name
+	Line   int    // line of the end of the duplicate name, starting at one
+	Column int    // byte column of the end of the duplicate name, starting at one
+}
+
+func (e *DuplicateKeyError) Error() string {
+	return "duplicate key " + strconv.Quote(e.Key) + " in object"
+}
+
+// SyntaxError is a description of a JSON syntax error.
 type SyntaxError struct {
-	Pos    int
+	Pos    int // input offset of the unexpected byte
+	Line   int // line of the unexpected byte, starting at one
+	Column int // byte column of the unexpected byte, starting at one
 	b      byte
 	expect string
 }
@@ -750,6 +2126,12 @@ func (e *SyntaxError) Error() string {
 
 const (
 	expectWhitespace           = "whitespace"
+	expectNewline              = "newline after value"
+	expectRecordSeparator      = "record separator before value"
+	expectValidString          = "valid UTF-8 or UTF-16 surrogate pair"
+	expectBOM                  = "UTF-8 byte order mark"
+	expectNBSP                 = "no-break space"
+	expectComment              = "'/' or '*' after '/'"
 	expectValue                = "start of JSON value"
 	expectArrayCommaOrClose    = "',' or ']' in array"
 	expectObjectKeyOrClose     = "key or '}' in object"
@@ -773,11 +2155,25 @@ const (
 	expectStringUnicodeEscape3 = "hex digit following \\u"
 	expectStringUnicodeEscape4 = "hex digit following \\u"
 	expectNumberNeg            = "digit after '-'"
+	expectNonFinite            = "NaN or Infinity"
+	expectNumberPlus           = "digit after '+'"
+	expectNumberHex            = "hex digit after 0x"
 	expectNumberFrac           = "digit after '.'"
 	expectNumberExp            = "exponent"
 	expectNumberExpDigit       = "exponent digits"
 )
 
+// isSpace returns true if b is whitespace within a value.
+func (s *Scanner) isSpace(b byte) bool {
+	return b == ' ' || b == '\t' || b == '\r' || (b == '\n' && !s.lines) || s.isRelaxedSpace(b)
+}
+
+// isRelaxedSpace returns true if b is a single byte whitespace character
+// accepted by AllowRelaxedWhitespace.
+func (s *Scanner) isRelaxedSpace(b byte) bool {
+	return s.relaxedSpace && (b == '\v' || b == '\f')
+}
+
 func isWhiteSpace(b byte) bool {
 	return b == ' ' || b == '\n' || b == '\r' || b == '\t'
 }
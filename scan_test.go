@@ -5,11 +5,15 @@
 package json
 
 import (
+	"bufio"
+	"bytes"
 	"io"
+	"math"
 	"reflect"
 	"strconv"
 	"strings"
 	"testing"
+	"testing/iotest"
 )
 
 type scan struct {
@@ -130,6 +134,18 @@ var scannerTests = []struct {
 	{`"matzue: 松江, asakusa: 浅草"`,
 		[]scan{{k: String, v: "matzue: 松江, asakusa: 浅草"}, eof}},
 
+	// Strings longer than the eight bytes scanned at a time.
+	{`["0123456789abcdef\u00e9\t", "0123456789ü", "01234567"]`,
+		[]scan{
+			{k: Array},
+			{k: String, v: "0123456789abcdef\u00e9\t"},
+			{k: String, v: "0123456789ü"},
+			{k: String, v: "01234567"},
+			{k: End},
+			eof}},
+	{"\"0123456789abcdef\x01\"", []scan{syntaxError(0x01, expectStringNotControl)}},
+	{`"0123456789abcdef\x"`, []scan{syntaxError('x', expectStringEscape)}},
+
 	{`"Да Му Еба Майката"`,
 		[]scan{{k: String, v: "Да Му Еба Майката"}, eof}},
 
@@ -272,27 +288,96 @@ var scannerTests = []struct {
 	{`[ "foo", "bar"`, []scan{{k: Array}, {k: String, v: "foo"}, {k: String, v: "bar"}, scanError(io.ErrUnexpectedEOF)}},
 }
 
+// readerOnly hides the type of the reader from the scanner.
+type readerOnly struct {
+	io.Reader
+}
+
 func TestScanner(t *testing.T) {
-tests:
+	testScanner(t, func(s string) *Scanner { return NewScanner(readerOnly{strings.NewReader(s)}) })
+}
+
+func TestScannerSources(t *testing.T) {
+	for _, newReader := range []func(string) io.Reader{
+		func(s string) io.Reader { return bytes.NewBufferString(s) },
+		func(s string) io.Reader { return bytes.NewReader([]byte(s)) },
+		func(s string) io.Reader { return strings.NewReader(s) },
+		func(s string) io.Reader { return bufio.NewReaderSize(strings.NewReader(s), 16) },
+	} {
+		testScanner(t, func(s string) *Scanner { return NewScanner(newReader(s)) })
+	}
+}
+
+func TestScannerSourcePosition(t *testing.T) {
+	r := strings.NewReader(`xx[1]`)
+	r.Seek(2, 0)
+	s := NewScanner(r)
+	if !s.Scan() || s.Kind() != Array || !s.Scan() || string(s.Value()) != "1" {
+		t.Fatalf("did not scan from reader position")
+	}
+
+	// Data written to a buffer after the scanner is created is scanned and
+	// the scanner does not alias the buffer's storage.
+	b := new(bytes.Buffer)
+	s = NewScanner(b)
+	b.WriteString(`["ab"]`)
+	if !s.Scan() || s.Kind() != Array || !s.Scan() || string(s.Value()) != "ab" {
+		t.Fatalf("did not scan data written after NewScanner")
+	}
+	v := s.Value()
+	b.Reset()
+	b.WriteString(`["xy"]`)
+	if string(v) != "ab" {
+		t.Errorf("value changed to %q after buffer write", v)
+	}
+}
+
+func TestScannerBytes(t *testing.T) {
+	testScanner(t, func(s string) *Scanner { return NewScannerBytes([]byte(s)) })
+}
+
+func TestScannerReset(t *testing.T) {
+	s := NewScannerSize(strings.NewReader(`{"a": [1, 2`), 8)
+	s.AllowMultiple()
+	s.RejectDuplicateKeys(true)
+	s.Scan()
+	s.Scan()
+	for i, tt := range scannerTests {
+		if i%2 == 0 {
+			s.Reset(strings.NewReader(tt.s))
+		} else {
+			s.ResetBytes([]byte(tt.s))
+		}
+		checkScans(t, s, tt.s, tt.scans)
+	}
+}
+
+func testScanner(t *testing.T, newScanner func(string) *Scanner) {
 	for _, tt := range scannerTests {
-		s := NewScanner(strings.NewReader(tt.s))
-		s.AllowMultple()
-		for i, want := range tt.scans {
-			var got scan
-			if !s.Scan() {
-				got.k = -1
-				if err := s.Err(); err != nil {
-					got.e = err.Error()
-				}
-			} else {
-				got.k = s.Kind()
-				got.n = string(s.Name())
-				got.v = string(s.Value())
-			}
-			if !reflect.DeepEqual(got, want) {
-				t.Errorf("%q:%d, got=%s, want=%s", tt.s, i, got, want)
-				continue tests
+		s := newScanner(tt.s)
+		s.AllowMultiple()
+		checkScans(t, s, tt.s, tt.scans)
+	}
+}
+
+// checkScans checks that the scanner returns the expected elements for the
+// input.
+func checkScans(t *testing.T, s *Scanner, input string, scans []scan) {
+	for i, want := range scans {
+		var got scan
+		if !s.Scan() {
+			got.k = -1
+			if err := s.Err(); err != nil {
+				got.e = err.Error()
 			}
+		} else {
+			got.k = s.Kind()
+			got.n = string(s.Name())
+			got.v = string(s.Value())
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%q:%d, got=%s, want=%s", input, i, got, want)
+			return
 		}
 	}
 }
@@ -322,3 +407,775 @@ func TestScanAtLevel(t *testing.T) {
 		t.Errorf("expected ss.Scan() = false")
 	}
 }
+
+func TestScanAboveLevel(t *testing.T) {
+	s := NewScanner(strings.NewReader(`[[{"a": [2]}, 3], 1]`))
+	s.Scan()
+	s.Scan()
+	s.Scan()
+	n := s.NestingLevel()
+	var got []Kind
+	for s.ScanAboveLevel(n) {
+		got = append(got, s.Kind())
+	}
+	want := []Kind{Array, End, Number, End, Number, End}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if err := s.Err(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestScannerBytesNoModify(t *testing.T) {
+	const doc = `{"a\u0062": "\n\u00e9\t", "c": "d"}`
+	data := []byte(doc)
+	s := NewScannerBytes(data)
+	var got []string
+	for s.Scan() {
+		got = append(got, string(s.Name()), string(s.Value()))
+		got = append(got, string(s.Name()), string(s.Value()))
+	}
+	if err := s.Err(); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"", "", "", "", "ab", "\n\u00e9\t", "ab", "\n\u00e9\t", "c", "d", "c", "d", "", "", "", ""}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if string(data) != doc {
+		t.Errorf("data modified, got %q, want %q", data, doc)
+	}
+}
+
+var syntaxErrorPositionTests = []struct {
+	s                 string
+	pos, line, column int
+}{
+	{`x`, 0, 1, 1},
+	{`[1, x]`, 4, 1, 5},
+	{"[\n  1,\n  x]", 9, 3, 3},
+	{"{\"a\":\n\"b\nc\"}", 8, 2, 3},
+	{"[\r\n\r\n\t-x", 7, 3, 3},
+	// Newlines before input moved to the start of the buffer.
+	{"[\n\n\n\n" + strings.Repeat(" ", 1000) + `"` + strings.Repeat("a", 100) + "\",\n x]", 1110, 6, 2},
+}
+
+func TestSyntaxErrorPosition(t *testing.T) {
+	for _, tt := range syntaxErrorPositionTests {
+		for _, s := range []*Scanner{
+			NewScanner(strings.NewReader(tt.s)),
+			NewScanner(readerOnly{strings.NewReader(tt.s)}),
+			NewScanner(iotest.OneByteReader(strings.NewReader(tt.s))),
+			NewScannerBytes([]byte(tt.s)),
+		} {
+			for s.Scan() {
+			}
+			e, ok := s.Err().(*SyntaxError)
+			if !ok {
+				t.Errorf("%q: got error %v, want syntax error", tt.s, s.Err())
+				continue
+			}
+			if e.Pos != tt.pos || e.Line != tt.line || e.Column != tt.column {
+				t.Errorf("%q: got pos=%d line=%d column=%d, want pos=%d line=%d column=%d",
+					tt.s, e.Pos, e.Line, e.Column, tt.pos, tt.line, tt.column)
+			}
+		}
+	}
+}
+
+func TestPosition(t *testing.T) {
+	s := NewScanner(iotest.OneByteReader(strings.NewReader("[\n  1,\n  \"two\"\n]")))
+	want := [][2]int{{1, 2}, {2, 4}, {3, 8}, {4, 2}}
+	for i := 0; s.Scan(); i++ {
+		line, column := s.Position()
+		if line != want[i][0] || column != want[i][1] {
+			t.Errorf("%d: got line=%d column=%d, want line=%d column=%d", i, line, column, want[i][0], want[i][1])
+		}
+	}
+}
+
+var limitTests = []struct {
+	s        string
+	maxDepth int
+	maxBytes int64
+	n        int // number of successful scans
+	err      error
+}{
+	{`[[1]]`, 2, 0, 5, nil},
+	{`[[1]]`, 1, 0, 1, &LimitError{"nesting depth", 1}},
+	{`[{"a":{}}]`, 2, 0, 2, &LimitError{"nesting depth", 2}},
+	{strings.Repeat("[", DefaultMaxDepth+1), 0, 0, DefaultMaxDepth, &LimitError{"nesting depth", DefaultMaxDepth}},
+	{strings.Repeat("[", DefaultMaxDepth+1) + strings.Repeat("]", DefaultMaxDepth+1), -1, 0, 2*DefaultMaxDepth + 2, nil},
+	{`[1, 2]`, 0, 6, 4, nil},
+	{`[1, 2] `, 0, 6, 4, &LimitError{"document size", 6}},
+	{`[1, 2]`, 0, 5, 2, &LimitError{"document size", 5}},
+}
+
+func TestLimits(t *testing.T) {
+	for _, tt := range limitTests {
+		for _, s := range []*Scanner{
+			NewScanner(strings.NewReader(tt.s)),
+			NewScanner(iotest.OneByteReader(strings.NewReader(tt.s))),
+			NewScannerBytes([]byte(tt.s)),
+		} {
+			s.SetMaxDepth(tt.maxDepth)
+			s.SetMaxBytes(tt.maxBytes)
+			n := 0
+			for s.Scan() {
+				n++
+			}
+			if n != tt.n {
+				t.Errorf("%q: got %d scans, want %d", tt.s, n, tt.n)
+			}
+			if !reflect.DeepEqual(s.Err(), tt.err) {
+				t.Errorf("%q: got error %v, want %v", tt.s, s.Err(), tt.err)
+			}
+		}
+	}
+}
+
+var valueLimitTests = []struct {
+	s           string
+	maxString   int
+	maxMembers  int
+	maxElements int
+	n           int // number of successful scans
+	err         error
+}{
+	{`["abc",{"abc":1}]`, 3, 0, 0, 6, nil},
+	{`["abcd"]`, 3, 0, 0, 1, &LimitError{"string length", 3}},
+	{`[{"abcd":1}]`, 3, 0, 0, 2, &LimitError{"string length", 3}},
+	{`["\u0061\u0062\u0063"]`, 3, 0, 0, 3, nil},
+	{`["\u00e9\u00e9"]`, 3, 0, 0, 1, &LimitError{"string length", 3}},
+	{`[1,2,[3,4,5]]`, 0, 0, 3, 9, nil},
+	{`[1,2,[3,4,5,6]]`, 0, 0, 3, 7, &LimitError{"array elements", 3}},
+	{`[[],[],[],[]]`, 0, 0, 3, 7, &LimitError{"array elements", 3}},
+	{`{"a":{"b":1,"c":2},"d":[1,2,3]}`, 0, 2, 0, 11, nil},
+	{`{"a":{"b":1,"c":2,"d":3}}`, 0, 2, 0, 4, &LimitError{"object members", 2}},
+	{`{"a":[1,2,3],"b":1,"c":1}`, 0, 2, 2, 4, &LimitError{"array elements", 2}},
+}
+
+func TestValueLimits(t *testing.T) {
+	for _, tt := range valueLimitTests {
+		s := NewScanner(iotest.OneByteReader(strings.NewReader(tt.s)))
+		s.SetMaxStringLen(tt.maxString)
+		s.SetMaxMembers(tt.maxMembers)
+		s.SetMaxElements(tt.maxElements)
+		n := 0
+		for s.Scan() {
+			n++
+		}
+		if n != tt.n {
+			t.Errorf("%q: got %d scans, want %d", tt.s, n, tt.n)
+		}
+		if !reflect.DeepEqual(s.Err(), tt.err) {
+			t.Errorf("%q: got error %v, want %v", tt.s, s.Err(), tt.err)
+		}
+	}
+}
+
+var bufferTests = []struct {
+	s   string
+	n   int
+	err error
+}{
+	{`["` + strings.Repeat("a", 60) + `"]`, 3, nil},
+	{`["` + strings.Repeat("a", 100) + `"]`, 1, ErrTokenTooLong},
+	{`[` + strings.Repeat("1", 100) + `]`, 1, ErrTokenTooLong},
+	{`[1,` + strings.Repeat(" ", 1000) + `2]`, 4, nil},
+}
+
+func TestBuffer(t *testing.T) {
+	for _, tt := range bufferTests {
+		for _, s := range []*Scanner{
+			NewScanner(readerOnly{strings.NewReader(tt.s)}),
+			NewScanner(iotest.OneByteReader(strings.NewReader(tt.s))),
+		} {
+			s.Buffer(make([]byte, 0, 16), 64)
+			n := 0
+			for s.Scan() {
+				n++
+			}
+			if n != tt.n {
+				t.Errorf("%.10q: got %d scans, want %d", tt.s, n, tt.n)
+			}
+			if s.Err() != tt.err {
+				t.Errorf("%.10q: got error %v, want %v", tt.s, s.Err(), tt.err)
+			}
+		}
+	}
+}
+
+var duplicateKeyTests = []struct {
+	s   string
+	err error
+}{
+	{`{"a":1,"b":2}`, nil},
+	{`[{"a":1},{"a":2}]`, nil},
+	{`{"a":{"a":1},"b":{"a":2}}`, nil},
+	{`{"a":1,"a":2}`, &DuplicateKeyError{Key: "a", Pos: 9, Line: 1, Column: 10}},
+	{`{"a":{"b":1},"a":2}`, &DuplicateKeyError{Key: "a", Pos: 15, Line: 1, Column: 16}},
+	{"{\"a\":{\"b\":1,\n\"b\":2}}", &DuplicateKeyError{Key: "b", Pos: 15, Line: 2, Column: 3}},
+}
+
+func TestRejectDuplicateKeys(t *testing.T) {
+	for _, tt := range duplicateKeyTests {
+		s := NewScanner(iotest.OneByteReader(strings.NewReader(tt.s)))
+		s.RejectDuplicateKeys(true)
+		for s.Scan() {
+		}
+		if !reflect.DeepEqual(s.Err(), tt.err) {
+			t.Errorf("%q: got error %v, want %v", tt.s, s.Err(), tt.err)
+		}
+	}
+}
+
+func TestRawValue(t *testing.T) {
+	const doc = ` [ 1, "ab" , {"x" : [true, null], "y":"\n"}, [ ] ] `
+	want := []string{
+		`[`,
+		`1`,
+		`"ab"`,
+		`{`,
+		`[`,
+		`true`,
+		`null`,
+		`[true, null]`,
+		`"\n"`,
+		`{"x" : [true, null], "y":"\n"}`,
+		`[`,
+		`[ ]`,
+		`[ 1, "ab" , {"x" : [true, null], "y":"\n"}, [ ] ]`,
+	}
+	for _, s := range []*Scanner{
+		NewScanner(strings.NewReader(doc)),
+		NewScanner(iotest.OneByteReader(strings.NewReader(doc))),
+		NewScannerBytes([]byte(doc)),
+	} {
+		s.RecordRawValues(true)
+		var got []string
+		for s.Scan() {
+			// Cook name and value to check that cooking does not modify
+			// the raw value.
+			s.Name()
+			s.Value()
+			got = append(got, string(s.RawValue()))
+		}
+		if err := s.Err(); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("got  %q\nwant %q", got, want)
+		}
+	}
+}
+
+func TestRawValueSkip(t *testing.T) {
+	s := NewScanner(iotest.OneByteReader(strings.NewReader(`{"a": {"b": [1, 2]}, "c": 3}`)))
+	s.RecordRawValues(true)
+	if !s.Scan() || !s.Seek("/a") {
+		t.Fatal("Seek(/a) = false")
+	}
+	if err := s.Skip(); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(s.RawValue()), `{"b": [1, 2]}`; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestSkipRaw(t *testing.T) {
+	const doc = `{"a": {"b": [1, "\\n"]}, "c": "x\\ty", "d": [], "e": 1.5}`
+	want := []string{`{"b": [1, "\\n"]}`, `"x\\ty"`, `[]`, `1.5`}
+	for _, s := range []*Scanner{
+		NewScanner(iotest.OneByteReader(strings.NewReader(doc))),
+		NewScannerBytes([]byte(doc)),
+	} {
+		var got []string
+		s.Scan()
+		for s.Scan() && s.Kind() != End {
+			p, err := s.SkipRaw()
+			if err != nil {
+				t.Fatal(err)
+			}
+			got = append(got, string(p))
+		}
+		if err := s.Err(); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("got %q, want %q", got, want)
+		}
+	}
+}
+
+var linesTests = []struct {
+	s     string
+	scans []scan
+}{
+	{"", []scan{eof}},
+	{"\n\n", []scan{eof}},
+	{"1\n2", []scan{{k: Number, v: "1"}, {k: Number, v: "2"}, eof}},
+	{"1\r\n\r\n\"a\"\r\n", []scan{{k: Number, v: "1"}, {k: String, v: "a"}, eof}},
+	{"{\"a\": [1, 2]}\n[]\n", []scan{{k: Object}, {k: Array, n: "a"}, {k: Number, v: "1"}, {k: Number, v: "2"}, {k: End}, {k: End}, {k: Array}, {k: End}, eof}},
+	{"1 2\n", []scan{{k: Number, v: "1"}, syntaxError('2', expectNewline)}},
+	{"{} {}\n", []scan{{k: Object}, {k: End}, syntaxError('{', expectNewline)}},
+	{"[1,\n2]\n", []scan{{k: Array}, {k: Number, v: "1"}, syntaxError('\n', expectValue)}},
+	{"[1\n", []scan{{k: Array}, {k: Number, v: "1"}, syntaxError('\n', expectArrayCommaOrClose)}},
+}
+
+func TestExpectLines(t *testing.T) {
+	for _, tt := range linesTests {
+		s := NewScanner(strings.NewReader(tt.s))
+		s.ExpectLines()
+		checkScans(t, s, tt.s, tt.scans)
+	}
+}
+
+func TestResync(t *testing.T) {
+	const doc = "{\"a\": 1}\n{\"a\": x, \"b\": [1, 2]}\n[1\n{\"a\": 3}\n{\"a\": 4, \"a\": 5}\n{\"a\": 6}"
+	for _, s := range []*Scanner{
+		NewScanner(strings.NewReader(doc)),
+		NewScanner(iotest.OneByteReader(strings.NewReader(doc))),
+		NewScannerBytes([]byte(doc)),
+	} {
+		s.ExpectLines()
+		s.RejectDuplicateKeys(true)
+		var got []string
+		var errs []int
+		for {
+			if !s.Scan() {
+				if s.Err() == nil {
+					break
+				}
+				switch err := s.Err().(type) {
+				case *SyntaxError:
+					errs = append(errs, err.Line)
+				case *DuplicateKeyError:
+					errs = append(errs, err.Line)
+				}
+				if !s.Resync() {
+					t.Fatalf("Resync() = false, error %v", s.Err())
+				}
+				continue
+			}
+			if s.Kind() == Number {
+				got = append(got, string(s.Value()))
+			}
+		}
+		if want := []string{"1", "1", "3", "4", "6"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("got values %v, want %v", got, want)
+		}
+		if want := []int{2, 3, 5}; !reflect.DeepEqual(errs, want) {
+			t.Errorf("got error lines %v, want %v", errs, want)
+		}
+	}
+}
+
+func TestResyncIOError(t *testing.T) {
+	s := NewScanner(iotest.TimeoutReader(strings.NewReader("1\n2\n")))
+	s.ExpectLines()
+	for s.Scan() {
+	}
+	if s.Err() == nil {
+		t.Fatal("expected error")
+	}
+	if s.Resync() {
+		t.Error("Resync() = true after I/O error")
+	}
+}
+
+var commentTests = []struct {
+	s     string
+	scans []scan
+}{
+	{"// c\n1", []scan{{k: Number, v: "1"}, eof}},
+	{"/* c */ 1 /* c */", []scan{{k: Number, v: "1"}, eof}},
+	{"1// c", []scan{{k: Number, v: "1"}, eof}},
+	{"/**/1/***/", []scan{{k: Number, v: "1"}, eof}},
+	{"[1/* , */, // ]\n 2 /* ] */]", []scan{{k: Array}, {k: Number, v: "1"}, {k: Number, v: "2"}, {k: End}, eof}},
+	{"{/*a*/\"a\"/*b*/:/*c*/\"/*x*/\"/*d*/,//e\n\"b\"://f\ntrue}",
+		[]scan{{k: Object}, {k: String, n: "a", v: "/*x*/"}, {k: Bool, n: "b", v: "true"}, {k: End}, eof}},
+	{"1 /* c ", []scan{{k: Number, v: "1"}, scanError(io.ErrUnexpectedEOF)}},
+	{"1 /x", []scan{{k: Number, v: "1"}, syntaxError('x', expectComment)}},
+}
+
+func TestAllowComments(t *testing.T) {
+	for _, tt := range commentTests {
+		s := NewScanner(strings.NewReader(tt.s))
+		s.AllowMultiple()
+		s.AllowComments(true)
+		checkScans(t, s, tt.s, tt.scans)
+	}
+}
+
+func TestAllowCommentsLines(t *testing.T) {
+	s := NewScanner(strings.NewReader("// header\n1 // one\n/* two */ 2\n"))
+	s.ExpectLines()
+	s.AllowComments(true)
+	var got []string
+	for s.Scan() {
+		got = append(got, string(s.Value()))
+	}
+	if err := s.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"1", "2"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestCommentsNotAllowed(t *testing.T) {
+	s := NewScanner(strings.NewReader("[1 // c\n]"))
+	for s.Scan() {
+	}
+	if _, ok := s.Err().(*SyntaxError); !ok {
+		t.Errorf("got error %v, want syntax error", s.Err())
+	}
+}
+
+var trailingCommaTests = []struct {
+	s     string
+	scans []scan
+}{
+	{`[1,]`, []scan{{k: Array}, {k: Number, v: "1"}, {k: End}, eof}},
+	{`[1 , ]`, []scan{{k: Array}, {k: Number, v: "1"}, {k: End}, eof}},
+	{`{"a":1,}`, []scan{{k: Object}, {k: Number, n: "a", v: "1"}, {k: End}, eof}},
+	{`[[],{},]`, []scan{{k: Array}, {k: Array}, {k: End}, {k: Object}, {k: End}, {k: End}, eof}},
+	{`[,]`, []scan{{k: Array}, syntaxError(',', expectValue)}},
+	{`[1,,]`, []scan{{k: Array}, {k: Number, v: "1"}, syntaxError(',', expectValue)}},
+	{`{,}`, []scan{{k: Object}, syntaxError(',', expectObjectKeyOrClose)}},
+	{`{"a":1,,}`, []scan{{k: Object}, {k: Number, n: "a", v: "1"}, syntaxError(',', expectObjectKeyOrClose)}},
+}
+
+func TestAllowTrailingCommas(t *testing.T) {
+	for _, tt := range trailingCommaTests {
+		s := NewScanner(strings.NewReader(tt.s))
+		s.AllowTrailingCommas(true)
+		checkScans(t, s, tt.s, tt.scans)
+	}
+}
+
+var relaxedWhitespaceTests = []struct {
+	s     string
+	scans []scan
+}{
+	{"\f\v\u00a01\u00a0\f", []scan{{k: Number, v: "1"}, eof}},
+	{"[\u00a01\f,\v2\u00a0]", []scan{{k: Array}, {k: Number, v: "1"}, {k: Number, v: "2"}, {k: End}, eof}},
+	{"{\u00a0\"a\"\u00a0:\f\"\u00a0\"\v,\v\"b\":true\u00a0}",
+		[]scan{{k: Object}, {k: String, n: "a", v: "\u00a0"}, {k: Bool, n: "b", v: "true"}, {k: End}, eof}},
+	{"1\u00a0\f2", []scan{{k: Number, v: "1"}, {k: Number, v: "2"}, eof}},
+	{"[1\xc2]", []scan{{k: Array}, {k: Number, v: "1"}, syntaxError(']', expectNBSP)}},
+	{"1\xc2", []scan{{k: Number, v: "1"}, syntaxError(' ', expectNBSP)}},
+	{"[\u2003]", []scan{{k: Array}, syntaxError(0xe2, expectValue)}},
+}
+
+func TestAllowRelaxedWhitespace(t *testing.T) {
+	for _, tt := range relaxedWhitespaceTests {
+		s := NewScanner(strings.NewReader(tt.s))
+		s.AllowMultiple()
+		s.AllowRelaxedWhitespace(true)
+		checkScans(t, s, tt.s, tt.scans)
+	}
+}
+
+func TestRelaxedWhitespaceNotAllowed(t *testing.T) {
+	for _, in := range []string{"[1\f]", "[\u00a01]", "1\v"} {
+		s := NewScanner(strings.NewReader(in))
+		for s.Scan() {
+		}
+		if _, ok := s.Err().(*SyntaxError); !ok {
+			t.Errorf("%q: got error %v, want syntax error", in, s.Err())
+		}
+	}
+}
+
+var singleQuoteTests = []struct {
+	s     string
+	scans []scan
+}{
+	{`'a'`, []scan{{k: String, v: "a"}, eof}},
+	{`'a"b\'c\u00e9\n'`, []scan{{k: String, v: "a\"b'c\u00e9\n"}, eof}},
+	{`"a'b\'"`, []scan{{k: String, v: "a'b'"}, eof}},
+	{`{'a': 'x', "b": 'y', 'c':"z"}`,
+		[]scan{{k: Object}, {k: String, n: "a", v: "x"}, {k: String, n: "b", v: "y"}, {k: String, n: "c", v: "z"}, {k: End}, eof}},
+	{`['', '\\']`, []scan{{k: Array}, {k: String, v: ""}, {k: String, v: "\\"}, {k: End}, eof}},
+	{`'a`, []scan{scanError(io.ErrUnexpectedEOF)}},
+	{`'a"`, []scan{scanError(io.ErrUnexpectedEOF)}},
+	{"'\n'", []scan{syntaxError('\n', expectStringNotControl)}},
+}
+
+func TestAllowSingleQuotes(t *testing.T) {
+	for _, tt := range singleQuoteTests {
+		for _, s := range []*Scanner{
+			NewScanner(iotest.OneByteReader(strings.NewReader(tt.s))),
+			NewScannerBytes([]byte(tt.s)),
+		} {
+			s.AllowSingleQuotes(true)
+			checkScans(t, s, tt.s, tt.scans)
+		}
+	}
+}
+
+func TestSingleQuotesNotAllowed(t *testing.T) {
+	for _, in := range []string{`'a'`, `{'a': 1}`, `["\'"]`} {
+		s := NewScanner(strings.NewReader(in))
+		for s.Scan() {
+		}
+		if _, ok := s.Err().(*SyntaxError); !ok {
+			t.Errorf("%s: got error %v, want syntax error", in, s.Err())
+		}
+	}
+}
+
+func TestSingleQuotesSkipRaw(t *testing.T) {
+	s := NewScanner(readerOnly{strings.NewReader(`['a"\'b']`)})
+	s.AllowSingleQuotes(true)
+	s.Scan()
+	s.Scan()
+	p, err := s.SkipRaw()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(p) != `'a"\'b'` {
+		t.Errorf("SkipRaw() = %s, want %s", p, `'a"\'b'`)
+	}
+}
+
+var nonFiniteTests = []struct {
+	s     string
+	scans []scan
+}{
+	{`NaN`, []scan{{k: Number, v: "NaN"}, eof}},
+	{`[Infinity,-Infinity, NaN]`, []scan{{k: Array}, {k: Number, v: "Infinity"}, {k: Number, v: "-Infinity"}, {k: Number, v: "NaN"}, {k: End}, eof}},
+	{`{"a":-Infinity}`, []scan{{k: Object}, {k: Number, n: "a", v: "-Infinity"}, {k: End}, eof}},
+	{`Nan`, []scan{syntaxError('n', expectNonFinite)}},
+	{`[Inf]`, []scan{{k: Array}, syntaxError(']', expectNonFinite)}},
+	{`-NaN`, []scan{syntaxError('N', expectNumberNeg)}},
+	{`Infinity1`, []scan{{k: Number, v: "Infinity"}, syntaxError('1', expectWhitespace)}},
+}
+
+func TestAllowNonFiniteNumbers(t *testing.T) {
+	for _, tt := range nonFiniteTests {
+		for _, s := range []*Scanner{
+			NewScanner(iotest.OneByteReader(strings.NewReader(tt.s))),
+			NewScannerBytes([]byte(tt.s)),
+		} {
+			s.AllowNonFiniteNumbers(true)
+			checkScans(t, s, tt.s, tt.scans)
+		}
+	}
+}
+
+func TestNonFiniteFloat64(t *testing.T) {
+	s := NewScannerBytes([]byte(`[NaN, Infinity, -Infinity]`))
+	s.AllowNonFiniteNumbers(true)
+	s.Scan()
+	var got []float64
+	level := s.NestingLevel()
+	for s.ScanAtLevel(level) {
+		f, err := s.Float64()
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, f)
+	}
+	if len(got) != 3 || !math.IsNaN(got[0]) || !math.IsInf(got[1], 1) || !math.IsInf(got[2], -1) {
+		t.Errorf("got %v, want [NaN +Inf -Inf]", got)
+	}
+	s = NewScanner(strings.NewReader(`NaN`))
+	if s.Scan() {
+		t.Error("NaN accepted without AllowNonFiniteNumbers")
+	}
+}
+
+var relaxedNumberTests = []struct {
+	s     string
+	scans []scan
+}{
+	{`[0x1F, 0X1f, -0x10, +0x0]`, []scan{{k: Array}, {k: Number, v: "31"}, {k: Number, v: "31"}, {k: Number, v: "-16"}, {k: Number, v: "0"}, {k: End}, eof}},
+	{`[+5, +0, +1.5e3, .5, -.5, +.25E-1]`, []scan{{k: Array}, {k: Number, v: "5"}, {k: Number, v: "0"}, {k: Number, v: "1.5e3"}, {k: Number, v: "0.5"}, {k: Number, v: "-0.5"}, {k: Number, v: "0.25E-1"}, {k: End}, eof}},
+	{`{"a": 0x10000000000000000}`, []scan{{k: Object}, {k: Number, n: "a", v: "18446744073709551616"}, {k: End}, eof}},
+	{`[10, -1.5, 0]`, []scan{{k: Array}, {k: Number, v: "10"}, {k: Number, v: "-1.5"}, {k: Number, v: "0"}, {k: End}, eof}},
+	{`0x`, []scan{scanError(io.ErrUnexpectedEOF)}},
+	{`0xg`, []scan{syntaxError('g', expectNumberHex)}},
+	{`[10x1]`, []scan{{k: Array}, {k: Number, v: "10"}, syntaxError('x', expectArrayCommaOrClose)}},
+	{`0x1.5`, []scan{{k: Number, v: "1"}, syntaxError('.', expectWhitespace)}},
+	{`+-1`, []scan{syntaxError('-', expectNumberPlus)}},
+	{`.e1`, []scan{syntaxError('e', expectNumberFrac)}},
+	{`+Infinity`, []scan{syntaxError('I', expectNumberPlus)}},
+}
+
+func TestAllowRelaxedNumbers(t *testing.T) {
+	for _, tt := range relaxedNumberTests {
+		for _, s := range []*Scanner{
+			NewScanner(iotest.OneByteReader(strings.NewReader(tt.s))),
+			NewScannerBytes([]byte(tt.s)),
+		} {
+			s.AllowRelaxedNumbers(true)
+			checkScans(t, s, tt.s, tt.scans)
+		}
+	}
+}
+
+func TestRelaxedNumbersNonFinite(t *testing.T) {
+	s := NewScannerBytes([]byte(`[+Infinity, -Infinity, +NaN]`))
+	s.AllowRelaxedNumbers(true)
+	s.AllowNonFiniteNumbers(true)
+	checkScans(t, s, "", []scan{{k: Array}, {k: Number, v: "Infinity"}, {k: Number, v: "-Infinity"}, syntaxError('N', expectNumberPlus)})
+}
+
+func TestRelaxedNumberConversions(t *testing.T) {
+	s := NewScannerBytes([]byte(`[0xff, +7, .5]`))
+	s.AllowRelaxedNumbers(true)
+	s.Scan()
+	s.Scan()
+	if i, err := s.Int64(); i != 255 || err != nil {
+		t.Errorf("Int64() = %d, %v, want 255", i, err)
+	}
+	s.Scan()
+	if u, err := s.Uint64(); u != 7 || err != nil {
+		t.Errorf("Uint64() = %d, %v, want 7", u, err)
+	}
+	s.Scan()
+	if f, err := s.Float64(); f != 0.5 || err != nil {
+		t.Errorf("Float64() = %v, %v, want 0.5", f, err)
+	}
+	for _, in := range []string{"0x1", "+1", ".5"} {
+		s := NewScanner(strings.NewReader(in))
+		for s.Scan() {
+		}
+		if _, ok := s.Err().(*SyntaxError); !ok {
+			t.Errorf("%s: got error %v, want syntax error", in, s.Err())
+		}
+	}
+}
+
+func TestRawStrings(t *testing.T) {
+	const doc = `{"ab": "x\nyé", "n": 1.5, "a\\b": ["\"q\""]}`
+	for _, s := range []*Scanner{
+		NewScanner(readerOnly{strings.NewReader(doc)}),
+		NewScannerBytes([]byte(doc)),
+	} {
+		s.SetRawStrings(true)
+		s.RejectDuplicateKeys(true)
+		s.TrackPath(true)
+		var got []string
+		for s.Scan() {
+			got = append(got, s.PathString()+"="+string(s.Name())+":"+string(s.Value()))
+		}
+		if err := s.Err(); err != nil {
+			t.Fatal(err)
+		}
+		want := []string{
+			"=:",
+			`/ab=ab:x\nyé`,
+			`/n=n:1.5`,
+			`/a\b=a\\b:`,
+			`/a\b/0=:\"q\"`,
+			`/a\b=:`,
+			"=:",
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("got  %q\nwant %q", got, want)
+		}
+	}
+}
+
+var strictUTF8Tests = []struct {
+	in  string
+	pos int // position of error or -1 if valid
+}{
+	{`"abc"`, -1},
+	{`"é€𝄞"`, -1},
+	{`"𝄞"`, -1},
+	{`"é\n"`, -1},
+	{`"a\ud834\udd1e"`, -1},
+	{"\"ab\xffc\"", 3},
+	{"\"\xe2\x82\"", 1},
+	{`"x\ud834"`, 2},
+	{`"\ud834x"`, 1},
+	{`"\ud834A"`, 1},
+	{`"\udd1e\ud834"`, 1},
+	{"{\"a\xff\": 1}", 3},
+}
+
+func TestStrictUTF8(t *testing.T) {
+	for _, tt := range strictUTF8Tests {
+		s := NewScannerBytes([]byte(tt.in))
+		s.StrictUTF8(true)
+		for s.Scan() {
+		}
+		err := s.Err()
+		if tt.pos < 0 {
+			if err != nil {
+				t.Errorf("%q: unexpected error %v", tt.in, err)
+			}
+			continue
+		}
+		if e, ok := err.(*SyntaxError); !ok || e.Pos != tt.pos {
+			t.Errorf("%q: got error %#v, want syntax error at %d", tt.in, err, tt.pos)
+		}
+	}
+}
+
+func TestKeyInterning(t *testing.T) {
+	data := []byte(`{"abcdefgh": 1}`)
+	s := NewScannerBytes(data)
+	for _, intern := range []bool{false, true} {
+		s.SetKeyInterning(intern)
+		var name string
+		allocs := testing.AllocsPerRun(10, func() {
+			s.ResetBytes(data)
+			s.Scan()
+			s.Scan()
+			name = s.NameString()
+		})
+		if name != "abcdefgh" {
+			t.Errorf("intern=%v: got name %q, want %q", intern, name, "abcdefgh")
+		}
+		if intern != (allocs == 0) {
+			t.Errorf("intern=%v: got %v allocs", intern, allocs)
+		}
+	}
+}
+
+var nameIsTests = []struct {
+	in        string
+	name      string
+	is, folds bool
+}{
+	{`{"abc": 1}`, "abc", true, true},
+	{`{"abc": 1}`, "ab", false, false},
+	{`{"abc": 1}`, "ABC", false, true},
+	{`{"a\u0062c": 1}`, "abc", true, true},
+	{`{"a\u0062c": 1}`, "ABC", false, true},
+	{`{"aBc": 1}`, "abc", false, true},
+	{`{"é": 1}`, "é", true, true},
+	{`{"É": 1}`, "é", false, true},
+	{`{"K": 1}`, "K", false, true},
+	{"{\"a\xff\": 1}", "a�", true, true},
+	{`{"a\"": 1}`, `a"`, true, true},
+}
+
+func TestNameIs(t *testing.T) {
+	for _, tt := range nameIsTests {
+		for _, s := range []*Scanner{
+			NewScannerBytes([]byte(tt.in)),
+			NewScanner(readerOnly{strings.NewReader(tt.in)}),
+		} {
+			s.Scan()
+			if s.NameIs(tt.name) {
+				t.Errorf("%s: NameIs(%q) = true for object", tt.in, tt.name)
+			}
+			s.Scan()
+			if is := s.NameIs(tt.name); is != tt.is {
+				t.Errorf("%s: NameIs(%q) = %v, want %v", tt.in, tt.name, is, tt.is)
+			}
+			if folds := s.NameEqualFold(tt.name); folds != tt.folds {
+				t.Errorf("%s: NameEqualFold(%q) = %v, want %v", tt.in, tt.name, folds, tt.folds)
+			}
+		}
+	}
+}
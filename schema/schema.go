@@ -0,0 +1,950 @@
+// Copyright 2014 Gary Burd. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package schema validates JSON documents against a JSON Schema.
+//
+// The package supports the following subset of JSON Schema draft 2020-12:
+//
+//  type, enum, const
+//  minimum, maximum, exclusiveMinimum, exclusiveMaximum, multipleOf
+//  minLength, maxLength, pattern
+//  items, prefixItems, contains, minItems, maxItems, uniqueItems
+//  properties, patternProperties, additionalProperties, required,
+//  minProperties, maxProperties
+//  allOf, anyOf, oneOf, not
+//  $ref to "#" and JSON Pointers in the schema, $defs
+//
+// Other keywords are ignored. Regular expressions use the syntax of the Go
+// regexp package.
+//
+// Validation reads the document from a json.Scanner and does not hold the
+// document in memory, except where a keyword requires the complete value.
+// The enum, const, uniqueItems, allOf, anyOf, oneOf and not keywords buffer
+// the value at their schema and contains buffers each array element.
+// Members that match more than one of properties and patternProperties are
+// also buffered.
+package schema
+
+import (
+	"errors"
+	"math/big"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/garyburd/json"
+)
+
+// Schema is a compiled JSON Schema.
+type Schema struct {
+	root *node
+}
+
+// Violation describes a value that does not satisfy the schema.
+type Violation struct {
+	Path    string // RFC 6901 JSON Pointer to the value
+	Message string
+}
+
+func (v Violation) String() string {
+	return v.Path + ": " + v.Message
+}
+
+// ValidationError is returned when a document does not satisfy the schema.
+type ValidationError struct {
+	Violations []Violation
+}
+
+func (e *ValidationError) Error() string {
+	s := e.Violations[0].String()
+	if n := len(e.Violations) - 1; n > 0 {
+		s += " (and " + strconv.Itoa(n) + " more)"
+	}
+	return s
+}
+
+type limit struct {
+	d    decimal
+	text string
+}
+
+type patternNode struct {
+	re *regexp.Regexp
+	n  *node
+}
+
+// node is a compiled schema.
+type node struct {
+	reject bool  // if true, the schema is false.
+	ref    *node // target of $ref without sibling keywords
+	buffer bool  // if true, the value is buffered for validation.
+
+	types []string
+	enum  [][]byte
+	cnst  bool // if true, enum holds the const value.
+
+	minimum, maximum, exclusiveMinimum, exclusiveMaximum, multipleOf *limit
+
+	minLength, maxLength int
+	pattern              *regexp.Regexp
+
+	items       *node
+	prefixItems []*node
+	contains    *node
+	minItems    int
+	maxItems    int
+	uniqueItems bool
+
+	properties    map[string]*node
+	patternProps  []patternNode
+	additional    *node
+	required      []string
+	minProperties int
+	maxProperties int
+
+	allOf, anyOf, oneOf []*node
+	not                 *node
+}
+
+// Compile compiles the JSON Schema in data.
+func Compile(data []byte) (*Schema, error) {
+	doc, err := json.ParseDocument(data)
+	if err != nil {
+		return nil, err
+	}
+	c := &compiler{root: doc.Root(), nodes: make(map[string]*node)}
+	root := c.compile(c.root, "")
+	if c.err == nil {
+		c.checkCycles()
+	}
+	if c.err != nil {
+		return nil, c.err
+	}
+	return &Schema{root: root}, nil
+}
+
+type compiler struct {
+	root  json.Value
+	nodes map[string]*node // compiled schemas by JSON Pointer
+	err   error
+}
+
+func (c *compiler) fail(ptr, msg string) {
+	if c.err == nil {
+		c.err = errors.New("invalid schema at #" + ptr + ": " + msg)
+	}
+}
+
+var typeNames = map[string]bool{
+	"null": true, "boolean": true, "object": true, "array": true,
+	"number": true, "integer": true, "string": true,
+}
+
+func (c *compiler) compile(v json.Value, ptr string) *node {
+	if n := c.nodes[ptr]; n != nil {
+		return n
+	}
+	n := &node{minLength: -1, maxLength: -1, minItems: -1, maxItems: -1, minProperties: -1, maxProperties: -1}
+	c.nodes[ptr] = n
+
+	switch v.Kind() {
+	case json.Bool:
+		n.reject = !v.Bool()
+		return n
+	case json.Object:
+	default:
+		c.fail(ptr, "schema must be an object or boolean")
+		return n
+	}
+
+	var ref *node
+	other := false
+	v.Members(func(name string, v json.Value) bool {
+		p := ptr + "/" + escape(name)
+		switch name {
+		case "$ref":
+			ref = c.ref(v.String(), p)
+			return true
+		case "type":
+			switch v.Kind() {
+			case json.String:
+				n.types = []string{v.String()}
+			case json.Array:
+				for i := 0; i < v.Len(); i++ {
+					n.types = append(n.types, v.Index(i).String())
+				}
+			}
+			for _, t := range n.types {
+				if !typeNames[t] {
+					c.fail(p, "unknown type "+strconv.Quote(t))
+				}
+			}
+		case "enum":
+			if v.Kind() != json.Array {
+				c.fail(p, "enum must be an array")
+			}
+			for i := 0; i < v.Len(); i++ {
+				n.enum = append(n.enum, v.Index(i).Raw())
+			}
+		case "const":
+			n.enum = [][]byte{v.Raw()}
+			n.cnst = true
+		case "minimum":
+			n.minimum = c.limit(v, p)
+		case "maximum":
+			n.maximum = c.limit(v, p)
+		case "exclusiveMinimum":
+			n.exclusiveMinimum = c.limit(v, p)
+		case "exclusiveMaximum":
+			n.exclusiveMaximum = c.limit(v, p)
+		case "multipleOf":
+			n.multipleOf = c.limit(v, p)
+			if n.multipleOf != nil && n.multipleOf.d.sign <= 0 {
+				c.fail(p, "multipleOf must be greater than zero")
+			}
+		case "minLength":
+			n.minLength = c.count(v, p)
+		case "maxLength":
+			n.maxLength = c.count(v, p)
+		case "pattern":
+			n.pattern = c.regexp(v, p)
+		case "items":
+			if v.Kind() == json.Array {
+				// Draft 2019-09 and earlier array form.
+				n.prefixItems = c.list(v, p)
+			} else {
+				n.items = c.compile(v, p)
+			}
+		case "prefixItems":
+			n.prefixItems = c.list(v, p)
+		case "contains":
+			n.contains = c.compile(v, p)
+		case "minItems":
+			n.minItems = c.count(v, p)
+		case "maxItems":
+			n.maxItems = c.count(v, p)
+		case "uniqueItems":
+			n.uniqueItems = v.Bool()
+		case "properties":
+			n.properties = make(map[string]*node)
+			v.Members(func(name string, v json.Value) bool {
+				n.properties[name] = c.compile(v, p+"/"+escape(name))
+				return true
+			})
+		case "patternProperties":
+			v.Members(func(name string, v json.Value) bool {
+				pp := p + "/" + escape(name)
+				re, err := regexp.Compile(name)
+				if err != nil {
+					c.fail(pp, err.Error())
+					return true
+				}
+				n.patternProps = append(n.patternProps, patternNode{re, c.compile(v, pp)})
+				return true
+			})
+		case "additionalProperties":
+			n.additional = c.compile(v, p)
+		case "required":
+			for i := 0; i < v.Len(); i++ {
+				n.required = append(n.required, v.Index(i).String())
+			}
+		case "minProperties":
+			n.minProperties = c.count(v, p)
+		case "maxProperties":
+			n.maxProperties = c.count(v, p)
+		case "allOf":
+			n.allOf = c.list(v, p)
+		case "anyOf":
+			n.anyOf = c.list(v, p)
+		case "oneOf":
+			n.oneOf = c.list(v, p)
+		case "not":
+			n.not = c.compile(v, p)
+		default:
+			return true
+		}
+		other = true
+		return true
+	})
+
+	if ref != nil {
+		if other {
+			n.allOf = append(n.allOf, ref)
+		} else {
+			n.ref = ref
+		}
+	}
+	n.buffer = len(n.enum) > 0 || n.uniqueItems || len(n.allOf) > 0 ||
+		len(n.anyOf) > 0 || len(n.oneOf) > 0 || n.not != nil
+	return n
+}
+
+// ref compiles the schema referenced by the $ref value s.
+func (c *compiler) ref(s, ptr string) *node {
+	if !strings.HasPrefix(s, "#") {
+		c.fail(ptr, "unsupported $ref "+strconv.Quote(s))
+		return &node{}
+	}
+	target := strings.TrimPrefix(s, "#")
+	if target != "" && !strings.HasPrefix(target, "/") {
+		c.fail(ptr, "unsupported $ref "+strconv.Quote(s))
+		return &node{}
+	}
+	v := c.root
+	if target != "" {
+		for _, tok := range strings.Split(target[1:], "/") {
+			tok = unescaper.Replace(tok)
+			if v.Kind() == json.Array {
+				i, err := strconv.Atoi(tok)
+				if err != nil {
+					v = json.Value{}
+					break
+				}
+				v = v.Index(i)
+			} else {
+				v = v.Get(tok)
+			}
+		}
+	}
+	if !v.Exists() {
+		c.fail(ptr, "unresolved $ref "+strconv.Quote(s))
+		return &node{}
+	}
+	return c.compile(v, target)
+}
+
+// checkCycles reports a cycle of $ref, allOf, anyOf, oneOf and not keywords.
+// These keywords apply a schema to the same value, so validation of a cycle
+// would not terminate.
+func (c *compiler) checkCycles() {
+	ptrs := make([]string, 0, len(c.nodes))
+	for p := range c.nodes {
+		ptrs = append(ptrs, p)
+	}
+	sort.Strings(ptrs)
+	state := make(map[*node]int)
+	for _, p := range ptrs {
+		if cyclic(c.nodes[p], state) {
+			c.fail(p, "circular $ref")
+			return
+		}
+	}
+}
+
+const (
+	visiting = iota + 1
+	visited
+)
+
+func cyclic(n *node, state map[*node]int) bool {
+	switch state[n] {
+	case visiting:
+		return true
+	case visited:
+		return false
+	}
+	state[n] = visiting
+	next := []*node{n.ref, n.not}
+	next = append(next, n.allOf...)
+	next = append(next, n.anyOf...)
+	next = append(next, n.oneOf...)
+	for _, m := range next {
+		if m != nil && cyclic(m, state) {
+			return true
+		}
+	}
+	state[n] = visited
+	return false
+}
+
+func (c *compiler) list(v json.Value, ptr string) []*node {
+	if v.Kind() != json.Array || v.Len() == 0 {
+		c.fail(ptr, "value must be a non-empty array")
+		return nil
+	}
+	var nodes []*node
+	for i := 0; i < v.Len(); i++ {
+		nodes = append(nodes, c.compile(v.Index(i), ptr+"/"+strconv.Itoa(i)))
+	}
+	return nodes
+}
+
+func (c *compiler) limit(v json.Value, ptr string) *limit {
+	if v.Kind() != json.Number {
+		c.fail(ptr, "value must be a number")
+		return nil
+	}
+	text := string(v.Number())
+	d, ok := parseDecimal(text)
+	if !ok {
+		c.fail(ptr, "invalid number "+text)
+		return nil
+	}
+	return &limit{d, text}
+}
+
+func (c *compiler) count(v json.Value, ptr string) int {
+	n, err := v.Number().Int()
+	if v.Kind() != json.Number || err != nil || n < 0 {
+		c.fail(ptr, "value must be a non-negative integer")
+		return -1
+	}
+	return n
+}
+
+func (c *compiler) regexp(v json.Value, ptr string) *regexp.Regexp {
+	if v.Kind() != json.String {
+		c.fail(ptr, "value must be a string")
+		return nil
+	}
+	re, err := regexp.Compile(v.String())
+	if err != nil {
+		c.fail(ptr, err.Error())
+		return nil
+	}
+	return re
+}
+
+var (
+	escaper   = strings.NewReplacer("~", "~0", "/", "~1")
+	unescaper = strings.NewReplacer("~1", "/", "~0", "~")
+)
+
+func escape(name string) string {
+	if strings.ContainsAny(name, "~/") {
+		return escaper.Replace(name)
+	}
+	return name
+}
+
+// Validate validates the scanner's current value against the schema. On
+// return, the scanner is positioned at the last element of the value.
+// Validate returns a *ValidationError if the value does not satisfy the
+// schema or the scanner's error if the value cannot be scanned.
+func (sc *Schema) Validate(s *json.Scanner) error {
+	v := &validator{s: s}
+	if err := v.validate(sc.root); err != nil {
+		return err
+	}
+	if len(v.violations) > 0 {
+		return &ValidationError{v.violations}
+	}
+	return nil
+}
+
+// ValidateBytes validates the JSON document in data against the schema.
+func (sc *Schema) ValidateBytes(data []byte) error {
+	s := json.NewScannerBytes(data)
+	if !s.Scan() {
+		if err := s.Err(); err != nil {
+			return err
+		}
+		return errors.New("unexpected end of JSON input")
+	}
+	if err := sc.Validate(s); err != nil {
+		return err
+	}
+	s.Scan()
+	return s.Err()
+}
+
+type validator struct {
+	s          *json.Scanner
+	path       []string // escaped reference tokens of the path to the value
+	violations []Violation
+}
+
+func (v *validator) report(msg string) {
+	var p string
+	if len(v.path) > 0 {
+		p = "/" + strings.Join(v.path, "/")
+	}
+	v.violations = append(v.violations, Violation{p, msg})
+}
+
+func (v *validator) validate(n *node) error {
+	for n.ref != nil {
+		n = n.ref
+	}
+	switch {
+	case n.reject:
+		v.report("value is not allowed")
+		return v.s.Skip()
+	case n.buffer:
+		raw, err := v.s.SkipRaw()
+		if err != nil {
+			return err
+		}
+		return v.validateRaw(n, raw)
+	default:
+		return v.validateValue(n)
+	}
+}
+
+// run validates raw against n with a new scanner and appends the violations
+// to violations.
+func (v *validator) run(n *node, raw []byte, violations *[]Violation, self bool) error {
+	s := json.NewScannerBytes(raw)
+	if !s.Scan() {
+		return s.Err()
+	}
+	w := &validator{s: s, path: v.path[:len(v.path):len(v.path)]}
+	var err error
+	if self {
+		err = w.validateValue(n)
+	} else {
+		err = w.validate(n)
+	}
+	*violations = append(*violations, w.violations...)
+	return err
+}
+
+// matches returns true if raw satisfies n.
+func (v *validator) matches(n *node, raw []byte) (bool, error) {
+	var violations []Violation
+	err := v.run(n, raw, &violations, false)
+	return len(violations) == 0, err
+}
+
+func (v *validator) validateRaw(n *node, raw []byte) error {
+	if len(n.enum) > 0 {
+		found := false
+		for _, e := range n.enum {
+			eq, err := json.Equal(raw, e)
+			if err != nil {
+				return err
+			}
+			if eq {
+				found = true
+				break
+			}
+		}
+		switch {
+		case found:
+		case n.cnst:
+			v.report("value must be " + string(n.enum[0]))
+		default:
+			v.report("value must be one of the enum values")
+		}
+	}
+
+	if n.uniqueItems {
+		if err := v.unique(raw); err != nil {
+			return err
+		}
+	}
+
+	for _, sub := range n.allOf {
+		if err := v.run(sub, raw, &v.violations, false); err != nil {
+			return err
+		}
+	}
+
+	if len(n.anyOf) > 0 {
+		found := false
+		for _, sub := range n.anyOf {
+			ok, err := v.matches(sub, raw)
+			if err != nil {
+				return err
+			}
+			if ok {
+				found = true
+				break
+			}
+		}
+		if !found {
+			v.report("value does not match any schema in anyOf")
+		}
+	}
+
+	if len(n.oneOf) > 0 {
+		count := 0
+		for _, sub := range n.oneOf {
+			ok, err := v.matches(sub, raw)
+			if err != nil {
+				return err
+			}
+			if ok {
+				count++
+			}
+		}
+		if count != 1 {
+			v.report("value matches " + strconv.Itoa(count) + " schemas in oneOf, want 1")
+		}
+	}
+
+	if n.not != nil {
+		ok, err := v.matches(n.not, raw)
+		if err != nil {
+			return err
+		}
+		if ok {
+			v.report("value must not match schema in not")
+		}
+	}
+
+	return v.run(n, raw, &v.violations, true)
+}
+
+func (v *validator) unique(raw []byte) error {
+	doc, err := json.ParseDocument(raw)
+	if err != nil {
+		return err
+	}
+	a := doc.Root()
+	if a.Kind() != json.Array {
+		return nil
+	}
+	for i := 0; i < a.Len(); i++ {
+		for j := i + 1; j < a.Len(); j++ {
+			eq, err := json.Equal(a.Index(i).Raw(), a.Index(j).Raw())
+			if err != nil {
+				return err
+			}
+			if eq {
+				v.report("array items " + strconv.Itoa(i) + " and " + strconv.Itoa(j) + " are equal")
+				return nil
+			}
+		}
+	}
+	return nil
+}
+
+func typeName(k json.Kind) string {
+	switch k {
+	case json.Null:
+		return "null"
+	case json.Bool:
+		return "boolean"
+	case json.String:
+		return "string"
+	case json.Number:
+		return "number"
+	case json.Array:
+		return "array"
+	default:
+		return "object"
+	}
+}
+
+func isInteger(text string) bool {
+	d, ok := parseDecimal(text)
+	return ok && d.isInt()
+}
+
+// decimal is a number with value sign × 0.digits × 10^exp. The digits have no
+// leading or trailing zeros. The value zero has sign 0 and no digits.
+//
+// Decimals are compared without converting to big.Rat so that numbers like
+// 1e999999999 are handled exactly and without allocating huge integers.
+type decimal struct {
+	sign   int
+	digits string
+	exp    int64
+}
+
+// maxExp bounds the exponent of a decimal. Larger exponents are clamped. The
+// bound exceeds the length of any input, so clamping does not change the
+// result of comparisons.
+const maxExp = 1 << 40
+
+func isDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func parseDecimal(text string) (decimal, bool) {
+	s := text
+	sign := 1
+	if strings.HasPrefix(s, "-") {
+		sign = -1
+		s = s[1:]
+	}
+	var exp int64
+	if i := strings.IndexAny(s, "eE"); i >= 0 {
+		e := strings.TrimPrefix(s[i+1:], "+")
+		s = s[:i]
+		neg := strings.HasPrefix(e, "-")
+		e = strings.TrimLeft(strings.TrimPrefix(e, "-"), "0")
+		if !isDigits(e) {
+			return decimal{}, false
+		}
+		if len(e) > 13 {
+			exp = maxExp
+		} else if e != "" {
+			exp, _ = strconv.ParseInt(e, 10, 64)
+			if exp > maxExp {
+				exp = maxExp
+			}
+		}
+		if neg {
+			exp = -exp
+		}
+	}
+	intPart, frac := s, ""
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		intPart, frac = s[:i], s[i+1:]
+		if frac == "" {
+			return decimal{}, false
+		}
+	}
+	if intPart == "" || !isDigits(intPart) || !isDigits(frac) {
+		return decimal{}, false
+	}
+	digits := strings.TrimLeft(intPart+frac, "0")
+	exp += int64(len(intPart) - (len(intPart) + len(frac) - len(digits)))
+	digits = strings.TrimRight(digits, "0")
+	if digits == "" {
+		return decimal{}, true
+	}
+	return decimal{sign: sign, digits: digits, exp: exp}, true
+}
+
+// cmp returns -1, 0 or +1 depending on whether d is less than, equal to or
+// greater than x.
+func (d decimal) cmp(x decimal) int {
+	switch {
+	case d.sign < x.sign:
+		return -1
+	case d.sign > x.sign:
+		return 1
+	case d.sign == 0:
+		return 0
+	case d.exp < x.exp:
+		return -d.sign
+	case d.exp > x.exp:
+		return d.sign
+	}
+	return strings.Compare(d.digits, x.digits) * d.sign
+}
+
+func (d decimal) isInt() bool {
+	return d.sign == 0 || d.exp >= int64(len(d.digits))
+}
+
+// multipleOf returns whether d is an integer multiple of the positive decimal
+// m.
+func (d decimal) multipleOf(m decimal) bool {
+	if d.sign == 0 {
+		return true
+	}
+	// d = dd × 10^(k + e) and m = dm × 10^e for integers dd and dm.
+	k := (d.exp - int64(len(d.digits))) - (m.exp - int64(len(m.digits)))
+	dd, _ := new(big.Int).SetString(d.digits, 10)
+	dm, _ := new(big.Int).SetString(m.digits, 10)
+	if k < 0 {
+		// dd < 10^len(dd) <= 10^-k × dm.
+		if -k >= int64(len(d.digits)) {
+			return false
+		}
+		dm.Mul(dm, new(big.Int).Exp(big.NewInt(10), big.NewInt(-k), nil))
+	} else {
+		// Powers of ten beyond the number of factors of 2 and 5 in dm do not
+		// change the result.
+		if max := int64(4 * len(m.digits)); k > max {
+			k = max
+		}
+		dd.Mul(dd, new(big.Int).Exp(big.NewInt(10), big.NewInt(k), nil))
+	}
+	return new(big.Int).Rem(dd, dm).Sign() == 0
+}
+
+func (v *validator) validateValue(n *node) error {
+	s := v.s
+	if n.types != nil {
+		found := false
+		for _, t := range n.types {
+			if t == typeName(s.Kind()) || (t == "integer" && s.Kind() == json.Number && isInteger(string(s.Value()))) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			v.report("expected " + strings.Join(n.types, " or ") + ", found " + typeName(s.Kind()))
+			return s.Skip()
+		}
+	}
+	switch s.Kind() {
+	case json.Number:
+		v.number(n, string(s.Value()))
+	case json.String:
+		v.string(n, s.Value())
+	case json.Array:
+		return v.array(n)
+	case json.Object:
+		return v.object(n)
+	}
+	return nil
+}
+
+func (v *validator) number(n *node, text string) {
+	if n.minimum == nil && n.maximum == nil && n.exclusiveMinimum == nil &&
+		n.exclusiveMaximum == nil && n.multipleOf == nil {
+		return
+	}
+	d, ok := parseDecimal(text)
+	if !ok {
+		v.report("invalid number " + text)
+		return
+	}
+	if l := n.minimum; l != nil && d.cmp(l.d) < 0 {
+		v.report("value " + text + " is less than minimum " + l.text)
+	}
+	if l := n.maximum; l != nil && d.cmp(l.d) > 0 {
+		v.report("value " + text + " is greater than maximum " + l.text)
+	}
+	if l := n.exclusiveMinimum; l != nil && d.cmp(l.d) <= 0 {
+		v.report("value " + text + " is not greater than exclusive minimum " + l.text)
+	}
+	if l := n.exclusiveMaximum; l != nil && d.cmp(l.d) >= 0 {
+		v.report("value " + text + " is not less than exclusive maximum " + l.text)
+	}
+	if l := n.multipleOf; l != nil && !d.multipleOf(l.d) {
+		v.report("value " + text + " is not a multiple of " + l.text)
+	}
+}
+
+func (v *validator) string(n *node, p []byte) {
+	if n.minLength >= 0 || n.maxLength >= 0 {
+		count := utf8.RuneCount(p)
+		if n.minLength >= 0 && count < n.minLength {
+			v.report("length " + strconv.Itoa(count) + " is less than minLength " + strconv.Itoa(n.minLength))
+		}
+		if n.maxLength >= 0 && count > n.maxLength {
+			v.report("length " + strconv.Itoa(count) + " is greater than maxLength " + strconv.Itoa(n.maxLength))
+		}
+	}
+	if n.pattern != nil && !n.pattern.Match(p) {
+		v.report("value does not match pattern " + strconv.Quote(n.pattern.String()))
+	}
+}
+
+func (v *validator) array(n *node) error {
+	s := v.s
+	level := s.NestingLevel()
+	i := 0
+	found := false
+	for s.ScanAtLevel(level) {
+		v.path = append(v.path, strconv.Itoa(i))
+		sub := n.items
+		if i < len(n.prefixItems) {
+			sub = n.prefixItems[i]
+		}
+		var err error
+		switch {
+		case n.contains != nil:
+			var raw []byte
+			if raw, err = s.SkipRaw(); err != nil {
+				return err
+			}
+			if !found {
+				if found, err = v.matches(n.contains, raw); err != nil {
+					return err
+				}
+			}
+			if sub != nil {
+				err = v.run(sub, raw, &v.violations, false)
+			}
+		case sub != nil:
+			err = v.validate(sub)
+		default:
+			err = s.Skip()
+		}
+		if err != nil {
+			return err
+		}
+		v.path = v.path[:len(v.path)-1]
+		i++
+	}
+	if err := s.Err(); err != nil {
+		return err
+	}
+	if n.minItems >= 0 && i < n.minItems {
+		v.report("array has " + strconv.Itoa(i) + " items, less than minItems " + strconv.Itoa(n.minItems))
+	}
+	if n.maxItems >= 0 && i > n.maxItems {
+		v.report("array has " + strconv.Itoa(i) + " items, more than maxItems " + strconv.Itoa(n.maxItems))
+	}
+	if n.contains != nil && !found {
+		v.report("array does not contain an item matching contains")
+	}
+	return nil
+}
+
+func (v *validator) object(n *node) error {
+	s := v.s
+	level := s.NestingLevel()
+	count := 0
+	var seen map[string]bool
+	if len(n.required) > 0 {
+		seen = make(map[string]bool, len(n.required))
+	}
+	var subs []*node
+	for s.ScanAtLevel(level) {
+		count++
+		name := s.NameString()
+		if seen != nil {
+			seen[name] = true
+		}
+		subs = subs[:0]
+		if sub := n.properties[name]; sub != nil {
+			subs = append(subs, sub)
+		}
+		for _, pp := range n.patternProps {
+			if pp.re.MatchString(name) {
+				subs = append(subs, pp.n)
+			}
+		}
+		if len(subs) == 0 && n.additional != nil {
+			subs = append(subs, n.additional)
+		}
+
+		v.path = append(v.path, escape(name))
+		var err error
+		switch {
+		case len(subs) == 0:
+			err = s.Skip()
+		case len(subs) == 1 && subs[0] == n.additional && n.additional.reject:
+			v.report("property is not allowed")
+			err = s.Skip()
+		case len(subs) == 1:
+			err = v.validate(subs[0])
+		default:
+			var raw []byte
+			if raw, err = s.SkipRaw(); err != nil {
+				return err
+			}
+			for _, sub := range subs {
+				if err = v.run(sub, raw, &v.violations, false); err != nil {
+					break
+				}
+			}
+		}
+		if err != nil {
+			return err
+		}
+		v.path = v.path[:len(v.path)-1]
+	}
+	if err := s.Err(); err != nil {
+		return err
+	}
+	for _, name := range n.required {
+		if !seen[name] {
+			v.report("missing required property " + strconv.Quote(name))
+		}
+	}
+	if n.minProperties >= 0 && count < n.minProperties {
+		v.report("object has " + strconv.Itoa(count) + " properties, less than minProperties " + strconv.Itoa(n.minProperties))
+	}
+	if n.maxProperties >= 0 && count > n.maxProperties {
+		v.report("object has " + strconv.Itoa(count) + " properties, more than maxProperties " + strconv.Itoa(n.maxProperties))
+	}
+	return nil
+}
@@ -0,0 +1,195 @@
+// Copyright 2014 Gary Burd. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package schema
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/garyburd/json"
+)
+
+const testSchema = `{
+	"type": "object",
+	"properties": {
+		"name": {"type": "string", "minLength": 1, "maxLength": 5},
+		"age": {"type": "integer", "minimum": 0, "exclusiveMaximum": 150},
+		"price": {"type": "number", "multipleOf": 0.01},
+		"tags": {"type": "array", "items": {"type": "string"}, "uniqueItems": true, "maxItems": 3},
+		"kind": {"enum": ["a", "b", {"c": 1}]},
+		"version": {"const": 2},
+		"point": {"type": "array", "prefixItems": [{"type": "number"}, {"type": "number"}], "items": false},
+		"child": {"$ref": "#"},
+		"code": {"type": "string", "pattern": "^[A-Z]{3}$"},
+		"id": {"anyOf": [{"type": "integer"}, {"type": "string", "pattern": "^x"}]},
+		"one": {"oneOf": [{"minimum": 0}, {"maximum": 10}]},
+		"not": {"not": {"type": "null"}},
+		"list": {"contains": {"$ref": "#/$defs/big"}},
+		"extra": {"type": "object", "additionalProperties": false, "properties": {"ok": true}}
+	},
+	"patternProperties": {"^x-": {"type": "string"}},
+	"required": ["name"],
+	"$defs": {"big": {"type": "number", "minimum": 100}}
+}`
+
+var validateTests = []struct {
+	doc   string
+	paths []string // paths of violations
+}{
+	{`{"name": "bob"}`, nil},
+	{`{}`, []string{""}},
+	{`[]`, []string{""}},
+	{`{"name": ""}`, []string{"/name"}},
+	{`{"name": "ééééé"}`, nil},
+	{`{"name": "éééééé"}`, []string{"/name"}},
+	{`{"name": "a", "age": 1.0}`, nil},
+	{`{"name": "a", "age": 1.5}`, []string{"/age"}},
+	{`{"name": "a", "age": -1}`, []string{"/age"}},
+	{`{"name": "a", "age": 150}`, []string{"/age"}},
+	{`{"name": "a", "price": 1.23}`, nil},
+	{`{"name": "a", "price": 1.234}`, []string{"/price"}},
+	{`{"name": "a", "tags": ["x", "y"]}`, nil},
+	{`{"name": "a", "tags": ["x", 1, "x", "y"]}`, []string{"/tags", "/tags/1", "/tags"}},
+	{`{"name": "a", "kind": {"c": 1.0}}`, nil},
+	{`{"name": "a", "kind": "d"}`, []string{"/kind"}},
+	{`{"name": "a", "version": 2e0}`, nil},
+	{`{"name": "a", "version": 3}`, []string{"/version"}},
+	{`{"name": "a", "point": [1, 2]}`, nil},
+	{`{"name": "a", "point": [1, "2", 3]}`, []string{"/point/1", "/point/2"}},
+	{`{"name": "a", "child": {"name": "b", "child": {"age": "x"}}}`, []string{"/child/child/age", "/child/child"}},
+	{`{"name": "a", "code": "ABC"}`, nil},
+	{`{"name": "a", "code": "ABCD"}`, []string{"/code"}},
+	{`{"name": "a", "id": 1}`, nil},
+	{`{"name": "a", "id": "xyz"}`, nil},
+	{`{"name": "a", "id": "abc"}`, []string{"/id"}},
+	{`{"name": "a", "one": 20}`, nil},
+	{`{"name": "a", "one": 5}`, []string{"/one"}},
+	{`{"name": "a", "not": null}`, []string{"/not"}},
+	{`{"name": "a", "list": [1, 200]}`, nil},
+	{`{"name": "a", "list": [1, 2]}`, []string{"/list"}},
+	{`{"name": "a", "extra": {"ok": 1, "a/b": 2}}`, []string{"/extra/a~1b"}},
+	{`{"name": "a", "x-y": "z"}`, nil},
+	{`{"name": "a", "x-y": 1}`, []string{"/x-y"}},
+}
+
+func TestValidate(t *testing.T) {
+	sc, err := Compile([]byte(testSchema))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range validateTests {
+		// Validate from a reader to exercise streaming.
+		s := json.NewScanner(strings.NewReader(tt.doc))
+		if !s.Scan() {
+			t.Fatalf("%s: %v", tt.doc, s.Err())
+		}
+		err := sc.Validate(s)
+		var paths []string
+		if err != nil {
+			e, ok := err.(*ValidationError)
+			if !ok {
+				t.Errorf("%s: unexpected error %v", tt.doc, err)
+				continue
+			}
+			for _, v := range e.Violations {
+				paths = append(paths, v.Path)
+			}
+		}
+		if !reflect.DeepEqual(paths, tt.paths) {
+			t.Errorf("%s: got violations %v, want paths %q", tt.doc, err, tt.paths)
+		}
+		if s.Scan() || s.Err() != nil {
+			t.Errorf("%s: scanner not at end of document, err %v", tt.doc, s.Err())
+		}
+	}
+}
+
+var compileErrorTests = []string{
+	`1`,
+	`{"type": "str"}`,
+	`{"minimum": "1"}`,
+	`{"multipleOf": 0}`,
+	`{"multipleOf": -0.0e99999999}`,
+	`{"minLength": -1}`,
+	`{"pattern": "("}`,
+	`{"$ref": "#/$defs/missing"}`,
+	`{"$ref": "http://example.com/schema"}`,
+	`{"properties": {"a": {"allOf": []}}}`,
+	`{"$ref": "#"}`,
+	`{"$defs": {"a": {"$ref": "#/$defs/b"}, "b": {"$ref": "#/$defs/a"}}, "$ref": "#/$defs/a"}`,
+	`{"$defs": {"a": {"not": {"$ref": "#/$defs/b"}}, "b": {"anyOf": [{"$ref": "#/$defs/a"}]}}, "properties": {"x": {"$ref": "#/$defs/a"}}}`,
+	`{"type": "object", "$ref": "#"}`,
+}
+
+func TestCompileError(t *testing.T) {
+	for _, s := range compileErrorTests {
+		if _, err := Compile([]byte(s)); err == nil {
+			t.Errorf("%s: no error", s)
+		}
+	}
+}
+
+func TestValidateBytes(t *testing.T) {
+	sc, err := Compile([]byte(`{"type": "array", "items": {"type": "integer"}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sc.ValidateBytes([]byte(`[1, 2]`)); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+	err = sc.ValidateBytes([]byte(`[1, "a"]`))
+	if err == nil || err.Error() != `/1: expected integer, found string` {
+		t.Errorf("got error %v", err)
+	}
+	if _, ok := sc.ValidateBytes([]byte(`[1, x]`)).(*json.SyntaxError); !ok {
+		t.Errorf("syntax error not returned")
+	}
+}
+
+var numberTests = []struct {
+	schema string
+	doc    string
+	valid  bool
+}{
+	{`{"maximum": 100}`, `1e9999999`, false},
+	{`{"maximum": 100}`, `1e999999999999999999999`, false},
+	{`{"maximum": 100}`, `-1e9999999`, true},
+	{`{"minimum": 0}`, `1e-9999999`, true},
+	{`{"minimum": 0}`, `-1e-9999999`, false},
+	{`{"minimum": 0}`, `-0.0e5`, true},
+	{`{"maximum": 1.5}`, `15e-1`, true},
+	{`{"maximum": 1.5}`, `150000000000000000001e-20`, false},
+	{`{"exclusiveMaximum": 1e999999}`, `1e999999`, false},
+	{`{"exclusiveMinimum": -1e999999}`, `-9.99e999998`, true},
+	{`{"type": "integer"}`, `1e400`, true},
+	{`{"type": "integer"}`, `1e999999999`, true},
+	{`{"type": "integer"}`, `1.5e-999999`, false},
+	{`{"type": "integer"}`, `12.5e1`, true},
+	{`{"type": "integer"}`, `0.0`, true},
+	{`{"multipleOf": 7}`, `7e999999`, true},
+	{`{"multipleOf": 7}`, `1e999999`, false},
+	{`{"multipleOf": 0.01}`, `1e-999999`, false},
+	{`{"multipleOf": 0.01}`, `12.30`, true},
+	{`{"multipleOf": 1e-999999}`, `3e-999998`, true},
+	{`{"multipleOf": 2.5e-999999}`, `1e-999998`, true},
+	{`{"multipleOf": 2.5e-999999}`, `1e-999999`, false},
+	{`{"multipleOf": 40}`, `1e999999`, true},
+	{`{"multipleOf": 0.3}`, `0`, true},
+}
+
+func TestNumber(t *testing.T) {
+	for _, tt := range numberTests {
+		sc, err := Compile([]byte(tt.schema))
+		if err != nil {
+			t.Errorf("%s: %v", tt.schema, err)
+			continue
+		}
+		err = sc.ValidateBytes([]byte(tt.doc))
+		if valid := err == nil; valid != tt.valid {
+			t.Errorf("%s %s: got error %v, want valid %v", tt.schema, tt.doc, err, tt.valid)
+		}
+	}
+}
@@ -0,0 +1,165 @@
+// Copyright 2014 Gary Burd. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package sse writes JSON values as server-sent events:
+//
+//  func handler(w http.ResponseWriter, r *http.Request) {
+//      e := sse.NewResponseEncoder(w)
+//      for update := range updates {
+//          if err := e.EncodeEvent("update", update.ID, update); err != nil {
+//              return
+//          }
+//      }
+//  }
+//
+// Each event is written as a data field with the encoded JSON value followed
+// by a blank line. The event is written to the output with a single call to
+// Write. If the output has a Flush method, such as http.Flusher, then the
+// method is called after each event.
+package sse
+
+import (
+	"bytes"
+	stdjson "encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/garyburd/json"
+)
+
+// Encoder writes events to an event stream.
+type Encoder struct {
+	w         io.Writer
+	flushHook func()
+	jw        *json.Writer
+	data      bytes.Buffer // encoded JSON value
+	buf       bytes.Buffer // event
+}
+
+// NewEncoder returns an encoder that writes to w.
+func NewEncoder(w io.Writer) *Encoder {
+	e := &Encoder{w: w}
+	e.jw = json.NewWriter(&e.data)
+	if f, ok := w.(interface {
+		Flush()
+	}); ok {
+		e.flushHook = f.Flush
+	}
+	return e
+}
+
+// NewResponseEncoder sets the Content-Type and Cache-Control headers for an
+// event stream and returns an encoder that writes to w.
+func NewResponseEncoder(w http.ResponseWriter) *Encoder {
+	h := w.Header()
+	h.Set("Content-Type", "text/event-stream")
+	h.Set("Cache-Control", "no-cache")
+	return NewEncoder(w)
+}
+
+// Writer returns the writer used to encode values. Use the writer to set
+// options such as indentation. The options apply to values written with
+// EncodeEventFunc and by json.Marshaler values. Indented values are written
+// as multiple data lines.
+func (e *Encoder) Writer() *json.Writer {
+	return e.jw
+}
+
+// Encode writes v as an event without an event type or id. The value is
+// encoded with its EncodeJSON method if v implements json.Marshaler and with
+// the encoding/json package otherwise.
+func (e *Encoder) Encode(v interface{}) error {
+	return e.EncodeEvent("", "", v)
+}
+
+// EncodeEvent writes v as an event with the given event type and id. The
+// event and id fields are omitted when empty.
+func (e *Encoder) EncodeEvent(event, id string, v interface{}) error {
+	return e.EncodeEventFunc(event, id, func(w *json.Writer) error {
+		if m, ok := v.(json.Marshaler); ok {
+			return m.EncodeJSON(w)
+		}
+		p, err := stdjson.Marshal(v)
+		if err != nil {
+			return err
+		}
+		return w.Raw(p)
+	})
+}
+
+// EncodeEventFunc writes an event with the given event type and id. The
+// function fn must write exactly one value.
+func (e *Encoder) EncodeEventFunc(event, id string, fn func(w *json.Writer) error) error {
+	if strings.ContainsAny(event, "\r\n") {
+		return errors.New("event contains newline")
+	}
+	if strings.ContainsAny(id, "\r\n\x00") {
+		return errors.New("id contains newline or NUL")
+	}
+	e.data.Reset()
+	e.jw.Reset(&e.data)
+	if err := fn(e.jw); err != nil {
+		return err
+	}
+	if err := e.jw.Flush(); err != nil {
+		return err
+	}
+	if e.data.Len() == 0 {
+		return errors.New("event value not written")
+	}
+	e.buf.Reset()
+	if event != "" {
+		e.field("event", event)
+	}
+	if id != "" {
+		e.field("id", id)
+	}
+	for _, line := range bytes.Split(bytes.TrimRight(e.data.Bytes(), "\n"), []byte{'\n'}) {
+		e.buf.WriteString("data: ")
+		e.buf.Write(line)
+		e.buf.WriteByte('\n')
+	}
+	return e.flush()
+}
+
+// Comment writes a comment. Clients ignore comments. Send a comment
+// periodically to keep a connection open through proxies.
+func (e *Encoder) Comment(text string) error {
+	e.buf.Reset()
+	for _, line := range strings.Split(text, "\n") {
+		e.buf.WriteString(": ")
+		e.buf.WriteString(strings.TrimSuffix(line, "\r"))
+		e.buf.WriteByte('\n')
+	}
+	return e.flush()
+}
+
+// Retry sets the client's reconnection time in milliseconds.
+func (e *Encoder) Retry(ms int) error {
+	e.buf.Reset()
+	e.field("retry", strconv.Itoa(ms))
+	return e.flush()
+}
+
+func (e *Encoder) field(name, value string) {
+	e.buf.WriteString(name)
+	e.buf.WriteString(": ")
+	e.buf.WriteString(value)
+	e.buf.WriteByte('\n')
+}
+
+// flush ends the event in buf and writes the event to the output.
+func (e *Encoder) flush() error {
+	e.buf.WriteByte('\n')
+	if _, err := e.w.Write(e.buf.Bytes()); err != nil {
+		return err
+	}
+	if e.flushHook != nil {
+		e.flushHook()
+	}
+	return nil
+}
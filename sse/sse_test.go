@@ -0,0 +1,93 @@
+// Copyright 2014 Gary Burd. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sse
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/garyburd/json"
+)
+
+// flushRecorder records the output at each call to Flush.
+type flushRecorder struct {
+	buf     bytes.Buffer
+	flushes []string
+}
+
+func (r *flushRecorder) Write(p []byte) (int, error) { return r.buf.Write(p) }
+func (r *flushRecorder) Flush()                      { r.flushes = append(r.flushes, r.buf.String()) }
+
+func TestEncoder(t *testing.T) {
+	var r flushRecorder
+	e := NewEncoder(&r)
+	steps := []struct {
+		fn   func() error
+		want string
+	}{
+		{func() error { return e.Encode(map[string]string{"a": "x\ny"}) },
+			"data: {\"a\":\"x\\ny\"}\n\n"},
+		{func() error { return e.EncodeEvent("update", "7", 1.5) },
+			"event: update\nid: 7\ndata: 1.5\n\n"},
+		{func() error {
+			return e.EncodeEventFunc("", "8", func(w *json.Writer) error { return w.Strings([]string{"<"}) })
+		}, "id: 8\ndata: [\"\\u003c\"]\n\n"},
+		{func() error { return e.Comment("ping\r\npong") },
+			": ping\n: pong\n\n"},
+		{func() error { return e.Retry(1500) },
+			"retry: 1500\n\n"},
+		{func() error {
+			e.Writer().SetIndent("", " ")
+			return e.EncodeEventFunc("", "", func(w *json.Writer) error { return w.Ints([]int64{1, 2}) })
+		}, "data: [\ndata:  1,\ndata:  2\ndata: ]\n\n"},
+	}
+	for i, step := range steps {
+		n := r.buf.Len()
+		if err := step.fn(); err != nil {
+			t.Fatalf("step %d: error %v", i, err)
+		}
+		if got := r.buf.String()[n:]; got != step.want {
+			t.Errorf("step %d: got %q, want %q", i, got, step.want)
+		}
+		if len(r.flushes) != i+1 || r.flushes[i] != r.buf.String() {
+			t.Errorf("step %d: output not flushed", i)
+		}
+	}
+}
+
+func TestEncoderError(t *testing.T) {
+	var buf bytes.Buffer
+	e := NewEncoder(&buf)
+	if err := e.EncodeEvent("a\nb", "", 1); err == nil {
+		t.Error("EncodeEvent with newline in event returned nil error")
+	}
+	if err := e.EncodeEvent("", "a\x00", 1); err == nil {
+		t.Error("EncodeEvent with NUL in id returned nil error")
+	}
+	if err := e.EncodeEventFunc("", "", func(w *json.Writer) error { return nil }); err == nil {
+		t.Error("EncodeEventFunc without value returned nil error")
+	}
+	if err := e.Encode(func() {}); err == nil {
+		t.Error("Encode(func) returned nil error")
+	}
+	if buf.Len() != 0 {
+		t.Errorf("output %q after errors, want none", buf.String())
+	}
+}
+
+func TestNewResponseEncoder(t *testing.T) {
+	w := httptest.NewRecorder()
+	e := NewResponseEncoder(w)
+	if err := e.Encode(true); err != nil {
+		t.Fatal(err)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	if !w.Flushed || w.Body.String() != "data: true\n\n" {
+		t.Errorf("flushed %v, body %q", w.Flushed, w.Body.String())
+	}
+}
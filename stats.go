@@ -0,0 +1,165 @@
+// Copyright 2014 Gary Burd. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package json
+
+// ScannerStats holds counters collected by a scanner. See
+// Scanner.CollectStats.
+type ScannerStats struct {
+	Bytes         int64 // input bytes consumed
+	Tokens        int64 // elements returned by Scan
+	MaxDepth      int   // maximum nesting level
+	StringsCooked int64 // strings unescaped or converted to valid UTF-8
+	BufferGrowths int64 // input buffer allocations
+}
+
+// WriterStats holds counters collected by a writer. See Writer.CollectStats.
+type WriterStats struct {
+	Bytes    int64 // output bytes
+	Values   int64 // values written, including arrays and objects
+	MaxDepth int   // maximum nesting level
+}
+
+// StatsHook receives statistics from scanners and writers, for example to
+// export the statistics to a monitoring system.
+type StatsHook interface {
+	// ScannerStats is called with the scanner's statistics when Scan
+	// returns false at the end of the input or on an error.
+	ScannerStats(ScannerStats)
+
+	// WriterStats is called after each top-level value with the statistics
+	// for that value.
+	WriterStats(WriterStats)
+}
+
+// CollectStats sets whether the scanner collects statistics. The counters
+// are reset when collection is enabled and by Reset.
+func (s *Scanner) CollectStats(collect bool) {
+	s.stats = nil
+	if collect {
+		s.stats = &ScannerStats{}
+	}
+}
+
+// SetStatsHook enables collection of statistics and sets the hook that
+// receives them. A nil hook removes the hook.
+func (s *Scanner) SetStatsHook(h StatsHook) {
+	if s.stats == nil {
+		s.stats = &ScannerStats{}
+	}
+	s.hook = h
+}
+
+// Stats returns the statistics collected by the scanner. Only the Bytes
+// counter is maintained when collection is not enabled.
+func (s *Scanner) Stats() ScannerStats {
+	var st ScannerStats
+	if s.stats != nil {
+		st = *s.stats
+	}
+	st.Bytes = s.base + int64(s.pos)
+	return st
+}
+
+// countScan updates the statistics after a call to scan.
+func (s *Scanner) countScan(ok bool) {
+	if ok {
+		s.stats.Tokens++
+		if n := len(s.states); n > s.stats.MaxDepth {
+			s.stats.MaxDepth = n
+		}
+		return
+	}
+	if s.hook != nil && !s.hookDone && s.pending == nil {
+		s.hookDone = true
+		s.hook.ScannerStats(s.Stats())
+	}
+}
+
+// countWriter counts the bytes written to a writer.
+type countWriter struct {
+	sw stringWriter
+	n  int64
+}
+
+func (w *countWriter) Write(p []byte) (int, error) {
+	n, err := w.sw.Write(p)
+	w.n += int64(n)
+	return n, err
+}
+
+func (w *countWriter) WriteByte(c byte) error {
+	err := w.sw.WriteByte(c)
+	if err == nil {
+		w.n++
+	}
+	return err
+}
+
+func (w *countWriter) WriteString(s string) (int, error) {
+	n, err := w.sw.WriteString(s)
+	w.n += int64(n)
+	return n, err
+}
+
+// CollectStats sets whether the writer collects statistics. The counters are
+// reset when collection is enabled and by Reset. Call CollectStats before
+// writing a value.
+func (w *Writer) CollectStats(collect bool) {
+	if w.count != nil {
+		w.sw = w.count.sw
+		w.count = nil
+	}
+	w.stats = nil
+	w.reported = WriterStats{}
+	if collect {
+		w.stats = &WriterStats{}
+		w.count = &countWriter{sw: w.sw}
+		w.sw = w.count
+	}
+}
+
+// SetStatsHook enables collection of statistics and sets the hook that
+// receives them. A nil hook removes the hook.
+func (w *Writer) SetStatsHook(h StatsHook) {
+	if w.stats == nil {
+		w.CollectStats(true)
+	}
+	w.hook = h
+}
+
+// Stats returns the statistics collected by the writer. Output buffered by
+// the writer is counted.
+func (w *Writer) Stats() WriterStats {
+	var st WriterStats
+	if w.stats != nil {
+		st = *w.stats
+		st.Bytes = w.count.n
+	}
+	return st
+}
+
+// countValue updates the statistics after a value is written.
+func (w *Writer) countValue() {
+	w.stats.Values++
+	if w.depth+1 > w.valueDepth {
+		w.valueDepth = w.depth + 1
+	}
+	if w.depth != 0 {
+		return
+	}
+	if w.valueDepth > w.stats.MaxDepth {
+		w.stats.MaxDepth = w.valueDepth
+	}
+	st := w.Stats()
+	if w.hook != nil {
+		w.hook.WriterStats(WriterStats{
+			Bytes:    st.Bytes - w.reported.Bytes,
+			Values:   st.Values - w.reported.Values,
+			MaxDepth: w.valueDepth,
+		})
+	}
+	w.reported = st
+	w.valueDepth = 0
+}
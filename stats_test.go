@@ -0,0 +1,84 @@
+// Copyright 2014 Gary Burd. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package json
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type testStatsHook struct {
+	scanner []ScannerStats
+	writer  []WriterStats
+}
+
+func (h *testStatsHook) ScannerStats(st ScannerStats) { h.scanner = append(h.scanner, st) }
+func (h *testStatsHook) WriterStats(st WriterStats)   { h.writer = append(h.writer, st) }
+
+func TestScannerStats(t *testing.T) {
+	const input = `{"a": [1, "x\n"], "b": {"c": "é"}}`
+	var h testStatsHook
+	s := NewScanner(readerOnly{strings.NewReader(input)})
+	s.Buffer(make([]byte, 0, 16), 1<<20)
+	s.SetStatsHook(&h)
+	for s.Scan() {
+		s.Value()
+	}
+	want := ScannerStats{Bytes: int64(len(input)), Tokens: 9, MaxDepth: 3, StringsCooked: 2, BufferGrowths: 1}
+	if got := s.Stats(); got != want {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+	if !reflect.DeepEqual(h.scanner, []ScannerStats{want}) {
+		t.Errorf("hook got %+v, want %+v", h.scanner, want)
+	}
+
+	s.Reset(strings.NewReader(`[]`))
+	for s.Scan() {
+	}
+	want = ScannerStats{Bytes: 2, Tokens: 2, MaxDepth: 2, BufferGrowths: 0}
+	if got := s.Stats(); got != want {
+		t.Errorf("after Reset got %+v, want %+v", got, want)
+	}
+	if len(h.scanner) != 2 {
+		t.Errorf("hook called %d times, want 2", len(h.scanner))
+	}
+
+	s = NewScannerBytes([]byte(`[1]`))
+	for s.Scan() {
+	}
+	if got := s.Stats(); got != (ScannerStats{Bytes: 3}) {
+		t.Errorf("without collection got %+v", got)
+	}
+}
+
+func TestWriterStats(t *testing.T) {
+	var h testStatsHook
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	w.SetFraming(FrameLines)
+	w.SetStatsHook(&h)
+	w.StartObject()
+	w.Name("a")
+	w.Ints([]int64{1, 2})
+	w.EndObject()
+	w.Strings([]string{"x"})
+	want := WriterStats{Bytes: int64(buf.Len()), Values: 6, MaxDepth: 3}
+	if got := w.Stats(); got != want {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+	wantHook := []WriterStats{{Bytes: 12, Values: 4, MaxDepth: 3}, {Bytes: 6, Values: 2, MaxDepth: 2}}
+	if !reflect.DeepEqual(h.writer, wantHook) {
+		t.Errorf("hook got %+v, want %+v", h.writer, wantHook)
+	}
+
+	buf.Reset()
+	w.Reset(&buf)
+	w.Int(1)
+	if got := w.Stats(); got != (WriterStats{Bytes: 2, Values: 1, MaxDepth: 1}) {
+		t.Errorf("after Reset got %+v", got)
+	}
+}
@@ -0,0 +1,355 @@
+// Copyright 2014 Gary Burd. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package json
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// StreamStrings sets whether the scanner streams string values. When
+// streaming, Scan returns a String element after reading the opening quote
+// of the string. Use StringReader to read the contents of the string
+// incrementally or Value to read the entire string. Scan skips the unread
+// part of the string. Object member names are not streamed.
+func (s *Scanner) StreamStrings(stream bool) {
+	s.streamStrings = stream
+}
+
+// StringReader returns a reader for the unescaped contents of the current
+// string value. If the scanner is streaming strings, then the reader reads
+// the contents from the input without buffering the entire string. The
+// reader is valid until the next call to Scan.
+func (s *Scanner) StringReader() io.Reader {
+	if !s.streaming {
+		var p []byte
+		if s.kind == String {
+			p = s.Value()
+		}
+		return bytes.NewReader(p)
+	}
+	return &stringReader{s: s}
+}
+
+// ValueTo writes the current string or number value to w and returns the
+// number of bytes written. Strings are unescaped as by Value. If the scanner
+// is streaming strings, then ValueTo copies the unread contents of the string
+// from the input without buffering the entire string; Value returns nil
+// after the copy.
+func (s *Scanner) ValueTo(w io.Writer) (int64, error) {
+	switch {
+	case s.kind == String && s.streaming:
+		return io.Copy(w, &stringReader{s: s})
+	case s.kind == String || s.kind == Number:
+		n, err := w.Write(s.Value())
+		return int64(n), err
+	}
+	return 0, fmt.Errorf("unexpected %v, expected string or number", s.kind)
+}
+
+// streamValue reads the rest of the current string value to cbuf.
+func (s *Scanner) streamValue() []byte {
+	if s.streaming {
+		r := stringReader{s: s}
+		p := s.cbuf[valueData][:0]
+		for {
+			if len(p) == cap(p) {
+				p = append(p, 0)[:len(p)]
+			}
+			n, err := r.Read(p[len(p):cap(p)])
+			p = p[:len(p)+n]
+			if err != nil {
+				break
+			}
+		}
+		s.cbuf[valueData] = p
+		s.streamed = true
+	}
+	return s.cbuf[valueData]
+}
+
+// skipString skips the rest of the current string value.
+func (s *Scanner) skipString() bool {
+	r := stringReader{s: s}
+	var buf [512]byte
+	for {
+		_, err := r.Read(buf[:])
+		if err == io.EOF {
+			return true
+		}
+		if err != nil {
+			return false
+		}
+	}
+}
+
+var errStringWriterClosed = errors.New("write to closed StringWriter")
+
+type stringReader struct {
+	s       *Scanner
+	pending []byte // decoded bytes not returned to the caller
+	scratch [utf8.UTFMax]byte
+}
+
+// avail attempts to make n bytes available in the scanner's buffer. The
+// function returns false if there are fewer than n bytes available before
+// the end of the input or an error.
+func (r *stringReader) avail(n int) bool {
+	s := r.s
+	for len(s.buf)-s.pos < n && s.err == nil {
+		// Retain the unread bytes across the fill.
+		data := &s.data[valueData]
+		data.pos = s.pos
+		data.end = -1
+		s.pos = len(s.buf)
+		s.fill()
+		s.pos = data.pos
+		data.pos = -1
+	}
+	return len(s.buf)-s.pos >= n
+}
+
+// fail stops streaming and returns the scanner's error.
+func (r *stringReader) fail() error {
+	s := r.s
+	s.streaming = false
+	if s.err == nil || s.err == io.EOF {
+		s.err = io.ErrUnexpectedEOF
+	}
+	return s.err
+}
+
+func (r *stringReader) Read(p []byte) (int, error) {
+	s := r.s
+	n := 0
+	for n < len(p) {
+		if len(r.pending) > 0 {
+			c := copy(p[n:], r.pending)
+			r.pending = r.pending[c:]
+			n += c
+			continue
+		}
+		if !s.streaming {
+			break
+		}
+		if !r.avail(1) {
+			return n, r.fail()
+		}
+		switch b := s.buf[s.pos]; {
+		case b == '"':
+			s.pos++
+			s.streaming = false
+		case b == '\\':
+			rn, size, ok := r.escape()
+			if !ok {
+				return n, s.err
+			}
+			if rn == utf8.RuneError && s.strictUTF8 {
+				return n, r.syntaxError(0, expectValidString)
+			}
+			s.pos += size
+			r.pending = r.scratch[:utf8.EncodeRune(r.scratch[:], rn)]
+		case b < ' ':
+			return n, r.syntaxError(0, expectStringNotControl)
+		case b < utf8.RuneSelf:
+			i := s.pos
+			for i < len(s.buf) && n+i-s.pos < len(p) {
+				if b := s.buf[i]; b < ' ' || b == '"' || b == '\\' || b >= utf8.RuneSelf {
+					break
+				}
+				i++
+			}
+			n += copy(p[n:], s.buf[s.pos:i])
+			s.pos = i
+		default:
+			r.avail(utf8.UTFMax)
+			rn, size := utf8.DecodeRune(s.buf[s.pos:])
+			if rn == utf8.RuneError && size == 1 {
+				if s.strictUTF8 {
+					return n, r.syntaxError(0, expectValidString)
+				}
+				r.pending = r.scratch[:utf8.EncodeRune(r.scratch[:], rn)]
+			} else {
+				r.pending = append(r.scratch[:0], s.buf[s.pos:s.pos+size]...)
+			}
+			s.pos += size
+		}
+	}
+	if n == 0 && !s.streaming && len(p) > 0 {
+		return 0, io.EOF
+	}
+	return n, nil
+}
+
+// syntaxError reports a syntax error at offset i from the scanner's position.
+func (r *stringReader) syntaxError(i int, expect string) error {
+	s := r.s
+	s.pos += i
+	s.streaming = false
+	s.syntaxError(s.buf[s.pos], expect)
+	return s.err
+}
+
+// escape decodes the escape sequence at the scanner's position.
+func (r *stringReader) escape() (rune, int, bool) {
+	s := r.s
+	if !r.avail(2) {
+		r.fail()
+		return 0, 0, false
+	}
+	switch b := s.buf[s.pos+1]; b {
+	case '"', '\\', '/':
+		return rune(b), 2, true
+	case 'b':
+		return '\b', 2, true
+	case 'f':
+		return '\f', 2, true
+	case 'n':
+		return '\n', 2, true
+	case 'r':
+		return '\r', 2, true
+	case 't':
+		return '\t', 2, true
+	case 'u':
+	default:
+		r.syntaxError(1, expectStringEscape)
+		return 0, 0, false
+	}
+	rn, ok := r.hex(2)
+	if !ok {
+		return 0, 0, false
+	}
+	if !utf16.IsSurrogate(rn) {
+		return rn, 6, true
+	}
+	if r.avail(12) && s.buf[s.pos+6] == '\\' && s.buf[s.pos+7] == 'u' {
+		rn2, ok := r.hex(8)
+		if !ok {
+			return 0, 0, false
+		}
+		if rn := utf16.DecodeRune(rn, rn2); rn != utf8.RuneError {
+			return rn, 12, true
+		}
+	}
+	return utf8.RuneError, 6, true
+}
+
+// hex decodes the four hexadecimal digits at offset i from the scanner's
+// position.
+func (r *stringReader) hex(i int) (rune, bool) {
+	s := r.s
+	if !r.avail(i + 4) {
+		r.fail()
+		return 0, false
+	}
+	for j := i; j < i+4; j++ {
+		if !isHexDigit(s.buf[s.pos+j]) {
+			r.syntaxError(j, expectStringUnicodeEscape1)
+			return 0, false
+		}
+	}
+	return parseHex(s.buf[s.pos+i : s.pos+i+4]), true
+}
+
+// StringWriter starts a string value and returns a writer for the contents
+// of the string. The contents are escaped as they are written. Close the
+// returned writer to end the string. Other Writer methods return
+// ErrStringOpen until the string is closed.
+//
+// Invalid UTF-8 in the contents is replaced with U+FFFD. A multibyte UTF-8
+// sequence can be split across calls to Write.
+func (w *Writer) StringWriter() io.WriteCloser {
+	if err := w.value(); err != nil {
+		return &stringWriterValue{err: err}
+	}
+	w.str = true
+	return &stringWriterValue{w: w, err: w.sw.WriteByte('"')}
+}
+
+// StringCopy writes the contents read from r as a string value.
+func (w *Writer) StringCopy(r io.Reader) error {
+	sw := w.StringWriter()
+	_, err := io.Copy(sw, r)
+	if e := sw.Close(); err == nil {
+		err = e
+	}
+	return err
+}
+
+type stringWriterValue struct {
+	w    *Writer
+	err  error
+	n    int               // number of bytes in part
+	part [utf8.UTFMax]byte // incomplete UTF-8 sequence from the previous write
+}
+
+func (sw *stringWriterValue) Write(p []byte) (int, error) {
+	if sw.err != nil {
+		return 0, sw.err
+	}
+	if sw.w == nil {
+		return 0, errStringWriterClosed
+	}
+	escapeHTML := !sw.w.noEscapeHTML
+	n := len(p)
+
+	// Complete the sequence from the previous write.
+	for sw.n > 0 && len(p) > 0 {
+		k := copy(sw.part[sw.n:], p)
+		buf := sw.part[:sw.n+k]
+		if !utf8.FullRune(buf) {
+			sw.n += k
+			return n, nil
+		}
+		_, size := utf8.DecodeRune(buf)
+		sw.err = sw.w.latch(writeEscaped(sw.w.sw, buf[:size], escapeHTML))
+		if size >= sw.n {
+			p = p[size-sw.n:]
+			sw.n = 0
+		} else {
+			sw.n = copy(sw.part[:], sw.part[size:sw.n])
+		}
+	}
+
+	// Hold back an incomplete sequence at the end of p.
+	i := len(p)
+	for j := len(p) - 1; j >= 0 && j >= len(p)-utf8.UTFMax; j-- {
+		if utf8.RuneStart(p[j]) {
+			if !utf8.FullRune(p[j:]) {
+				i = j
+			}
+			break
+		}
+	}
+	if sw.err == nil {
+		sw.err = sw.w.latch(writeEscaped(sw.w.sw, p[:i], escapeHTML))
+	}
+	sw.n += copy(sw.part[sw.n:], p[i:])
+	if sw.err != nil {
+		return 0, sw.err
+	}
+	return n, nil
+}
+
+// Close ends the string value.
+func (sw *stringWriterValue) Close() error {
+	if sw.w == nil {
+		return sw.err
+	}
+	w := sw.w
+	sw.w = nil
+	w.str = false
+	writeEscaped(w.sw, sw.part[:sw.n], !w.noEscapeHTML)
+	sw.n = 0
+	err := w.end(w.sw.WriteByte('"'))
+	if sw.err == nil {
+		sw.err = err
+	}
+	return sw.err
+}
@@ -0,0 +1,169 @@
+// Copyright 2014 Gary Burd. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package json
+
+import (
+	"bytes"
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+var streamStringTests = []struct {
+	in   string
+	want string
+}{
+	{`""`, ""},
+	{`"hello"`, "hello"},
+	{`"a\"b\\c\/d\b\f\n\r\t"`, "a\"b\\c/d\b\f\n\r\t"},
+	{`"é€"`, "é€"},
+	{`"𝄞"`, "𝄞"},
+	{`"\ud834x"`, "�x"},
+	{`"\ud834A"`, "�A"},
+	{`"é€𝄞"`, "é€𝄞"},
+	{"\"a\xffb\"", "a�b"},
+	{`"` + strings.Repeat("abcdefgh", 100) + `"`, strings.Repeat("abcdefgh", 100)},
+	{`"` + strings.Repeat(`éx`, 100) + `"`, strings.Repeat("éx", 100)},
+}
+
+func TestStringReader(t *testing.T) {
+	for _, tt := range streamStringTests {
+		for _, s := range []*Scanner{
+			NewScanner(iotest.OneByteReader(strings.NewReader(tt.in))),
+			NewScannerSize(readerOnly{strings.NewReader(tt.in)}, 16),
+			NewScannerBytes([]byte(tt.in)),
+		} {
+			s.StreamStrings(true)
+			if !s.Scan() {
+				t.Fatalf("%q: Scan() = false, err %v", tt.in, s.Err())
+			}
+			if s.Kind() != String {
+				t.Fatalf("%q: kind = %v, want String", tt.in, s.Kind())
+			}
+			p, err := ioutil.ReadAll(iotest.OneByteReader(s.StringReader()))
+			if err != nil {
+				t.Errorf("%q: read returned error %v", tt.in, err)
+			}
+			if string(p) != tt.want {
+				t.Errorf("%q: got %q, want %q", tt.in, p, tt.want)
+			}
+			if s.Scan() {
+				t.Errorf("%q: Scan() = true, want false", tt.in)
+			}
+			if err := s.Err(); err != nil {
+				t.Errorf("%q: got error %v", tt.in, err)
+			}
+		}
+	}
+}
+
+func TestStreamStringsSkip(t *testing.T) {
+	const doc = `{"a": "xyz", "b": ["hello", "é\n", 1], "c": "abc"}`
+	s := NewScannerSize(iotest.OneByteReader(strings.NewReader(doc)), 16)
+	s.StreamStrings(true)
+	var got []string
+	for s.Scan() {
+		switch string(s.Name()) {
+		case "a":
+			// Read part of the string and skip the rest.
+			var buf [1]byte
+			s.StringReader().Read(buf[:])
+			got = append(got, string(buf[:]))
+		case "c":
+			got = append(got, string(s.Value()), string(s.Value()))
+		default:
+			if s.Kind() == String {
+				got = append(got, string(s.Value()))
+			}
+		}
+	}
+	if err := s.Err(); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"x", "hello", "é\n", "abc", "abc"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestStringReaderNotStreaming(t *testing.T) {
+	s := NewScannerBytes([]byte(`"a\nb"`))
+	s.Scan()
+	p, err := ioutil.ReadAll(s.StringReader())
+	if err != nil || string(p) != "a\nb" {
+		t.Errorf("got %q, %v, want %q, nil", p, err, "a\nb")
+	}
+}
+
+var streamStringErrorTests = []struct {
+	in     string
+	strict bool
+	pos    int // position of syntax error or -1 for unexpected EOF
+}{
+	{`"abc`, false, -1},
+	{`"ab\u00`, false, -1},
+	{"\"a\nb\"", false, 2},
+	{`"a\qb"`, false, 3},
+	{`"a\u00x0"`, false, 6},
+	{"\"a\xffb\"", true, 2},
+	{`"a\ud834"`, true, 2},
+}
+
+func TestStringReaderError(t *testing.T) {
+	for _, tt := range streamStringErrorTests {
+		s := NewScanner(iotest.OneByteReader(strings.NewReader(tt.in)))
+		s.StreamStrings(true)
+		s.StrictUTF8(tt.strict)
+		if !s.Scan() {
+			t.Fatalf("%q: Scan() = false, err %v", tt.in, s.Err())
+		}
+		_, err := ioutil.ReadAll(s.StringReader())
+		if err == nil {
+			t.Errorf("%q: no error", tt.in)
+			continue
+		}
+		if err != s.Err() {
+			t.Errorf("%q: read error %v differs from scanner error %v", tt.in, err, s.Err())
+		}
+		if e, ok := err.(*SyntaxError); ok {
+			if e.Pos != tt.pos {
+				t.Errorf("%q: got position %d, want %d", tt.in, e.Pos, tt.pos)
+			}
+		} else if tt.pos >= 0 {
+			t.Errorf("%q: got error %v, want syntax error", tt.in, err)
+		}
+		if s.Scan() {
+			t.Errorf("%q: Scan() after error returned true", tt.in)
+		}
+	}
+}
+
+func TestValueTo(t *testing.T) {
+	for _, stream := range []bool{false, true} {
+		for _, tt := range streamStringTests {
+			in := `[` + tt.in + `,-1.5e3,true]`
+			s := NewScanner(iotest.OneByteReader(strings.NewReader(in)))
+			s.StreamStrings(stream)
+			var buf bytes.Buffer
+			s.Scan()
+			s.Scan()
+			n, err := s.ValueTo(&buf)
+			if err != nil || buf.String() != tt.want || n != int64(len(tt.want)) {
+				t.Errorf("%s, stream=%v: got %q, %d, %v, want %q", tt.in, stream, buf.String(), n, err, tt.want)
+			}
+			buf.Reset()
+			s.Scan()
+			if _, err := s.ValueTo(&buf); err != nil || buf.String() != "-1.5e3" {
+				t.Errorf("%s, stream=%v: got number %q, %v", tt.in, stream, buf.String(), err)
+			}
+			s.Scan()
+			if _, err := s.ValueTo(&buf); err == nil {
+				t.Errorf("%s, stream=%v: ValueTo returned nil error for bool", tt.in, stream)
+			}
+		}
+	}
+}
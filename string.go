@@ -13,13 +13,13 @@ import (
 
 var hex = "0123456789abcdef"
 
-// NOTE: keep in sync with stringBytes below.
-func writeString(e stringWriter, s string) error {
+// NOTE: keep in sync with writeStringBytes and appendString below.
+func writeString(e stringWriter, s string, escapeHTML bool) error {
 	e.WriteByte('"')
 	start := 0
 	for i := 0; i < len(s); {
 		if b := s[i]; b < utf8.RuneSelf {
-			if 0x20 <= b && b != '\\' && b != '"' && b != '<' && b != '>' && b != '&' {
+			if 0x20 <= b && b != 0x7f && b != '\\' && b != '"' && (!escapeHTML || (b != '<' && b != '>' && b != '&')) {
 				i++
 				continue
 			}
@@ -36,11 +36,21 @@ func writeString(e stringWriter, s string) error {
 			case '\r':
 				e.WriteByte('\\')
 				e.WriteByte('r')
+			case '\t':
+				e.WriteByte('\\')
+				e.WriteByte('t')
+			case '\b':
+				e.WriteByte('\\')
+				e.WriteByte('b')
+			case '\f':
+				e.WriteByte('\\')
+				e.WriteByte('f')
 			default:
-				// This encodes bytes < 0x20 except for \n and \r,
-				// as well as < and >. The latter are escaped because they
-				// can lead to security holes when user-controlled strings
-				// are rendered into JSON and served to some browsers.
+				// This encodes bytes < 0x20 except for \n, \r, \t, \b
+				// and \f, the byte 0x7f, as well as <, > and & when
+				// escaping HTML. The latter are escaped because they can
+				// lead to security holes when user-controlled strings are
+				// rendered into JSON and served to some browsers.
 				e.WriteString(`\u00`)
 				e.WriteByte(hex[b>>4])
 				e.WriteByte(hex[b&0xF])
@@ -84,13 +94,20 @@ func writeString(e stringWriter, s string) error {
 	return e.WriteByte('"')
 }
 
-// NOTE: keep in sync with string above.
-func writeStringBytes(e stringWriter, s []byte) error {
+func writeStringBytes(e stringWriter, s []byte, escapeHTML bool) error {
 	e.WriteByte('"')
+	writeEscaped(e, s, escapeHTML)
+	return e.WriteByte('"')
+}
+
+// writeEscaped writes the escaped contents of a string without quotes.
+//
+// NOTE: keep in sync with writeString above.
+func writeEscaped(e stringWriter, s []byte, escapeHTML bool) error {
 	start := 0
 	for i := 0; i < len(s); {
 		if b := s[i]; b < utf8.RuneSelf {
-			if 0x20 <= b && b != '\\' && b != '"' && b != '<' && b != '>' && b != '&' {
+			if 0x20 <= b && b != 0x7f && b != '\\' && b != '"' && (!escapeHTML || (b != '<' && b != '>' && b != '&')) {
 				i++
 				continue
 			}
@@ -107,11 +124,21 @@ func writeStringBytes(e stringWriter, s []byte) error {
 			case '\r':
 				e.WriteByte('\\')
 				e.WriteByte('r')
+			case '\t':
+				e.WriteByte('\\')
+				e.WriteByte('t')
+			case '\b':
+				e.WriteByte('\\')
+				e.WriteByte('b')
+			case '\f':
+				e.WriteByte('\\')
+				e.WriteByte('f')
 			default:
-				// This encodes bytes < 0x20 except for \n and \r,
-				// as well as < and >. The latter are escaped because they
-				// can lead to security holes when user-controlled strings
-				// are rendered into JSON and served to some browsers.
+				// This encodes bytes < 0x20 except for \n, \r, \t, \b
+				// and \f, the byte 0x7f, as well as <, > and & when
+				// escaping HTML. The latter are escaped because they can
+				// lead to security holes when user-controlled strings are
+				// rendered into JSON and served to some browsers.
 				e.WriteString(`\u00`)
 				e.WriteByte(hex[b>>4])
 				e.WriteByte(hex[b&0xF])
@@ -150,7 +177,89 @@ func writeStringBytes(e stringWriter, s []byte) error {
 		i += size
 	}
 	if start < len(s) {
-		e.Write(s[start:])
+		_, err := e.Write(s[start:])
+		return err
 	}
-	return e.WriteByte('"')
+	return nil
+}
+
+// NOTE: keep in sync with writeString above.
+func appendString(dst []byte, s string, escapeHTML bool) []byte {
+	dst = append(dst, '"')
+	start := 0
+	for i := 0; i < len(s); {
+		if b := s[i]; b < utf8.RuneSelf {
+			if 0x20 <= b && b != 0x7f && b != '\\' && b != '"' && (!escapeHTML || (b != '<' && b != '>' && b != '&')) {
+				i++
+				continue
+			}
+			if start < i {
+				dst = append(dst, s[start:i]...)
+			}
+			switch b {
+			case '\\', '"':
+				dst = append(dst, '\\')
+				dst = append(dst, b)
+			case '\n':
+				dst = append(dst, '\\')
+				dst = append(dst, 'n')
+			case '\r':
+				dst = append(dst, '\\')
+				dst = append(dst, 'r')
+			case '\t':
+				dst = append(dst, '\\')
+				dst = append(dst, 't')
+			case '\b':
+				dst = append(dst, '\\')
+				dst = append(dst, 'b')
+			case '\f':
+				dst = append(dst, '\\')
+				dst = append(dst, 'f')
+			default:
+				// This encodes bytes < 0x20 except for \n, \r, \t, \b
+				// and \f, the byte 0x7f, as well as <, > and & when
+				// escaping HTML. The latter are escaped because they can
+				// lead to security holes when user-controlled strings are
+				// rendered into JSON and served to some browsers.
+				dst = append(dst, `\u00`...)
+				dst = append(dst, hex[b>>4])
+				dst = append(dst, hex[b&0xF])
+			}
+			i++
+			start = i
+			continue
+		}
+		c, size := utf8.DecodeRuneInString(s[i:])
+		if c == utf8.RuneError && size == 1 {
+			if start < i {
+				dst = append(dst, s[start:i]...)
+			}
+			dst = append(dst, `\ufffd`...)
+			i += size
+			start = i
+			continue
+		}
+		// U+2028 is LINE SEPARATOR.
+		// U+2029 is PARAGRAPH SEPARATOR.
+		// They are both technically valid characters in JSON strings,
+		// but don't work in JSONP, which has to be evaluated as JavaScript,
+		// and can lead to security holes there. It is valid JSON to
+		// escape them, so we do so unconditionally.
+		// See http://timelessrepo.com/json-isnt-a-javascript-subset for discussion.
+		if c == '\u2028' || c == '\u2029' {
+			if start < i {
+				dst = append(dst, s[start:i]...)
+			}
+			dst = append(dst, `\u202`...)
+			dst = append(dst, hex[c&0xF])
+			i += size
+			start = i
+			continue
+		}
+		i += size
+	}
+	if start < len(s) {
+		dst = append(dst, s[start:]...)
+	}
+	return append(dst, '"')
 }
@@ -0,0 +1,38 @@
+// Copyright 2014 Gary Burd. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package json
+
+import "io"
+
+// Tee copies the input consumed by the scanner to w. The scanner writes the
+// input through the end of the current element when Scan returns and does
+// not write input that is buffered, but not scanned. Tee copies input
+// consumed after the call to Tee. Reset removes the tee.
+//
+// If a write to w fails, then scanning stops with the write error.
+func (s *Scanner) Tee(w io.Writer) {
+	s.tee = w
+	s.teePos = s.pos
+}
+
+// flushTee writes the input consumed since the last call to the tee.
+// flushTee returns false if the write fails.
+func (s *Scanner) flushTee() bool {
+	pos := s.pos
+	if pos > len(s.buf) {
+		pos = len(s.buf)
+	}
+	if pos <= s.teePos {
+		return true
+	}
+	_, err := s.tee.Write(s.buf[s.teePos:pos])
+	s.teePos = pos
+	if err != nil {
+		s.tee = nil
+		s.err = err
+		return false
+	}
+	return true
+}
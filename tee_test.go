@@ -0,0 +1,68 @@
+// Copyright 2014 Gary Burd. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package json
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestTee(t *testing.T) {
+	const doc = `{"a": [1, 2], "b": "` + "0123456789abcdefghij" + `"} 123 ["x"]  `
+	for _, s := range []*Scanner{
+		NewScannerSize(readerOnly{strings.NewReader(doc)}, 16),
+		NewScanner(iotest.OneByteReader(strings.NewReader(doc))),
+		NewScannerBytes([]byte(doc)),
+	} {
+		s.AllowMultiple()
+		var buf bytes.Buffer
+		s.Tee(&buf)
+		var got []string
+		for s.Scan() {
+			got = append(got, buf.String())
+		}
+		if err := s.Err(); err != nil {
+			t.Fatal(err)
+		}
+		want := []string{
+			`{`,
+			`{"a": [`,
+			`{"a": [1`,
+			`{"a": [1, 2`,
+			`{"a": [1, 2]`,
+			`{"a": [1, 2], "b": "0123456789abcdefghij"`,
+			`{"a": [1, 2], "b": "0123456789abcdefghij"}`,
+			`{"a": [1, 2], "b": "0123456789abcdefghij"} 123`,
+			`{"a": [1, 2], "b": "0123456789abcdefghij"} 123 [`,
+			`{"a": [1, 2], "b": "0123456789abcdefghij"} 123 ["x"`,
+			`{"a": [1, 2], "b": "0123456789abcdefghij"} 123 ["x"]`,
+		}
+		if len(got) != len(want) {
+			t.Fatalf("got %d elements, want %d", len(got), len(want))
+		}
+		for i := range got {
+			if got[i] != want[i] {
+				t.Errorf("%d: got %q, want %q", i, got[i], want[i])
+			}
+		}
+		if buf.String() != doc {
+			t.Errorf("at end, got %q, want %q", buf.String(), doc)
+		}
+	}
+}
+
+func TestTeeWriteError(t *testing.T) {
+	s := NewScannerBytes([]byte(`[1, 2]`))
+	s.Tee(&failWriter{n: 2})
+	n := 0
+	for s.Scan() {
+		n++
+	}
+	if n != 2 || s.Err() != errTestWrite {
+		t.Errorf("got %d elements, error %v, want 2, %v", n, s.Err(), errTestWrite)
+	}
+}
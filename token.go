@@ -0,0 +1,162 @@
+// Copyright 2014 Gary Burd. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package json
+
+import (
+	"errors"
+	"io"
+	"strconv"
+)
+
+// A Token holds a value of one of these types:
+//
+//	Delim, for the four JSON delimiters [ ] { }
+//	bool, for JSON booleans
+//	float64 or NumberValue, for JSON numbers
+//	string, for JSON string literals and object member names
+//	nil, for JSON null
+//
+// The types match the tokens returned by the encoding/json package.
+type Token interface{}
+
+// A Delim is a JSON array or object delimiter, one of [ ] { or }.
+type Delim rune
+
+func (d Delim) String() string {
+	return string(d)
+}
+
+// Decoder reads a stream of JSON values as tokens. The Decoder is an adapter
+// over Scanner with an API compatible with the Token methods of the
+// encoding/json Decoder.
+type Decoder struct {
+	s         *Scanner
+	useNumber bool
+	opts      UnmarshalOptions
+	scanned   bool    // if true, the scanner has an element that is not returned.
+	pending   bool    // if true, the name is returned and the value is not.
+	stack     []Delim // open delimiters
+}
+
+// NewDecoder returns a new decoder that reads from r.
+func NewDecoder(r io.Reader) *Decoder {
+	s := NewScanner(r)
+	s.AllowMultiple()
+	return &Decoder{s: s}
+}
+
+// Scanner returns the decoder's underlying scanner.
+func (d *Decoder) Scanner() *Scanner {
+	return d.s
+}
+
+// UseNumber causes the Decoder to return numbers as NumberValue instead of
+// as float64.
+func (d *Decoder) UseNumber() {
+	d.useNumber = true
+}
+
+// CaseInsensitive sets whether Decode matches object member names to struct
+// fields using Unicode case-folding when there is no exact match. See
+// UnmarshalOptions.
+func (d *Decoder) CaseInsensitive(fold bool) {
+	d.opts.CaseInsensitive = fold
+}
+
+// DisallowUnknownFields causes Decode to return an error when the destination
+// is a struct and the input contains object members that do not match a
+// field in the destination.
+func (d *Decoder) DisallowUnknownFields() {
+	d.opts.DisallowUnknownFields = true
+}
+
+// scan advances to the next element if the current element was consumed.
+func (d *Decoder) scan() error {
+	if d.scanned {
+		return nil
+	}
+	if !d.s.Scan() {
+		if err := d.s.Err(); err != nil {
+			return err
+		}
+		return io.EOF
+	}
+	d.scanned = true
+	return nil
+}
+
+// Token returns the next JSON token in the input stream. At the end of the
+// input stream, Token returns nil, io.EOF.
+func (d *Decoder) Token() (Token, error) {
+	if d.pending {
+		d.pending = false
+		d.scanned = false
+		return d.token()
+	}
+	if err := d.scan(); err != nil {
+		return nil, err
+	}
+	if d.s.Kind() != End && len(d.stack) > 0 && d.stack[len(d.stack)-1] == '{' {
+		d.pending = true
+		return d.s.NameString(), nil
+	}
+	d.scanned = false
+	return d.token()
+}
+
+func (d *Decoder) token() (Token, error) {
+	s := d.s
+	switch s.Kind() {
+	case Array:
+		d.stack = append(d.stack, '[')
+		return Delim('['), nil
+	case Object:
+		d.stack = append(d.stack, '{')
+		return Delim('{'), nil
+	case End:
+		delim := d.stack[len(d.stack)-1]
+		d.stack = d.stack[:len(d.stack)-1]
+		if delim == '[' {
+			return Delim(']'), nil
+		}
+		return Delim('}'), nil
+	case String:
+		return string(s.Value()), nil
+	case Number:
+		if d.useNumber {
+			return NumberValue(s.Value()), nil
+		}
+		return strconv.ParseFloat(string(s.Value()), 64)
+	case Bool:
+		return s.Value()[0] == 't', nil
+	default:
+		return nil, nil
+	}
+}
+
+// More reports whether there is another element in the current array or
+// object being parsed.
+func (d *Decoder) More() bool {
+	if d.pending {
+		return true
+	}
+	return d.scan() == nil && d.s.Kind() != End
+}
+
+// Decode reads the next JSON value from its input and stores it in the value
+// pointed to by v. See Unmarshal for details about the conversion.
+func (d *Decoder) Decode(v interface{}) error {
+	if !d.pending {
+		if err := d.scan(); err != nil {
+			return err
+		}
+		if d.s.Kind() == End {
+			return errors.New("unexpected end of array or object")
+		}
+	}
+	d.pending = false
+	d.scanned = false
+	return UnmarshalWith(d.s, v, d.opts)
+}
@@ -0,0 +1,103 @@
+// Copyright 2014 Gary Burd. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package json
+
+import (
+	sjson "encoding/json"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var tokenTests = []string{
+	`null`,
+	`[]`,
+	`{}`,
+	`[1, "a", true, false, null, -1.5e3]`,
+	`{"a": 1, "b": [2, {"c": "d"}], "e": {}}`,
+	`[[], [[]], {"a": {"b": {}}}]`,
+	`1 "two" [3] {"four": 4}`,
+}
+
+func TestToken(t *testing.T) {
+	for _, s := range tokenTests {
+		for _, useNumber := range []bool{false, true} {
+			var got, want []interface{}
+
+			sd := sjson.NewDecoder(strings.NewReader(s))
+			d := NewDecoder(strings.NewReader(s))
+			if useNumber {
+				sd.UseNumber()
+				d.UseNumber()
+			}
+
+			for {
+				tok, err := sd.Token()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					t.Fatalf("%s: encoding/json returned error %v", s, err)
+				}
+				if n, ok := tok.(sjson.Number); ok {
+					tok = NumberValue(n)
+				}
+				if d, ok := tok.(sjson.Delim); ok {
+					tok = Delim(d)
+				}
+				want = append(want, tok)
+			}
+
+			for {
+				tok, err := d.Token()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					t.Fatalf("%s: returned error %v", s, err)
+				}
+				got = append(got, tok)
+			}
+
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("%s:\n got %v\nwant %v", s, got, want)
+			}
+		}
+	}
+}
+
+func TestDecoderMoreAndDecode(t *testing.T) {
+	d := NewDecoder(strings.NewReader(`{"items": [{"A": 1}, {"A": 2}], "next": null}`))
+	var got []int
+	for _, want := range []Token{Delim('{'), "items", Delim('[')} {
+		tok, err := d.Token()
+		if err != nil || tok != want {
+			t.Fatalf("got %v, %v, want %v", tok, err, want)
+		}
+	}
+	for d.More() {
+		var v struct{ A int }
+		if err := d.Decode(&v); err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, v.A)
+	}
+	if !reflect.DeepEqual(got, []int{1, 2}) {
+		t.Errorf("got %v, want [1 2]", got)
+	}
+	for _, want := range []Token{Delim(']'), "next", nil, Delim('}')} {
+		tok, err := d.Token()
+		if err != nil || tok != want {
+			t.Fatalf("got %v, %v, want %v", tok, err, want)
+		}
+	}
+	if d.More() {
+		t.Error("More() = true at end of input")
+	}
+	if _, err := d.Token(); err != io.EOF {
+		t.Errorf("got error %v, want EOF", err)
+	}
+}
@@ -0,0 +1,146 @@
+// Copyright 2014 Gary Burd. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package json
+
+import "bytes"
+
+// Transform copies the JSON values in src to dst. Transform enables path
+// tracking on src and must be called before src is scanned.
+//
+// Before copying each element, Transform calls fn with the RFC 6901 JSON
+// Pointer to the element, the scanner positioned at the element and the
+// writer. If fn returns handled == false, then Transform copies the element.
+// The function can inject values by writing to w before returning false.
+// If fn returns handled == true, then Transform does not copy the element.
+// The function can drop the element or write a replacement, including the
+// member name when the element is an object member. When handled is true and
+// the element is an object or array, the function must consume the element
+// through the matching End, for example by calling Skip.
+func Transform(dst *Writer, src *Scanner, fn func(path string, s *Scanner, w *Writer) (handled bool, err error)) error {
+	return transform(dst, src, fn, nil)
+}
+
+// RenameKeys copies the JSON values in src to dst, replacing each object
+// member name with the name returned by fn. The function is called with the
+// RFC 6901 JSON Pointer to the member, using the input names, and the input
+// name. The name slice is valid until the function returns. RenameKeys
+// enables path tracking on src and must be called before src is scanned.
+//
+// The SnakeToCamel and CamelToSnake functions convert between common naming
+// conventions:
+//
+//  err := json.RenameKeys(w, s, json.SnakeToCamel)
+func RenameKeys(dst *Writer, src *Scanner, fn func(path string, name []byte) []byte) error {
+	return transform(dst, src, nil, fn)
+}
+
+// transform implements Transform and RenameKeys. Either fn or rename may be
+// nil.
+func transform(dst *Writer, src *Scanner, fn func(path string, s *Scanner, w *Writer) (handled bool, err error), rename func(path string, name []byte) []byte) error {
+	src.TrackPath(true)
+	var objects []bool // for each open container, true if object
+	for src.Scan() {
+		var err error
+		if src.Kind() == End {
+			if objects[len(objects)-1] {
+				err = dst.EndObject()
+			} else {
+				err = dst.EndArray()
+			}
+			objects = objects[:len(objects)-1]
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		if fn != nil {
+			handled, err := fn(src.PathString(), src, dst)
+			if err != nil {
+				return err
+			}
+			if handled {
+				continue
+			}
+		}
+
+		if len(objects) > 0 && objects[len(objects)-1] {
+			name := src.Name()
+			if rename != nil {
+				name = rename(src.PathString(), name)
+			}
+			if err := dst.NameBytes(name); err != nil {
+				return err
+			}
+		}
+		switch src.Kind() {
+		case Object:
+			objects = append(objects, true)
+			err = dst.StartObject()
+		case Array:
+			objects = append(objects, false)
+			err = dst.StartArray()
+		case String:
+			err = dst.StringBytes(src.Value())
+		case Number:
+			err = dst.Raw(src.Value())
+		case Bool:
+			err = dst.Bool(src.Value()[0] == 't')
+		case Null:
+			err = dst.Null()
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return src.Err()
+}
+
+// SnakeToCamel converts a snake_case name to camelCase. The function has the
+// signature required by RenameKeys; the path is ignored.
+func SnakeToCamel(path string, name []byte) []byte {
+	if bytes.IndexByte(name, '_') < 0 {
+		return name
+	}
+	p := make([]byte, 0, len(name))
+	upper := false
+	for _, b := range name {
+		switch {
+		case b == '_' && len(p) > 0:
+			upper = true
+		case b == '_':
+			p = append(p, b)
+		case upper && isLower(b):
+			p = append(p, b-'a'+'A')
+			upper = false
+		default:
+			p = append(p, b)
+			upper = false
+		}
+	}
+	return p
+}
+
+// CamelToSnake converts a camelCase name to snake_case. An upper case ASCII
+// letter starts a new word unless it follows another upper case letter that
+// is not followed by a lower case letter, so "userID" is converted to
+// "user_id" and "HTTPServer" is converted to "http_server". The function has
+// the signature required by RenameKeys; the path is ignored.
+func CamelToSnake(path string, name []byte) []byte {
+	p := make([]byte, 0, len(name)+4)
+	for i, b := range name {
+		if isUpper(b) {
+			if i > 0 && (!isUpper(name[i-1]) || i+1 < len(name) && isLower(name[i+1])) && name[i-1] != '_' {
+				p = append(p, '_')
+			}
+			b += 'a' - 'A'
+		}
+		p = append(p, b)
+	}
+	return p
+}
+
+func isUpper(b byte) bool { return 'A' <= b && b <= 'Z' }
+func isLower(b byte) bool { return 'a' <= b && b <= 'z' }
@@ -0,0 +1,102 @@
+// Copyright 2014 Gary Burd. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package json
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestTransform(t *testing.T) {
+	const in = `{"name":"x","ssn":"123","cards":[{"number":"4111","exp":"12/20"}],"extra":{"a":[1,2]},"n":null,"b":true}`
+	const want = `{"name":"x","ssn":"***","cards":[{"exp":"12/20"}],"added":1,"n":null,"b":true}`
+
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	err := Transform(w, NewScannerBytes([]byte(in)), func(path string, s *Scanner, w *Writer) (bool, error) {
+		switch path {
+		case "/ssn":
+			w.Name("ssn")
+			return true, w.String("***")
+		case "/cards/0/number":
+			return true, nil
+		case "/extra":
+			w.Name("added")
+			w.Int(1)
+			return true, s.Skip()
+		}
+		return false, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != want {
+		t.Errorf("got  %s\nwant %s", buf.String(), want)
+	}
+}
+
+func TestTransformIdentity(t *testing.T) {
+	const in = `[1,"a\n",{"b":[true,false,null]},[],{}]`
+	var buf bytes.Buffer
+	err := Transform(NewWriter(&buf), NewScannerBytes([]byte(in)), func(string, *Scanner, *Writer) (bool, error) {
+		return false, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != in {
+		t.Errorf("got %s, want %s", buf.String(), in)
+	}
+}
+
+func TestTransformError(t *testing.T) {
+	var buf bytes.Buffer
+	err := Transform(NewWriter(&buf), NewScannerBytes([]byte(`[1,`)), func(string, *Scanner, *Writer) (bool, error) {
+		return false, nil
+	})
+	if err == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestRenameKeys(t *testing.T) {
+	const in = `{"user_id":1,"first_name":"x","tags":[{"tag_name":"a"}],"_meta":{"page_size":2}}`
+	var buf bytes.Buffer
+	var paths []string
+	err := RenameKeys(NewWriter(&buf), NewScannerBytes([]byte(in)), func(path string, name []byte) []byte {
+		paths = append(paths, path)
+		return SnakeToCamel(path, name)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := buf.String(), `{"userId":1,"firstName":"x","tags":[{"tagName":"a"}],"_meta":{"pageSize":2}}`; got != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+	if got, want := strings.Join(paths, " "), "/user_id /first_name /tags /tags/0/tag_name /_meta /_meta/page_size"; got != want {
+		t.Errorf("got paths %s, want %s", got, want)
+	}
+}
+
+var camelToSnakeTests = []struct {
+	in, want string
+}{
+	{"", ""},
+	{"id", "id"},
+	{"userId", "user_id"},
+	{"userID", "user_id"},
+	{"HTTPServer", "http_server"},
+	{"pageSize2", "page_size2"},
+	{"Already_Snake", "already_snake"},
+}
+
+func TestCamelToSnake(t *testing.T) {
+	for _, tt := range camelToSnakeTests {
+		if got := string(CamelToSnake("", []byte(tt.in))); got != tt.want {
+			t.Errorf("CamelToSnake(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
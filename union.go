@@ -0,0 +1,84 @@
+// Copyright 2014 Gary Burd. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package json
+
+import (
+	"errors"
+	"fmt"
+)
+
+// UnionDecoder decodes objects that hold one of several types selected by
+// the string value of a discriminator member:
+//
+//  u := json.NewUnionDecoder("type")
+//  u.Register("circle", func(s *json.Scanner) (interface{}, error) {
+//      var c Circle
+//      err := json.Unmarshal(s, &c)
+//      return &c, err
+//  })
+//  v, err := u.Decode(s)
+//
+// The discriminator member can appear anywhere in the object.
+type UnionDecoder struct {
+	key   string
+	funcs map[string]func(*Scanner) (interface{}, error)
+}
+
+// NewUnionDecoder returns a decoder for objects with the discriminator member
+// key.
+func NewUnionDecoder(key string) *UnionDecoder {
+	return &UnionDecoder{key: key, funcs: make(map[string]func(*Scanner) (interface{}, error))}
+}
+
+// Register sets the function to decode objects where the discriminator has
+// value typ. The scanner passed to fn is positioned at the start of the
+// object.
+func (u *UnionDecoder) Register(typ string, fn func(*Scanner) (interface{}, error)) {
+	u.funcs[typ] = fn
+}
+
+// Decode decodes the object at the scanner's current element with the
+// function registered for the value of the discriminator. The object members
+// preceding the discriminator are retained in the scanner's buffer and
+// scanned again by the registered function. If the discriminator is missing
+// or has an unregistered value, Decode returns an error and the scanner
+// remains at the start of the object. Decode replaces the scanner's mark, if
+// any.
+func (u *UnionDecoder) Decode(s *Scanner) (interface{}, error) {
+	if s.Kind() != Object {
+		return nil, fmt.Errorf("unexpected %v, expected object", s.Kind())
+	}
+	m := s.Mark()
+	typ, found := "", false
+	for s.Scan() && s.Kind() != End {
+		if s.NameIs(u.key) {
+			if s.Kind() != String {
+				s.Unmark()
+				return nil, fmt.Errorf("discriminator %s is %v, expected string", u.key, s.Kind())
+			}
+			typ, found = string(s.Value()), true
+			break
+		}
+		if err := s.Skip(); err != nil {
+			s.Unmark()
+			return nil, err
+		}
+	}
+	if err := s.Err(); err != nil {
+		s.Unmark()
+		return nil, err
+	}
+	if err := s.Rewind(m); err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, errors.New("discriminator " + u.key + " not found")
+	}
+	fn := u.funcs[typ]
+	if fn == nil {
+		return nil, fmt.Errorf("unknown %s %q", u.key, typ)
+	}
+	return fn(s)
+}
@@ -0,0 +1,92 @@
+// Copyright 2014 Gary Burd. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package json
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+type unionCircle struct {
+	Radius float64 `json:"radius"`
+}
+
+type unionSquare struct {
+	Side int `json:"side"`
+}
+
+func testUnionDecoder() *UnionDecoder {
+	u := NewUnionDecoder("type")
+	u.Register("circle", func(s *Scanner) (interface{}, error) {
+		var c unionCircle
+		err := Unmarshal(s, &c)
+		return &c, err
+	})
+	u.Register("square", func(s *Scanner) (interface{}, error) {
+		var q unionSquare
+		err := Unmarshal(s, &q)
+		return &q, err
+	})
+	return u
+}
+
+func TestUnionDecoder(t *testing.T) {
+	const input = `[{"radius": 2.5, "type": "circle"}, {"type": "square", "side": 3}]`
+	want := []interface{}{&unionCircle{2.5}, &unionSquare{3}}
+	u := testUnionDecoder()
+	for i, s := range []*Scanner{
+		NewScannerBytes([]byte(input)),
+		NewScanner(iotest.OneByteReader(strings.NewReader(input))),
+	} {
+		var got []interface{}
+		s.Scan()
+		for s.Scan() && s.Kind() != End {
+			v, err := u.Decode(s)
+			if err != nil {
+				t.Fatalf("%d: unexpected error %v", i, err)
+			}
+			got = append(got, v)
+		}
+		if err := s.Err(); err != nil {
+			t.Fatalf("%d: unexpected error %v", i, err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%d: got %v, want %v", i, got, want)
+		}
+	}
+}
+
+var unionErrorTests = []struct {
+	s   string
+	err string
+}{
+	{`[]`, "unexpected array, expected object"},
+	{`{"a": {"type": "circle"}}`, "discriminator type not found"},
+	{`{"type": 1}`, "discriminator type is number, expected string"},
+	{`{"type": "triangle"}`, `unknown type "triangle"`},
+	{`{"a": x, "type": "circle"}`, "expected start of JSON value, found 'x'"},
+}
+
+func TestUnionDecoderError(t *testing.T) {
+	u := testUnionDecoder()
+	for _, tt := range unionErrorTests {
+		s := NewScannerBytes([]byte(tt.s))
+		s.Scan()
+		_, err := u.Decode(s)
+		if err == nil || !strings.HasPrefix(err.Error(), tt.err) {
+			t.Errorf("%s: got error %v, want %s", tt.s, err, tt.err)
+		}
+	}
+
+	// The scanner remains at the start of an object with an unknown type.
+	s := NewScannerBytes([]byte(`{"type": "triangle", "n": 3}`))
+	s.Scan()
+	u.Decode(s)
+	if s.Kind() != Object || !s.Scan() || !s.NameIs("type") {
+		t.Errorf("scanner not at start of object")
+	}
+}
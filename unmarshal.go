@@ -0,0 +1,802 @@
+// Copyright 2014 Gary Burd. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package json
+
+import (
+	"bytes"
+	stdencoding "encoding"
+	stdjson "encoding/json"
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// An UnmarshalTypeError describes a JSON value that was not appropriate for
+// a value of a specific Go type.
+type UnmarshalTypeError struct {
+	Value string       // description of JSON value, "bool", "array", "number -5"
+	Type  reflect.Type // type of Go value it could not be assigned to
+}
+
+func (e *UnmarshalTypeError) Error() string {
+	return "cannot unmarshal " + e.Value + " into Go value of type " + e.Type.String()
+}
+
+// Unmarshaler is the interface implemented by types that decode themselves
+// from a Scanner. DecodeJSON is called with the scanner positioned at the
+// value. If the value is an object or array, DecodeJSON must consume the
+// value through the matching End element.
+type Unmarshaler interface {
+	DecodeJSON(s *Scanner) error
+}
+
+// An InvalidUnmarshalError describes an invalid argument passed to Unmarshal.
+// The argument to Unmarshal must be a non-nil pointer.
+type InvalidUnmarshalError struct {
+	Type reflect.Type
+}
+
+func (e *InvalidUnmarshalError) Error() string {
+	if e.Type == nil {
+		return "Unmarshal(nil)"
+	}
+	if e.Type.Kind() != reflect.Ptr {
+		return "Unmarshal(non-pointer " + e.Type.String() + ")"
+	}
+	return "Unmarshal(nil " + e.Type.String() + ")"
+}
+
+// Unmarshal decodes the current scanner value to the value pointed to by v.
+// On return, the scanner is positioned at the last element of the value.
+//
+// Unmarshal follows the rules of the encoding/json package: JSON objects are
+// decoded to structs and maps, JSON arrays are decoded to slices and arrays,
+// pointers are allocated as needed and JSON null sets pointers, interfaces,
+// maps and slices to nil. Struct fields are matched using the name in the
+// field's "json" tag or the field name if the tag is not present. Object
+// members that do not match a field are skipped. An empty interface is set to
+// the value returned by DecodeValue. The key type of a map must be a string,
+// an integer or implement encoding.TextUnmarshaler.
+//
+// A field with the "required" tag option must have a member in the object.
+// Unmarshal reports all missing members in a *MissingFieldsError. A field
+// with a "default" tag is set from the tag when the member is missing. The
+// tag is the value of a string field and JSON text for other types:
+//
+//  Port int    `json:"port" default:"8080"`
+//  Host string `json:"host" default:"localhost"`
+//  Key  string `json:"key,required"`
+//
+// If a value implements Unmarshaler, then Unmarshal calls the value's
+// DecodeJSON method. Otherwise, if the value implements the encoding/json
+// Unmarshaler interface, then Unmarshal calls the value's UnmarshalJSON
+// method with the JSON encoding of the value. The methods are not called for
+// JSON null, except for the optional types such as OptionalString. Use an
+// optional type to distinguish a null member from an absent member.
+func Unmarshal(s *Scanner, v interface{}) error {
+	return UnmarshalWith(s, v, UnmarshalOptions{})
+}
+
+// UnmarshalOptions specifies options for UnmarshalWith.
+type UnmarshalOptions struct {
+	// CaseInsensitive matches object member names to struct fields using
+	// Unicode case-folding when there is no exact match, as the
+	// encoding/json package does. By default, names must match exactly.
+	CaseInsensitive bool
+
+	// DisallowUnknownFields causes UnmarshalWith to return an
+	// *UnknownFieldError when an object has a member that does not match a
+	// field in the destination struct.
+	DisallowUnknownFields bool
+}
+
+// UnknownFieldError is returned when an object member does not match a field
+// in the destination struct and unknown fields are not allowed.
+type UnknownFieldError struct {
+	Name string       // the member name
+	Path string       // JSON Pointer to the member from the decoded value
+	Type reflect.Type // the destination struct type
+}
+
+func (e *UnknownFieldError) Error() string {
+	return "unknown field " + strconv.Quote(e.Name) + " at " + e.Path + " for Go value of type " + e.Type.String()
+}
+
+// MissingFieldsError is returned when objects do not have members for
+// struct fields with the "required" tag option. Unmarshal decodes the
+// complete value before returning the error.
+type MissingFieldsError struct {
+	Paths []string // JSON Pointers to the missing members from the decoded value
+}
+
+func (e *MissingFieldsError) Error() string {
+	return "missing required fields " + strings.Join(e.Paths, ", ")
+}
+
+// UnmarshalWith decodes the current scanner value as Unmarshal does using
+// the specified options.
+func UnmarshalWith(s *Scanner, v interface{}, opts UnmarshalOptions) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return &InvalidUnmarshalError{reflect.TypeOf(v)}
+	}
+	d := decodeState{s: s, opts: opts}
+	return d.unmarshal(rv)
+}
+
+// UnmarshalBytes decodes the JSON document in data to the value pointed to by
+// v.
+func UnmarshalBytes(data []byte, v interface{}) error {
+	s := NewScannerBytes(data)
+	if !s.Scan() {
+		if err := s.Err(); err != nil {
+			return err
+		}
+		return errors.New("unexpected end of JSON input")
+	}
+	if err := Unmarshal(s, v); err != nil {
+		return err
+	}
+	s.Scan()
+	return s.Err()
+}
+
+// DecodeArrayStream decodes the elements of the array at the scanner's
+// current element one at a time. For each element, DecodeArrayStream sets
+// the value pointed to by v to its zero value, unmarshals the element to the
+// value and calls fn. Use DecodeArrayStream to process large arrays with
+// constant memory. DecodeArrayStream stops at the first error returned by
+// fn.
+func DecodeArrayStream(s *Scanner, v interface{}, fn func() error) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return &InvalidUnmarshalError{reflect.TypeOf(v)}
+	}
+	zero := reflect.Zero(rv.Type().Elem())
+	d := decodeState{s: s}
+	return NewArrayReader(s).Read(func(i int, s *Scanner) error {
+		rv.Elem().Set(zero)
+		if err := d.unmarshal(rv.Elem()); err != nil {
+			return err
+		}
+		return fn()
+	})
+}
+
+type decodeState struct {
+	s       *Scanner
+	opts    UnmarshalOptions
+	path    []PathElement // path to the current value
+	names   [][]byte      // names of path elements with index pathName
+	missing []string      // paths of missing required members
+}
+
+// pathName is the index of a path element for an object member whose name
+// is stored in decodeState.names. The name is converted to a string only when
+// a path is returned in an error.
+const pathName = -2
+
+// unmarshal decodes the current value to v and reports missing required
+// members.
+func (d *decodeState) unmarshal(v reflect.Value) error {
+	d.path = d.path[:0]
+	d.missing = nil
+	m := d.s.startValue()
+	if err := d.s.endValue(m, d.value(v)); err != nil {
+		return err
+	}
+	if len(d.missing) > 0 {
+		return &MissingFieldsError{Paths: d.missing}
+	}
+	return nil
+}
+
+// pathTo returns the JSON Pointer to the element e of the current value.
+func (d *decodeState) pathTo(e PathElement) string {
+	path := make([]PathElement, 0, len(d.path)+1)
+	for i, p := range d.path {
+		if p.Index == pathName {
+			p = PathElement{Name: string(d.names[i]), Index: -1}
+		}
+		path = append(path, p)
+	}
+	return pathString(append(path, e))
+}
+
+// pushName appends a path element for the object member name p without
+// converting the name to a string.
+func (d *decodeState) pushName(p []byte) {
+	i := len(d.path)
+	d.path = append(d.path, PathElement{Index: pathName})
+	for len(d.names) <= i {
+		d.names = append(d.names, nil)
+	}
+	d.names[i] = append(d.names[i][:0], p...)
+}
+
+var numberValueType = reflect.TypeOf(NumberValue(""))
+
+func (d *decodeState) typeError(v reflect.Value) error {
+	desc := d.s.Kind().String()
+	if d.s.Kind() == Number {
+		desc += " " + string(d.s.Value())
+	}
+	return &UnmarshalTypeError{desc, v.Type()}
+}
+
+// indirect allocates pointers as needed and returns the value to decode to.
+func (d *decodeState) indirect(v reflect.Value) reflect.Value {
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		v = v.Elem()
+	}
+	return v
+}
+
+func (d *decodeState) value(v reflect.Value) error {
+	s := d.s
+
+	if s.Kind() == Null {
+		if v.CanAddr() {
+			if u, ok := v.Addr().Interface().(nullUnmarshaler); ok {
+				return u.DecodeJSON(s)
+			}
+		}
+		for v.Kind() == reflect.Ptr && !v.IsNil() && v.Elem().Kind() == reflect.Ptr {
+			v = v.Elem()
+		}
+		switch v.Kind() {
+		case reflect.Ptr, reflect.Interface, reflect.Map, reflect.Slice:
+			v.Set(reflect.Zero(v.Type()))
+		}
+		return nil
+	}
+
+	v = d.indirect(v)
+
+	if v.CanAddr() {
+		switch u := v.Addr().Interface().(type) {
+		case Unmarshaler:
+			return u.DecodeJSON(s)
+		case stdjson.Unmarshaler:
+			var buf bytes.Buffer
+			if err := copyValue(NewWriter(&buf), s); err != nil {
+				return err
+			}
+			return u.UnmarshalJSON(buf.Bytes())
+		}
+	}
+
+	if v.Kind() == reflect.Interface && v.NumMethod() == 0 {
+		x, err := DecodeValue(s)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(&x).Elem())
+		return nil
+	}
+
+	switch s.Kind() {
+	case Object:
+		switch v.Kind() {
+		case reflect.Struct:
+			return d.object(v)
+		case reflect.Map:
+			if mapKeyOK(v.Type().Key()) {
+				return d.mapObject(v)
+			}
+		}
+	case Array:
+		switch v.Kind() {
+		case reflect.Slice:
+			return d.slice(v)
+		case reflect.Array:
+			return d.array(v)
+		}
+	case String:
+		if v.Kind() == reflect.String && v.Type() != numberValueType {
+			v.SetString(string(s.Value()))
+			return nil
+		}
+		if v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8 {
+			b, err := s.BytesValue()
+			if err != nil {
+				return err
+			}
+			v.SetBytes(b)
+			return nil
+		}
+	case Number:
+		return d.number(v)
+	case Bool:
+		if v.Kind() == reflect.Bool {
+			v.SetBool(s.Value()[0] == 't')
+			return nil
+		}
+	}
+	err := d.typeError(v)
+	if e := s.Skip(); e != nil {
+		return e
+	}
+	return err
+}
+
+// copyValue copies the scanner's current value to w.
+func copyValue(w *Writer, s *Scanner) error {
+	switch s.Kind() {
+	case Object:
+		w.StartObject()
+		n := s.NestingLevel()
+		for s.ScanAtLevel(n) {
+			w.NameBytes(s.Name())
+			if err := copyValue(w, s); err != nil {
+				return err
+			}
+		}
+		if err := s.Err(); err != nil {
+			return err
+		}
+		return w.EndObject()
+	case Array:
+		w.StartArray()
+		n := s.NestingLevel()
+		for s.ScanAtLevel(n) {
+			if err := copyValue(w, s); err != nil {
+				return err
+			}
+		}
+		if err := s.Err(); err != nil {
+			return err
+		}
+		return w.EndArray()
+	case String:
+		return w.StringBytes(s.Value())
+	case Number:
+		return w.Raw(s.Value())
+	case Bool:
+		return w.Bool(s.Value()[0] == 't')
+	default:
+		return w.Null()
+	}
+}
+
+func (d *decodeState) number(v reflect.Value) error {
+	p := d.s.Value()
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(string(p), 10, 64)
+		if err != nil || v.OverflowInt(n) {
+			return d.typeError(v)
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		n, err := strconv.ParseUint(string(p), 10, 64)
+		if err != nil || v.OverflowUint(n) {
+			return d.typeError(v)
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(string(p), v.Type().Bits())
+		if err != nil || v.OverflowFloat(n) {
+			return d.typeError(v)
+		}
+		v.SetFloat(n)
+	case reflect.String:
+		if v.Type() != numberValueType {
+			return d.typeError(v)
+		}
+		v.SetString(string(p))
+	default:
+		return d.typeError(v)
+	}
+	return nil
+}
+
+func (d *decodeState) object(v reflect.Value) error {
+	s := d.s
+	fields := cachedFields(v.Type())
+	var seen []bool
+	if fields.checks {
+		seen = make([]bool, len(fields.list))
+	}
+	n := s.NestingLevel()
+	for s.ScanAtLevel(n) {
+		f, ok := fields.byName[string(s.Name())]
+		name := ""
+		if ok {
+			name = f.name
+		} else if d.opts.CaseInsensitive {
+			f, ok = fields.fold(s)
+			name = s.NameString()
+		}
+		if !ok {
+			if d.opts.DisallowUnknownFields {
+				name = s.NameString()
+				return &UnknownFieldError{Name: name, Path: d.pathTo(PathElement{Name: name, Index: -1}), Type: v.Type()}
+			}
+			if err := s.Skip(); err != nil {
+				return err
+			}
+			continue
+		}
+		if seen != nil {
+			seen[f.n] = true
+		}
+		d.path = append(d.path, PathElement{Name: name, Index: -1})
+		fv, err := fieldByIndex(v, f.index)
+		if err != nil {
+			return err
+		}
+		if err := d.value(fv); err != nil {
+			return err
+		}
+		d.path = d.path[:len(d.path)-1]
+	}
+	if err := s.Err(); err != nil {
+		return err
+	}
+	for i := range seen {
+		f := &fields.list[i]
+		switch {
+		case seen[i]:
+		case f.required:
+			d.missing = append(d.missing, d.pathTo(PathElement{Name: f.name, Index: -1}))
+		case f.hasDefault:
+			fv, err := fieldByIndex(v, f.index)
+			if err != nil {
+				return err
+			}
+			if err := setDefault(fv, f.def); err != nil {
+				return errors.New("invalid default for field " + f.name + " of " + v.Type().String() + ": " + err.Error())
+			}
+		}
+	}
+	return nil
+}
+
+// setDefault sets v to the value in a default tag. The tag is the value of
+// a string and JSON text for other types.
+func setDefault(v reflect.Value, def string) error {
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		v = v.Elem()
+	}
+	if v.Kind() == reflect.String && v.Type() != numberValueType {
+		v.SetString(def)
+		return nil
+	}
+	s := NewScannerBytes([]byte(def))
+	if !s.Scan() {
+		if err := s.Err(); err != nil {
+			return err
+		}
+		return errors.New("empty default")
+	}
+	d := decodeState{s: s}
+	if err := d.value(v); err != nil {
+		return err
+	}
+	s.Scan()
+	return s.Err()
+}
+
+// fieldByIndex returns the nested field of v with the given index sequence,
+// allocating embedded struct pointers as needed. A nil pointer to an
+// unexported embedded struct cannot be allocated.
+func fieldByIndex(v reflect.Value, index []int) (reflect.Value, error) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				if !v.CanSet() {
+					return reflect.Value{}, errors.New("cannot set embedded pointer to unexported struct " + v.Type().Elem().String())
+				}
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, nil
+}
+
+func (d *decodeState) mapObject(v reflect.Value) error {
+	s := d.s
+	t := v.Type()
+	if v.IsNil() {
+		v.Set(reflect.MakeMap(t))
+	}
+	elem := reflect.New(t.Elem()).Elem()
+	n := s.NestingLevel()
+	kt := t.Key()
+	text := reflect.PtrTo(kt).Implements(textUnmarshalerType)
+	for s.ScanAtLevel(n) {
+		key := reflect.New(kt).Elem()
+		switch {
+		case text:
+			d.pushName(s.Name())
+			if err := key.Addr().Interface().(stdencoding.TextUnmarshaler).UnmarshalText(s.Name()); err != nil {
+				return err
+			}
+		case kt.Kind() == reflect.String:
+			name := s.NameString()
+			d.path = append(d.path, PathElement{Name: name, Index: -1})
+			key.SetString(name)
+		default:
+			d.pushName(s.Name())
+			if err := setIntKey(key, s.Name()); err != nil {
+				return err
+			}
+		}
+		elem.Set(reflect.Zero(t.Elem()))
+		if err := d.value(elem); err != nil {
+			return err
+		}
+		d.path = d.path[:len(d.path)-1]
+		v.SetMapIndex(key, elem)
+	}
+	return s.Err()
+}
+
+var textUnmarshalerType = reflect.TypeOf((*stdencoding.TextUnmarshaler)(nil)).Elem()
+
+// mapKeyOK returns true if objects can be decoded to maps with key type t.
+func mapKeyOK(t reflect.Type) bool {
+	if reflect.PtrTo(t).Implements(textUnmarshalerType) {
+		return true
+	}
+	switch t.Kind() {
+	case reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return true
+	}
+	return false
+}
+
+// setIntKey sets the integer map key v from the member name p.
+func setIntKey(v reflect.Value, p []byte) error {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		q := p
+		neg := len(q) > 0 && q[0] == '-'
+		if neg {
+			q = q[1:]
+		}
+		u, ok := parseUint64(q)
+		n := int64(u)
+		if neg {
+			n = -n
+		}
+		if ok && (u <= 1<<63-1 || neg && u == 1<<63) && !v.OverflowInt(n) {
+			v.SetInt(n)
+			return nil
+		}
+	default:
+		u, ok := parseUint64(p)
+		if ok && !v.OverflowUint(u) {
+			v.SetUint(u)
+			return nil
+		}
+	}
+	return &UnmarshalTypeError{"number " + string(p), v.Type()}
+}
+
+func (d *decodeState) slice(v reflect.Value) error {
+	s := d.s
+	i := 0
+	n := s.NestingLevel()
+	for s.ScanAtLevel(n) {
+		if i >= v.Cap() {
+			newcap := v.Cap() + v.Cap()/2
+			if newcap < 4 {
+				newcap = 4
+			}
+			newv := reflect.MakeSlice(v.Type(), v.Len(), newcap)
+			reflect.Copy(newv, v)
+			v.Set(newv)
+		}
+		if i >= v.Len() {
+			v.SetLen(i + 1)
+		}
+		d.path = append(d.path, PathElement{Index: i})
+		if err := d.value(v.Index(i)); err != nil {
+			return err
+		}
+		d.path = d.path[:len(d.path)-1]
+		i++
+	}
+	if err := s.Err(); err != nil {
+		return err
+	}
+	if i < v.Len() {
+		v.SetLen(i)
+	}
+	if i == 0 && v.IsNil() {
+		v.Set(reflect.MakeSlice(v.Type(), 0, 0))
+	}
+	return nil
+}
+
+func (d *decodeState) array(v reflect.Value) error {
+	s := d.s
+	i := 0
+	n := s.NestingLevel()
+	for s.ScanAtLevel(n) {
+		if i < v.Len() {
+			d.path = append(d.path, PathElement{Index: i})
+			if err := d.value(v.Index(i)); err != nil {
+				return err
+			}
+			d.path = d.path[:len(d.path)-1]
+		} else if err := s.Skip(); err != nil {
+			return err
+		}
+		i++
+	}
+	if err := s.Err(); err != nil {
+		return err
+	}
+	for ; i < v.Len(); i++ {
+		v.Index(i).Set(reflect.Zero(v.Type().Elem()))
+	}
+	return nil
+}
+
+// field represents a struct field used for decoding.
+type field struct {
+	name       string
+	index      []int
+	n          int  // index in structFields.list
+	required   bool // if true, the member must be present.
+	hasDefault bool // if true, def is applied when the member is missing.
+	def        string
+}
+
+type structFields struct {
+	list   []field
+	byName map[string]*field
+	checks bool // if true, a field is required or has a default.
+}
+
+// fold returns the first field with a name equal to the current member name
+// under Unicode case-folding.
+func (fields *structFields) fold(s *Scanner) (*field, bool) {
+	for i := range fields.list {
+		if s.NameEqualFold(fields.list[i].name) {
+			return &fields.list[i], true
+		}
+	}
+	return nil, false
+}
+
+var fieldCache struct {
+	sync.RWMutex
+	m map[reflect.Type]*structFields
+}
+
+func cachedFields(t reflect.Type) *structFields {
+	fieldCache.RLock()
+	f := fieldCache.m[t]
+	fieldCache.RUnlock()
+	if f != nil {
+		return f
+	}
+	f = typeFields(t)
+	fieldCache.Lock()
+	if fieldCache.m == nil {
+		fieldCache.m = make(map[reflect.Type]*structFields)
+	}
+	fieldCache.m[t] = f
+	fieldCache.Unlock()
+	return f
+}
+
+// typeFields returns the fields that JSON should recognize for the given
+// type. Fields of embedded structs are promoted using the Go visibility
+// rules amended for JSON: a tagged field wins over untagged fields at the
+// same depth and fields with the same name at the same depth cancel.
+func typeFields(t reflect.Type) *structFields {
+	type candidate struct {
+		field
+		tagged bool
+	}
+
+	var candidates []candidate
+
+	var walk func(t reflect.Type, index []int, visited map[reflect.Type]bool)
+	walk = func(t reflect.Type, index []int, visited map[reflect.Type]bool) {
+		if visited[t] {
+			return
+		}
+		visited[t] = true
+		defer delete(visited, t)
+		for i := 0; i < t.NumField(); i++ {
+			sf := t.Field(i)
+			tag := sf.Tag.Get("json")
+			if tag == "-" {
+				continue
+			}
+			name, opts := tag, ""
+			if i := strings.Index(tag, ","); i >= 0 {
+				name, opts = tag[:i], tag[i:]
+			}
+			ft := sf.Type
+			if ft.Name() == "" && ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if sf.Anonymous && name == "" && ft.Kind() == reflect.Struct {
+				walk(ft, append(index[:len(index):len(index)], i), visited)
+				continue
+			}
+			if sf.PkgPath != "" {
+				continue
+			}
+			tagged := name != ""
+			if !tagged {
+				name = sf.Name
+			}
+			def, hasDefault := sf.Tag.Lookup("default")
+			candidates = append(candidates, candidate{
+				field{
+					name:       name,
+					index:      append(index[:len(index):len(index)], i),
+					required:   strings.Contains(opts+",", ",required,"),
+					hasDefault: hasDefault,
+					def:        def,
+				},
+				tagged,
+			})
+		}
+	}
+	walk(t, nil, map[reflect.Type]bool{})
+
+	// Select the dominant field for each name.
+	byName := map[string][]int{}
+	var names []string
+	for i, c := range candidates {
+		if byName[c.name] == nil {
+			names = append(names, c.name)
+		}
+		byName[c.name] = append(byName[c.name], i)
+	}
+
+	f := &structFields{byName: make(map[string]*field)}
+	for _, name := range names {
+		var all, tagged []int
+		for _, i := range byName[name] {
+			c := candidates[i]
+			if len(all) > 0 && len(c.index) > len(candidates[all[0]].index) {
+				continue
+			}
+			if len(all) > 0 && len(c.index) < len(candidates[all[0]].index) {
+				all, tagged = nil, nil
+			}
+			all = append(all, i)
+			if c.tagged {
+				tagged = append(tagged, i)
+			}
+		}
+		switch {
+		case len(tagged) == 1:
+			f.list = append(f.list, candidates[tagged[0]].field)
+		case len(all) == 1:
+			f.list = append(f.list, candidates[all[0]].field)
+		}
+	}
+	for i := range f.list {
+		f.list[i].n = i
+		f.byName[f.list[i].name] = &f.list[i]
+		if f.list[i].required || f.list[i].hasDefault {
+			f.checks = true
+		}
+	}
+	return f
+}
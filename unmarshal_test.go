@@ -0,0 +1,367 @@
+// Copyright 2014 Gary Burd. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package json
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type unmarshalEmbedded struct {
+	E string
+	F string `json:"f"`
+}
+
+type unmarshalStruct struct {
+	unmarshalEmbedded
+	A   int
+	B   string `json:"b"`
+	C   []int  `json:"c,omitempty"`
+	D   *bool
+	M   map[string]float64
+	I   interface{}
+	N   NumberValue
+	P   **uint8
+	Arr [2]string
+	X   string `json:"-"`
+	y   string
+}
+
+// decoderPoint implements Unmarshaler by decoding a two element array.
+type decoderPoint struct{ X, Y int64 }
+
+func (p *decoderPoint) DecodeJSON(s *Scanner) error {
+	n := s.NestingLevel()
+	for _, v := range []*int64{&p.X, &p.Y} {
+		if !s.ScanAtLevel(n) {
+			return s.Err()
+		}
+		var err error
+		if *v, err = s.Int64(); err != nil {
+			return err
+		}
+	}
+	s.ScanAtLevel(n)
+	return s.Err()
+}
+
+// stdUnmarshaler implements the encoding/json Unmarshaler interface.
+type stdUnmarshaler struct{ raw string }
+
+func (u *stdUnmarshaler) UnmarshalJSON(p []byte) error {
+	u.raw = string(p)
+	return nil
+}
+
+type unmarshalerStruct struct {
+	P  decoderPoint
+	PP *decoderPoint
+	S  stdUnmarshaler
+	A  []int
+}
+
+func ptrUint8(v uint8) **uint8 {
+	p := &v
+	return &p
+}
+
+var unmarshalTests = []struct {
+	s   string
+	ptr interface{}
+	v   interface{}
+	err error
+}{
+	{s: `1`, ptr: new(int), v: 1},
+	{s: `-1`, ptr: new(int8), v: int8(-1)},
+	{s: `1.5`, ptr: new(float64), v: 1.5},
+	{s: `1.5`, ptr: new(NumberValue), v: NumberValue("1.5")},
+	{s: `"a"`, ptr: new(string), v: "a"},
+	{s: `true`, ptr: new(bool), v: true},
+	{s: `"aGVsbG8="`, ptr: new([]byte), v: []byte("hello")},
+	{s: `null`, ptr: new(*int), v: (*int)(nil)},
+	{s: `[1,2,3]`, ptr: new([]int), v: []int{1, 2, 3}},
+	{s: `[]`, ptr: new([]int), v: []int{}},
+	{s: `[1,2,3]`, ptr: new([2]int), v: [2]int{1, 2}},
+	{s: `{"a":1,"b":2}`, ptr: new(map[string]int), v: map[string]int{"a": 1, "b": 2}},
+	{s: `{"-9223372036854775808":1,"2":2}`, ptr: new(map[int64]int), v: map[int64]int{-1 << 63: 1, 2: 2}},
+	{s: `{"255":1}`, ptr: new(map[uint8]int), v: map[uint8]int{255: 1}},
+	{s: `{"a-b":1}`, ptr: new(map[textKey]int), v: map[textKey]int{{"a", "b"}: 1}},
+	{s: `{"a-b":1,"c":2}`, ptr: new(map[textKey]int), v: map[textKey]int{{"a", "b"}: 1, {"c", ""}: 2}},
+	{s: `{"a":[1,"x"]}`, ptr: new(interface{}), v: map[string]interface{}{"a": []interface{}{NumberValue("1"), "x"}}},
+	{
+		s: `{"A":1,"b":"x","c":[1,2],"D":true,"M":{"z":0.5},"I":null,"N":12,"P":3,
+			"Arr":["p","q","r"],"X":"ignored","y":"ignored","E":"e","f":"f","unknown":{"a":[]}}`,
+		ptr: new(unmarshalStruct),
+		v: unmarshalStruct{
+			unmarshalEmbedded: unmarshalEmbedded{E: "e", F: "f"},
+			A:                 1,
+			B:                 "x",
+			C:                 []int{1, 2},
+			D:                 func() *bool { b := true; return &b }(),
+			M:                 map[string]float64{"z": 0.5},
+			N:                 "12",
+			P:                 ptrUint8(3),
+			Arr:               [2]string{"p", "q"},
+		},
+	},
+	{
+		s:   `{"P":[1,2],"PP":[3,4],"S":{"a" : [1, "\u0041", true, null]},"A":[5]}`,
+		ptr: new(unmarshalerStruct),
+		v:   unmarshalerStruct{P: decoderPoint{1, 2}, PP: &decoderPoint{3, 4}, S: stdUnmarshaler{`{"a":[1,"A",true,null]}`}, A: []int{5}},
+	},
+
+	{s: `256`, ptr: new(uint8), err: &UnmarshalTypeError{"number 256", reflect.TypeOf(uint8(0))}},
+	{s: `1.5`, ptr: new(int), err: &UnmarshalTypeError{"number 1.5", reflect.TypeOf(0)}},
+	{s: `"a"`, ptr: new(int), err: &UnmarshalTypeError{"string", reflect.TypeOf(0)}},
+	{s: `{"A":[1]}`, ptr: new(unmarshalStruct), err: &UnmarshalTypeError{"array", reflect.TypeOf(0)}},
+	{s: `{"a":1}`, ptr: new(map[float64]int), err: &UnmarshalTypeError{"object", reflect.TypeOf(map[float64]int{})}},
+	{s: `{"a":1}`, ptr: new(map[int]int), err: &UnmarshalTypeError{"number a", reflect.TypeOf(0)}},
+	{s: `{"128":1}`, ptr: new(map[int8]int), err: &UnmarshalTypeError{"number 128", reflect.TypeOf(int8(0))}},
+	{s: `{"-1":1}`, ptr: new(map[uint]int), err: &UnmarshalTypeError{"number -1", reflect.TypeOf(uint(0))}},
+	{s: `[1,x]`, ptr: new([]int), err: &SyntaxError{}},
+}
+
+// textKey implements encoding.TextUnmarshaler. UnmarshalText sets b only if
+// the text contains '-'.
+type textKey struct{ a, b string }
+
+func (k *textKey) UnmarshalText(p []byte) error {
+	i := bytes.IndexByte(p, '-')
+	if i < 0 {
+		k.a = string(p)
+		return nil
+	}
+	k.a, k.b = string(p[:i]), string(p[i+1:])
+	return nil
+}
+
+func TestUnmarshal(t *testing.T) {
+	for _, tt := range unmarshalTests {
+		err := UnmarshalBytes([]byte(tt.s), tt.ptr)
+		if tt.err != nil {
+			if reflect.TypeOf(err) != reflect.TypeOf(tt.err) {
+				t.Errorf("%s: got error %v, want %T", tt.s, err, tt.err)
+			} else if _, ok := tt.err.(*UnmarshalTypeError); ok && !reflect.DeepEqual(err, tt.err) {
+				t.Errorf("%s: got error %v, want %v", tt.s, err, tt.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", tt.s, err)
+			continue
+		}
+		if got := reflect.ValueOf(tt.ptr).Elem().Interface(); !reflect.DeepEqual(got, tt.v) {
+			t.Errorf("%s:\n got %#v\nwant %#v", tt.s, got, tt.v)
+		}
+	}
+}
+
+func TestUnmarshalInvalid(t *testing.T) {
+	var i int
+	for _, v := range []interface{}{nil, i, (*int)(nil)} {
+		if err := UnmarshalBytes([]byte(`1`), v); err == nil {
+			t.Errorf("UnmarshalBytes(%T) returned nil error", v)
+		} else if _, ok := err.(*InvalidUnmarshalError); !ok {
+			t.Errorf("UnmarshalBytes(%T) returned %T, want *InvalidUnmarshalError", v, err)
+		}
+	}
+}
+
+func TestUnmarshalScanner(t *testing.T) {
+	s := NewScannerBytes([]byte(`[{"A":1,"b":"x"},{"A":2,"b":"y"}]`))
+	if !s.Scan() || s.Kind() != Array {
+		t.Fatal("expected array")
+	}
+	var got []unmarshalStruct
+	n := s.NestingLevel()
+	for s.ScanAtLevel(n) {
+		var v unmarshalStruct
+		if err := Unmarshal(s, &v); err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, v)
+	}
+	if err := s.Err(); err != nil {
+		t.Fatal(err)
+	}
+	want := []unmarshalStruct{{A: 1, B: "x"}, {A: 2, B: "y"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestDecodeArrayStream(t *testing.T) {
+	s := NewScanner(readerOnly{strings.NewReader(`[{"A":1,"b":"x"},{"A":2},null]`)})
+	if !s.Scan() {
+		t.Fatal(s.Err())
+	}
+	var v unmarshalStruct
+	var got []unmarshalStruct
+	err := DecodeArrayStream(s, &v, func() error {
+		got = append(got, v)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []unmarshalStruct{{A: 1, B: "x"}, {A: 2}, {}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	s = NewScannerBytes([]byte(`[1,2,3]`))
+	s.Scan()
+	var i int
+	stop := errors.New("stop")
+	err = DecodeArrayStream(s, &i, func() error {
+		if i == 2 {
+			return stop
+		}
+		return nil
+	})
+	if err != stop {
+		t.Errorf("got error %v, want %v", err, stop)
+	}
+}
+
+type unmarshalHidden struct{ X int }
+
+type unmarshalHiddenPtr struct {
+	*unmarshalHidden
+	Y int
+}
+
+func TestUnmarshalUnexportedEmbeddedPtr(t *testing.T) {
+	var v unmarshalHiddenPtr
+	err := UnmarshalBytes([]byte(`{"X":1,"Y":2}`), &v)
+	if err == nil || !strings.Contains(err.Error(), "unexported struct") {
+		t.Errorf("nil pointer: got error %v, want unexported struct error", err)
+	}
+
+	v = unmarshalHiddenPtr{unmarshalHidden: &unmarshalHidden{}}
+	if err := UnmarshalBytes([]byte(`{"X":1,"Y":2}`), &v); err != nil {
+		t.Fatal(err)
+	}
+	if v.X != 1 || v.Y != 2 {
+		t.Errorf("got X=%d Y=%d, want X=1 Y=2", v.X, v.Y)
+	}
+
+	v = unmarshalHiddenPtr{}
+	if err := UnmarshalBytes([]byte(`{"Y":2}`), &v); err != nil || v.Y != 2 {
+		t.Errorf("got Y=%d, %v, want Y=2", v.Y, err)
+	}
+}
+
+func TestUnmarshalCaseInsensitive(t *testing.T) {
+	const doc = `{"a": 1, "B": "x", "ARR": ["p", "q"]}`
+	for _, fold := range []bool{false, true} {
+		d := NewDecoder(strings.NewReader(doc))
+		d.CaseInsensitive(fold)
+		var got unmarshalStruct
+		if err := d.Decode(&got); err != nil {
+			t.Fatal(err)
+		}
+		want := unmarshalStruct{}
+		if fold {
+			want = unmarshalStruct{A: 1, B: "x", Arr: [2]string{"p", "q"}}
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("fold=%v: got %+v, want %+v", fold, got, want)
+		}
+	}
+
+	// An exact match takes precedence over a case-folded match.
+	var v struct {
+		Name  string
+		NAME2 string `json:"name"`
+	}
+	s := NewScannerBytes([]byte(`{"name": "x"}`))
+	s.Scan()
+	if err := UnmarshalWith(s, &v, UnmarshalOptions{CaseInsensitive: true}); err != nil {
+		t.Fatal(err)
+	}
+	if v.Name != "" || v.NAME2 != "x" {
+		t.Errorf("got %+v, want exact match", v)
+	}
+}
+
+func TestDisallowUnknownFields(t *testing.T) {
+	var v struct {
+		A []unmarshalStruct
+		M map[string]unmarshalStruct
+		I map[int]unmarshalStruct
+		T map[textKey]unmarshalStruct
+	}
+	for _, tt := range []struct {
+		s    string
+		path string
+	}{
+		{`{"A": [{"A": 1}, {"A": 2}], "M": {"k": {"b": "x"}}}`, ""},
+		{`{"A": [{"A": 1}, {"Z": 2}]}`, "/A/1/Z"},
+		{`{"M": {"a/b": {"Z": 2}}}`, "/M/a~1b/Z"},
+		{`{"I": {"7": {"A": 1}, "8": {"Z": 2}}}`, "/I/8/Z"},
+		{`{"T": {"a-b": {"Z": 2}}}`, "/T/a-b/Z"},
+		{`{"Z": 1}`, "/Z"},
+	} {
+		d := NewDecoder(strings.NewReader(tt.s))
+		d.DisallowUnknownFields()
+		err := d.Decode(&v)
+		if tt.path == "" {
+			if err != nil {
+				t.Errorf("%s: unexpected error %v", tt.s, err)
+			}
+			continue
+		}
+		e, ok := err.(*UnknownFieldError)
+		if !ok {
+			t.Errorf("%s: got error %v, want *UnknownFieldError", tt.s, err)
+			continue
+		}
+		if e.Name != "Z" || e.Path != tt.path {
+			t.Errorf("%s: got name %q, path %q, want Z, %q", tt.s, e.Name, e.Path, tt.path)
+		}
+	}
+}
+
+type configServer struct {
+	Host  string   `json:"host" default:"localhost"`
+	Port  *int     `json:"port" default:"8080"`
+	Tags  []string `default:"[\"a\",\"b\"]"`
+	Key   string   `json:"key,required"`
+	Other string   `json:",required,omitempty"`
+}
+
+func TestUnmarshalRequiredDefault(t *testing.T) {
+	var v struct {
+		Servers []configServer `json:"servers"`
+		Main    configServer   `json:"main,required"`
+	}
+	err := UnmarshalBytes([]byte(`{"servers": [{"key": "k", "Other": ""}, {"host": "h", "Tags": [], "Other": ""}]}`), &v)
+	e, ok := err.(*MissingFieldsError)
+	if !ok {
+		t.Fatalf("got error %v, want *MissingFieldsError", err)
+	}
+	if want := []string{"/servers/1/key", "/main"}; !reflect.DeepEqual(e.Paths, want) {
+		t.Errorf("got paths %q, want %q", e.Paths, want)
+	}
+	port := 8080
+	want := []configServer{
+		{Host: "localhost", Port: &port, Tags: []string{"a", "b"}, Key: "k"},
+		{Host: "h", Port: &port, Tags: []string{}},
+	}
+	if !reflect.DeepEqual(v.Servers, want) {
+		t.Errorf("got %+v, want %+v", v.Servers, want)
+	}
+
+	var bad struct {
+		N int `default:"x"`
+	}
+	if err := UnmarshalBytes([]byte(`{}`), &bad); err == nil {
+		t.Error("invalid default did not return error")
+	}
+}
@@ -0,0 +1,27 @@
+// Copyright 2014 Gary Burd. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package json
+
+import (
+	"io"
+)
+
+// Valid returns true if data is a single valid JSON value.
+func Valid(data []byte) bool {
+	return validate(NewScannerBytes(data)) == nil
+}
+
+// Validate reads r to the end and returns nil if the input is a single valid
+// JSON value. Syntax errors are returned as a *SyntaxError with the position
+// of the error.
+func Validate(r io.Reader) error {
+	return validate(NewScanner(r))
+}
+
+func validate(s *Scanner) error {
+	for s.Scan() {
+	}
+	return s.Err()
+}
@@ -0,0 +1,45 @@
+// Copyright 2014 Gary Burd. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package json
+
+import (
+	"strings"
+	"testing"
+)
+
+var validTests = []struct {
+	in    string
+	valid bool
+}{
+	{`{"a":[1,2.5e3,true,false,null,"x"]}`, true},
+	{` 1 `, true},
+	{``, false},
+	{`1 2`, false},
+	{`{"a":}`, false},
+	{`[1,]`, false},
+	{`"\x"`, false},
+}
+
+func TestValid(t *testing.T) {
+	for _, tt := range validTests {
+		if valid := Valid([]byte(tt.in)); valid != tt.valid {
+			t.Errorf("Valid(%q) = %v, want %v", tt.in, valid, tt.valid)
+		}
+		if err := Validate(strings.NewReader(tt.in)); (err == nil) != tt.valid {
+			t.Errorf("Validate(%q) = %v, want valid %v", tt.in, err, tt.valid)
+		}
+	}
+}
+
+func TestValidateSyntaxError(t *testing.T) {
+	err := Validate(strings.NewReader("[1,\n 2 x]"))
+	e, ok := err.(*SyntaxError)
+	if !ok {
+		t.Fatalf("got %v, want *SyntaxError", err)
+	}
+	if e.Line != 2 || e.Column != 4 {
+		t.Errorf("got line %d, column %d, want line 2, column 4", e.Line, e.Column)
+	}
+}
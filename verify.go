@@ -0,0 +1,107 @@
+// Copyright 2014 Gary Burd. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package json
+
+import (
+	"io"
+)
+
+// VerifyingWriter checks that the data written through it is valid JSON.
+// Use a VerifyingWriter under a Writer in tests to catch encoders that
+// produce invalid output, for example through Raw:
+//
+//  vw := json.NewVerifyingWriter(&buf)
+//  w := json.NewWriter(vw)
+//  err := encode(w)
+//  ...
+//  if err := vw.Close(); err != nil {
+//      t.Fatal(err)
+//  }
+//
+// The Writer buffers output, so invalid output is detected when the Writer
+// flushes at the end of a top-level value or when Flush is called. Invalid
+// output is not written to the underlying writer.
+type VerifyingWriter struct {
+	w        io.Writer
+	s        *Scanner
+	disabled bool
+	panic    bool
+	err      error
+}
+
+// NewVerifyingWriter returns a verifying writer that writes to w. The writer
+// accepts a stream of top-level values separated by whitespace until
+// SetFraming is called.
+func NewVerifyingWriter(w io.Writer) *VerifyingWriter {
+	s := NewScannerPush()
+	s.SetFraming(FrameConcatenated)
+	s.SetMaxDepth(-1)
+	return &VerifyingWriter{w: w, s: s}
+}
+
+// SetFraming sets the framing of the verified output. Set the same framing
+// as the Writer. SetFraming must be called before the first call to Write.
+func (v *VerifyingWriter) SetFraming(f Framing) {
+	v.s.SetFraming(f)
+}
+
+// SetEnabled sets whether the writer verifies the output. A disabled writer
+// passes output to the underlying writer unchecked. Disable verification in
+// production to avoid the cost of scanning the output.
+func (v *VerifyingWriter) SetEnabled(enabled bool) {
+	v.disabled = !enabled
+}
+
+// SetPanic sets whether the writer panics with the error when the output is
+// not valid JSON. Use SetPanic to stop a test at the encoder bug.
+func (v *VerifyingWriter) SetPanic(on bool) {
+	v.panic = on
+}
+
+// Write verifies p and writes p to the underlying writer. If the output is
+// not valid JSON, then Write returns the *SyntaxError or *LimitError from
+// scanning the output. The error is also returned from subsequent calls.
+func (v *VerifyingWriter) Write(p []byte) (int, error) {
+	if v.disabled {
+		return v.w.Write(p)
+	}
+	if v.err != nil {
+		return 0, v.err
+	}
+	if _, err := v.s.Write(p); err != nil {
+		v.err = err
+		return 0, err
+	}
+	if !v.verify() {
+		return 0, v.err
+	}
+	return v.w.Write(p)
+}
+
+// Close checks that the output does not end with an incomplete value. Close
+// does not close the underlying writer.
+func (v *VerifyingWriter) Close() error {
+	if v.disabled || v.err != nil {
+		return v.err
+	}
+	v.s.CloseInput()
+	v.verify()
+	return v.err
+}
+
+// verify scans the output written to the scanner and records the first
+// error.
+func (v *VerifyingWriter) verify() bool {
+	for v.s.Scan() {
+	}
+	if err := v.s.Err(); err != nil {
+		v.err = err
+		if v.panic {
+			panic(err)
+		}
+		return false
+	}
+	return true
+}
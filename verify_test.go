@@ -0,0 +1,111 @@
+// Copyright 2014 Gary Burd. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package json
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"testing"
+)
+
+var verifyingWriterTests = []struct {
+	write func(w *Writer) error
+	out   string
+	err   bool // error from writer
+	close bool // error from Close
+}{
+	{func(w *Writer) error {
+		w.StartObject()
+		w.Name("a")
+		w.Raw([]byte(`[1, 2]`))
+		w.EndObject()
+		return w.EndDocument()
+	}, "{\"a\":[1, 2]}\n", false, false},
+	{func(w *Writer) error {
+		w.Int(1)
+		w.EndDocument()
+		w.String("x")
+		return w.EndDocument()
+	}, "1\n\"x\"\n", false, false},
+	{func(w *Writer) error {
+		w.Raw([]byte(`{"a":}`))
+		return w.EndDocument()
+	}, "", true, false},
+	{func(w *Writer) error {
+		w.StartArray()
+		w.Raw([]byte(`1 2`))
+		w.EndArray()
+		return w.Flush()
+	}, "", true, false},
+	{func(w *Writer) error {
+		w.StartArray()
+		w.Int(1)
+		return w.Flush()
+	}, "[1", false, true},
+}
+
+func TestVerifyingWriter(t *testing.T) {
+	for i, tt := range verifyingWriterTests {
+		var buf bytes.Buffer
+		vw := NewVerifyingWriter(&buf)
+		err := tt.write(NewWriter(vw))
+		if (err != nil) != tt.err {
+			t.Errorf("%d: write error %v, want error %v", i, err, tt.err)
+		}
+		if tt.err {
+			if _, ok := err.(*SyntaxError); !ok {
+				t.Errorf("%d: write error %T, want *SyntaxError", i, err)
+			}
+		}
+		if err := vw.Close(); (err != nil) != (tt.err || tt.close) {
+			t.Errorf("%d: Close() = %v", i, err)
+		}
+		if buf.String() != tt.out {
+			t.Errorf("%d: output %q, want %q", i, buf.String(), tt.out)
+		}
+	}
+}
+
+func TestVerifyingWriterLines(t *testing.T) {
+	var buf bytes.Buffer
+	vw := NewVerifyingWriter(&buf)
+	vw.SetFraming(FrameLines)
+	if _, err := io.WriteString(vw, "1\n[2]\n"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := io.WriteString(vw, "3 4\n"); err == nil {
+		t.Error("two values on a line accepted")
+	}
+	if buf.String() != "1\n[2]\n" {
+		t.Errorf("output %q", buf.String())
+	}
+}
+
+func TestVerifyingWriterDisabled(t *testing.T) {
+	var buf bytes.Buffer
+	vw := NewVerifyingWriter(&buf)
+	vw.SetEnabled(false)
+	if _, err := io.WriteString(vw, "{]"); err != nil {
+		t.Fatal(err)
+	}
+	if err := vw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != "{]" {
+		t.Errorf("output %q", buf.String())
+	}
+}
+
+func TestVerifyingWriterPanic(t *testing.T) {
+	vw := NewVerifyingWriter(ioutil.Discard)
+	vw.SetPanic(true)
+	defer func() {
+		if _, ok := recover().(*SyntaxError); !ok {
+			t.Error("Write did not panic with *SyntaxError")
+		}
+	}()
+	io.WriteString(vw, "[1,]")
+}
@@ -6,10 +6,17 @@ package json
 
 import (
 	"bufio"
+	"bytes"
+	stdencoding "encoding"
 	"errors"
+	"fmt"
 	"io"
 	"math"
+	"reflect"
+	"sort"
 	"strconv"
+	"strings"
+	"time"
 )
 
 type stringWriter interface {
@@ -18,93 +25,533 @@ type stringWriter interface {
 	WriteString(s string) (int, error)
 }
 
+// Marshaler is the interface implemented by types that encode themselves to
+// a Writer. EncodeJSON must write exactly one JSON value.
+type Marshaler interface {
+	EncodeJSON(w *Writer) error
+}
+
+// Errors returned by the Writer when methods are called out of order. The
+// Writer does not write output when returning one of these errors.
+var (
+	ErrMissingName    = errors.New("missing object member name")
+	ErrUnexpectedName = errors.New("unexpected object member name")
+	ErrUnexpectedEnd  = errors.New("unexpected end of object or array")
+	ErrStringOpen     = errors.New("string value from StringWriter not closed")
+	ErrMultipleValues = errors.New("multiple top-level values without EndDocument")
+)
+
 type Writer struct {
 	bw      *bufio.Writer
 	sw      stringWriter
 	scratch [64]byte
 	comma   bool
 	depth   int
+	objects []bool // for each open container, true if the container is an object
 	err     error
+	str     bool // if true, a string value from StringWriter is open
+	done    bool // if true, a top-level value was written with FrameSingle
+
+	noEscapeHTML bool   // if true, <, > and & are not escaped in strings.
+	timeLayout   string // default layout for Time, "" for time.RFC3339Nano
+	floatFmt     byte   // format for Float, 0 for 'g'
+	floatPrec    int    // precision for Float if floatFmt != 0
+	nonFinite    NonFinitePolicy
+	framing      Framing
+
+	// Statistics
+	stats      *WriterStats // statistics if collection is enabled
+	count      *countWriter // counts output bytes if collection is enabled
+	hook       StatsHook    // receives statistics after each top-level value
+	reported   WriterStats  // statistics at the end of the last top-level value
+	valueDepth int          // maximum nesting level of the current top-level value
+
+	// Duplicate names
+	dupNames bool              // if true, duplicate member names are rejected.
+	names    []map[string]bool // stack of member names written in objects
+	nnames   int               // number of objects in names
+
+	// Sorted objects
+	sortKeys bool         // if true, object members are sorted by name.
+	sorts    []*sortFrame // open sorted objects, innermost last
+	nsorts   int          // number of open sorted objects
+
+	// Indentation
+	indent bool   // if true, output is indented.
+	name   bool   // if true, the last call was Name.
+	prefix string // prefix for each line
+	step   string // indent for each nesting level
 }
 
 func NewWriter(w io.Writer) *Writer {
 	writer := &Writer{}
-	if sw, ok := w.(stringWriter); ok {
-		writer.sw = sw
-	} else {
-		writer.bw = bufio.NewWriter(w)
-		writer.sw = writer.bw
-	}
+	writer.setOutput(w)
+	return writer
+}
+
+// NewWriterSize returns a new writer that buffers output in a buffer of at
+// least size bytes. The writer flushes the buffer at the end of each
+// top-level value and when Flush is called.
+func NewWriterSize(w io.Writer, size int) *Writer {
+	writer := &Writer{bw: bufio.NewWriterSize(w, size)}
+	writer.sw = writer.bw
 	return writer
 }
 
+// Reset discards the writer's state and switches the writer to write to w.
+// Options set on the writer are retained. Reset allows writers to be pooled
+// with sync.Pool.
+func (w *Writer) Reset(wr io.Writer) {
+	w.setOutput(wr)
+	if w.stats != nil {
+		w.count = nil
+		w.CollectStats(true)
+	}
+	w.comma = false
+	w.done = false
+	w.depth = 0
+	w.objects = w.objects[:0]
+	w.err = nil
+	w.name = false
+	w.str = false
+	w.nsorts = 0
+	for w.nnames > 0 {
+		w.popNames()
+	}
+}
+
+// setOutput sets the writer's output to wr. Writes to the output must
+// report the first error on all subsequent writes. The bytes.Buffer,
+// strings.Builder and bufio.Writer types have this property. Other writers
+// are wrapped.
+func (w *Writer) setOutput(wr io.Writer) {
+	switch sw := wr.(type) {
+	case *bytes.Buffer:
+		w.sw = sw
+		w.bw = nil
+	case *strings.Builder:
+		w.sw = sw
+		w.bw = nil
+	case *bufio.Writer:
+		w.sw = sw
+		w.bw = nil
+	case stringWriter:
+		w.sw = &stickyWriter{sw: sw}
+		w.bw = nil
+	default:
+		if w.bw != nil {
+			w.bw.Reset(wr)
+		} else {
+			w.bw = bufio.NewWriter(wr)
+		}
+		w.sw = w.bw
+	}
+}
+
+// SetIndent instructs the writer to format each element in an object or
+// array on a separate line beginning with prefix followed by one or more
+// copies of indent according to the nesting level. Calling SetIndent("", "")
+// disables indentation.
+func (w *Writer) SetIndent(prefix, indent string) {
+	w.prefix = prefix
+	w.step = indent
+	w.indent = prefix != "" || indent != ""
+}
+
+// SetEscapeHTML specifies whether the characters <, > and & are escaped in
+// strings. The default is true. Control characters are always escaped, using
+// the short escapes \b, \f, \n, \r and \t where possible. U+2028 and U+2029
+// are always escaped to allow the output to be embedded in JavaScript.
+func (w *Writer) SetEscapeHTML(on bool) {
+	w.noEscapeHTML = !on
+}
+
+// SetTimeLayout sets the default layout used by Time and QuotedTime. The
+// default is time.RFC3339Nano.
+func (w *Writer) SetTimeLayout(layout string) {
+	w.timeLayout = layout
+}
+
+// FloatFormatStd is the float format of the encoding/json package: floats
+// are formatted without an exponent unless the magnitude is less than 1e-6
+// or greater than or equal to 1e21. Pass FloatFormatStd to SetFloatFormat.
+const FloatFormatStd byte = 'j'
+
+// SetFloatFormat sets the format and precision used by Float, Float32 and
+// Floats as described in strconv.FormatFloat. The format must be 'e', 'E',
+// 'f', 'g', 'G' or FloatFormatStd; other formats are treated as 'g'. The
+// precision is ignored for FloatFormatStd. The default is 'g' with precision
+// -1, the smallest number of digits necessary to represent the value.
+func (w *Writer) SetFloatFormat(fmt byte, prec int) {
+	switch fmt {
+	case 'e', 'E', 'f', 'g', 'G', FloatFormatStd:
+	default:
+		fmt = 'g'
+	}
+	w.floatFmt = fmt
+	w.floatPrec = prec
+}
+
+// NonFinitePolicy specifies how the writer handles infinite and NaN floats,
+// which cannot be represented in JSON.
+type NonFinitePolicy int
+
+const (
+	// NonFiniteError returns an error without writing output.
+	NonFiniteError NonFinitePolicy = iota
+
+	// NonFiniteNull writes null.
+	NonFiniteNull
+
+	// NonFiniteString writes the strings "NaN", "Infinity" and "-Infinity"
+	// for consumers that accept these values.
+	NonFiniteString
+)
+
+// SetNonFinite sets how Float, Float32 and Floats handle infinite and NaN
+// values. The default is NonFiniteError.
+func (w *Writer) SetNonFinite(p NonFinitePolicy) {
+	w.nonFinite = p
+}
+
+var errNonFinite = errors.New("unsupported value (inf, nan)")
+
+// appendNonFinite appends the encoding of the infinite or NaN value f for the
+// writer's policy.
+func (w *Writer) appendNonFinite(dst []byte, f float64) ([]byte, error) {
+	switch w.nonFinite {
+	case NonFiniteNull:
+		return append(dst, "null"...), nil
+	case NonFiniteString:
+		switch {
+		case math.IsNaN(f):
+			return append(dst, `"NaN"`...), nil
+		case f > 0:
+			return append(dst, `"Infinity"`...), nil
+		default:
+			return append(dst, `"-Infinity"`...), nil
+		}
+	}
+	return dst, errNonFinite
+}
+
+// appendFloat appends f formatted with the writer's float format. Bits is 32
+// for float32 values and 64 for float64 values.
+func (w *Writer) appendFloat(dst []byte, f float64, bits int) []byte {
+	switch w.floatFmt {
+	case 0:
+		return strconv.AppendFloat(dst, f, 'g', -1, bits)
+	case FloatFormatStd:
+		// Match the encoding/json package.
+		fmt := byte('f')
+		if abs := math.Abs(f); abs != 0 {
+			if bits == 64 && (abs < 1e-6 || abs >= 1e21) ||
+				bits == 32 && (float32(abs) < 1e-6 || float32(abs) >= 1e21) {
+				fmt = 'e'
+			}
+		}
+		dst = strconv.AppendFloat(dst, f, fmt, -1, bits)
+		if fmt == 'e' {
+			// Clean up e-09 to e-9.
+			n := len(dst)
+			if n >= 4 && dst[n-4] == 'e' && dst[n-3] == '-' && dst[n-2] == '0' {
+				dst[n-2] = dst[n-1]
+				dst = dst[:n-1]
+			}
+		}
+		return dst
+	}
+	return strconv.AppendFloat(dst, f, w.floatFmt, w.floatPrec, bits)
+}
+
+// SetSortKeys specifies whether the members of objects are sorted by name.
+// When sorting, the writer buffers each object and writes the members in
+// sorted order at EndObject. Members with the same name are written in the
+// order they were added. The setting applies to objects started after the
+// call to SetSortKeys.
+func (w *Writer) SetSortKeys(sort bool) {
+	w.sortKeys = sort
+}
+
+// SetRejectDuplicateNames sets whether Name and NameBytes return a
+// *DuplicateKeyError when the name was written before in the current object.
+// Only the Key field of the error is set. The writer does not write output
+// when returning the error. Call SetRejectDuplicateNames before writing a
+// value.
+func (w *Writer) SetRejectDuplicateNames(reject bool) {
+	w.dupNames = reject
+}
+
+func (w *Writer) pushNames() {
+	if w.nnames == len(w.names) {
+		w.names = append(w.names, make(map[string]bool))
+	}
+	w.nnames++
+}
+
+func (w *Writer) popNames() {
+	w.nnames--
+	m := w.names[w.nnames]
+	for k := range m {
+		delete(m, k)
+	}
+}
+
+// checkName records name and returns an error if the name was written before
+// in the current object.
+func (w *Writer) checkName(name string) error {
+	if !w.dupNames || w.nnames == 0 {
+		return nil
+	}
+	m := w.names[w.nnames-1]
+	if m[name] {
+		return &DuplicateKeyError{Key: name}
+	}
+	m[name] = true
+	return nil
+}
+
+// checkNameBytes is like checkName, but the lookup does not allocate. The
+// caller checks w.dupNames.
+func (w *Writer) checkNameBytes(name []byte) error {
+	if w.nnames == 0 {
+		return nil
+	}
+	m := w.names[w.nnames-1]
+	if m[string(name)] {
+		return &DuplicateKeyError{Key: string(name)}
+	}
+	m[string(name)] = true
+	return nil
+}
+
+// Flush writes buffered data to the underlying writer. Use Flush to send a
+// partial document, for example a long array that is streamed to a client.
+func (w *Writer) Flush() error {
+	if w.err != nil || w.bw == nil {
+		return w.err
+	}
+	return w.latch(w.bw.Flush())
+}
+
+// Err returns the first error encountered while writing to the underlying
+// writer. After an error, the writer methods return the error without writing
+// output.
 func (w *Writer) Err() error {
 	return w.err
 }
 
+// latch records err as the writer's error if err is the first error.
+func (w *Writer) latch(err error) error {
+	if err != nil && w.err == nil {
+		w.err = err
+	}
+	return err
+}
+
+// inObject returns true if the innermost open container is an object.
+func (w *Writer) inObject() bool {
+	return len(w.objects) > 0 && w.objects[len(w.objects)-1]
+}
+
+// value checks that a value is allowed and writes the separator before the
+// value.
+func (w *Writer) value() error {
+	if w.err != nil {
+		return w.err
+	}
+	if w.str {
+		return ErrStringOpen
+	}
+	if w.inObject() && !w.name {
+		return ErrMissingName
+	}
+	if w.depth == 0 && w.done {
+		return ErrMultipleValues
+	}
+	if w.depth == 0 && w.framing == FrameSeq {
+		w.sw.WriteByte(recordSeparator)
+	}
+	w.sep()
+	return nil
+}
+
+// sep writes the separator before an element.
+func (w *Writer) sep() {
+	if w.comma {
+		w.sw.WriteByte(',')
+	}
+	if w.indent && !w.name && w.depth > 0 {
+		w.newline(w.depth)
+	}
+	w.name = false
+}
+
+func (w *Writer) newline(depth int) {
+	w.sw.WriteByte('\n')
+	w.sw.WriteString(w.prefix)
+	for i := 0; i < depth; i++ {
+		w.sw.WriteString(w.step)
+	}
+}
+
+// close writes the closing delimiter c of an object or array.
+func (w *Writer) close(c byte) error {
+	if w.err != nil {
+		return w.err
+	}
+	if w.str {
+		return ErrStringOpen
+	}
+	if w.depth == 0 || w.name || w.inObject() != (c == '}') {
+		return ErrUnexpectedEnd
+	}
+	if f := w.sortFrame(); f != nil && c == '}' {
+		w.endSort(f)
+	}
+	if w.dupNames && c == '}' && w.nnames > 0 {
+		w.popNames()
+	}
+	w.objects = w.objects[:len(w.objects)-1]
+	w.depth -= 1
+	if w.indent && w.comma {
+		w.newline(w.depth)
+	}
+	return w.end(w.sw.WriteByte(c))
+}
+
 func (w *Writer) end(err error) error {
 	if w.depth != 0 {
 		w.comma = true
-		return err
+		if w.stats != nil {
+			w.countValue()
+		}
+		return w.latch(err)
 	}
 
 	w.comma = false
+	w.done = w.framing == FrameSingle
+	if w.framing != FrameSingle {
+		if e := w.sw.WriteByte('\n'); err == nil {
+			err = e
+		}
+	}
 	if w.bw != nil {
 		if e := w.bw.Flush(); e != nil && err == nil {
 			err = e
 		}
 	}
-	return err
+	if w.stats != nil {
+		w.countValue()
+	}
+	return w.latch(err)
 }
 
 func (w *Writer) StartArray() error {
-	if w.comma {
-		w.sw.WriteByte(',')
+	if err := w.value(); err != nil {
+		return err
 	}
 	w.comma = false
+	w.objects = append(w.objects, false)
 	w.depth += 1
-	return w.sw.WriteByte('[')
+	return w.latch(w.sw.WriteByte('['))
 }
 
 func (w *Writer) EndArray() error {
-	w.depth -= 1
-	return w.end(w.sw.WriteByte(']'))
+	return w.close(']')
 }
 
 func (w *Writer) StartObject() error {
-	if w.comma {
-		w.sw.WriteByte(',')
+	if err := w.value(); err != nil {
+		return err
 	}
 	w.comma = false
+	w.objects = append(w.objects, true)
 	w.depth += 1
-	return w.sw.WriteByte('{')
+	err := w.sw.WriteByte('{')
+	if w.sortKeys {
+		w.startSort()
+	}
+	if w.dupNames {
+		w.pushNames()
+	}
+	return w.latch(err)
 }
 
 func (w *Writer) EndObject() error {
-	w.depth -= 1
-	return w.end(w.sw.WriteByte('}'))
+	return w.close('}')
 }
 
 func (w *Writer) Name(name string) error {
-	if w.comma {
-		w.sw.WriteByte(',')
+	if w.err != nil {
+		return w.err
+	}
+	if w.str {
+		return ErrStringOpen
+	}
+	if !w.inObject() || w.name {
+		return ErrUnexpectedName
+	}
+	if err := w.checkName(name); err != nil {
+		return err
+	}
+	f := w.sortFrame()
+	f.endMember()
+	w.sep()
+	f.startMember(name)
+	writeString(w.sw, name, !w.noEscapeHTML)
+	return w.colon()
+}
+
+// NameBytes writes an object member name. NameBytes is like Name, but
+// avoids a string conversion when the name is a []byte.
+func (w *Writer) NameBytes(name []byte) error {
+	if w.err != nil {
+		return w.err
+	}
+	if w.str {
+		return ErrStringOpen
+	}
+	if !w.inObject() || w.name {
+		return ErrUnexpectedName
+	}
+	if w.dupNames {
+		if err := w.checkNameBytes(name); err != nil {
+			return err
+		}
+	}
+	f := w.sortFrame()
+	f.endMember()
+	w.sep()
+	if f != nil {
+		f.startMember(string(name))
 	}
+	writeStringBytes(w.sw, name, !w.noEscapeHTML)
+	return w.colon()
+}
+
+// colon writes the separator between a member name and value.
+func (w *Writer) colon() error {
 	w.comma = false
-	writeString(w.sw, name)
-	return w.sw.WriteByte(':')
+	w.name = true
+	if w.indent {
+		_, err := w.sw.WriteString(": ")
+		return w.latch(err)
+	}
+	return w.latch(w.sw.WriteByte(':'))
 }
 
 func (w *Writer) write(p []byte) error {
-	if w.comma {
-		w.sw.WriteByte(',')
+	if err := w.value(); err != nil {
+		return err
 	}
 	_, err := w.sw.Write(p)
 	return w.end(err)
 }
 
 func (w *Writer) writeQuoted(p []byte) error {
-	if w.comma {
-		w.sw.WriteByte(',')
+	if err := w.value(); err != nil {
+		return err
 	}
 	w.sw.WriteByte('"')
 	w.sw.Write(p)
@@ -127,32 +574,367 @@ func (w *Writer) QuotedInt(i int64) error {
 	return w.writeQuoted(strconv.AppendInt(w.scratch[:0], i, 10))
 }
 
+// Float writes f. Infinite and NaN values are handled as specified by
+// SetNonFinite.
 func (w *Writer) Float(f float64) error {
+	return w.float(f, 64)
+}
+
+// Float32 writes f formatted with the smallest number of digits necessary to
+// represent the float32 value when the writer uses the default format.
+func (w *Writer) Float32(f float32) error {
+	return w.float(float64(f), 32)
+}
+
+func (w *Writer) float(f float64, bits int) error {
 	if math.IsInf(f, 0) || math.IsNaN(f) {
-		w.write([]byte("0"))
-		return errors.New("unsupported value (inf, nan)")
+		p, err := w.appendNonFinite(w.scratch[:0], f)
+		if err != nil {
+			return err
+		}
+		return w.write(p)
+	}
+	return w.write(w.appendFloat(w.scratch[:0], f, bits))
+}
+
+// Number writes the number literal n. Number returns an error without
+// writing output if n does not match the JSON number grammar.
+func (w *Writer) Number(n NumberValue) error {
+	if !isValidNumber(string(n)) {
+		return errors.New("invalid number " + strconv.Quote(string(n)))
+	}
+	if err := w.value(); err != nil {
+		return err
 	}
-	return w.write(strconv.AppendFloat(w.scratch[:0], f, 'g', -1, 64))
+	_, err := w.sw.WriteString(string(n))
+	return w.end(err)
 }
 
 func (w *Writer) Bool(b bool) error {
-	if w.comma {
-		w.sw.WriteByte(',')
+	if err := w.value(); err != nil {
+		return err
 	}
 	_, err := w.sw.WriteString(strconv.FormatBool(b))
 	return w.end(err)
 }
 
+// Null writes a JSON null.
+func (w *Writer) Null() error {
+	if err := w.value(); err != nil {
+		return err
+	}
+	_, err := w.sw.WriteString("null")
+	return w.end(err)
+}
+
 func (w *Writer) String(s string) error {
-	if w.comma {
-		w.sw.WriteByte(',')
+	if err := w.value(); err != nil {
+		return err
 	}
-	return w.end(writeString(w.sw, s))
+	return w.end(writeString(w.sw, s, !w.noEscapeHTML))
 }
 
 func (w *Writer) StringBytes(p []byte) error {
-	if w.comma {
+	if err := w.value(); err != nil {
+		return err
+	}
+	return w.end(writeStringBytes(w.sw, p, !w.noEscapeHTML))
+}
+
+// Time writes t formatted with layout as a string. If layout is "", then the
+// writer's default layout is used.
+func (w *Writer) Time(t time.Time, layout string) error {
+	if layout == "" {
+		layout = w.timeLayout
+		if layout == "" {
+			layout = time.RFC3339Nano
+		}
+	}
+	return w.StringBytes(t.AppendFormat(w.scratch[:0], layout))
+}
+
+// QuotedTime writes t formatted with the writer's default layout as a string.
+func (w *Writer) QuotedTime(t time.Time) error {
+	return w.Time(t, "")
+}
+
+// TextMarshaler writes the text returned by v's MarshalText method as a
+// string. If v is nil or a nil pointer, then TextMarshaler writes null. The
+// writer does not write output when MarshalText returns an error.
+func (w *Writer) TextMarshaler(v stdencoding.TextMarshaler) error {
+	if v == nil || isNilPointer(v) {
+		return w.Null()
+	}
+	p, err := v.MarshalText()
+	if err != nil {
+		return err
+	}
+	return w.StringBytes(p)
+}
+
+// Stringer writes the value returned by v's String method as a string. If v
+// is nil or a nil pointer, then Stringer writes null.
+func (w *Writer) Stringer(v fmt.Stringer) error {
+	if v == nil || isNilPointer(v) {
+		return w.Null()
+	}
+	return w.String(v.String())
+}
+
+// isNilPointer returns true if v is a nil pointer of some type.
+func isNilPointer(v interface{}) bool {
+	rv := reflect.ValueOf(v)
+	return rv.Kind() == reflect.Ptr && rv.IsNil()
+}
+
+// Interface writes v without reflection. The value must be a tree with the
+// types returned by DecodeValueWith: nil, bool, string, NumberValue, float64,
+// int64, *big.Int, *big.Float, []interface{}, map[string]interface{} and
+// *OrderedObject. Values that implement Marshaler are written with their
+// EncodeJSON method. The members of a map[string]interface{} are written
+// sorted by name.
+func (w *Writer) Interface(v interface{}) error {
+	return writeDecoded(w, v)
+}
+
+// Raw writes the encoded JSON value p. Raw does not validate p.
+func (w *Writer) Raw(p []byte) error {
+	return w.write(p)
+}
+
+// RawString writes the encoded JSON value s. RawString does not validate s.
+func (w *Writer) RawString(s string) error {
+	if err := w.value(); err != nil {
+		return err
+	}
+	_, err := w.sw.WriteString(s)
+	return w.end(err)
+}
+
+// StringMap writes m as an object with the members sorted by name.
+func (w *Writer) StringMap(m map[string]string) error {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	if err := w.value(); err != nil {
+		return err
+	}
+	w.comma = false
+	w.depth += 1
+	w.sw.WriteByte('{')
+	for i, k := range keys {
+		w.elem(i)
+		writeString(w.sw, k, !w.noEscapeHTML)
+		if w.indent {
+			w.sw.WriteString(": ")
+		} else {
+			w.sw.WriteByte(':')
+		}
+		writeString(w.sw, m[k], !w.noEscapeHTML)
+	}
+	w.depth -= 1
+	if w.indent && len(keys) > 0 {
+		w.newline(w.depth)
+	}
+	return w.end(w.sw.WriteByte('}'))
+}
+
+// Strings writes a as an array of strings.
+func (w *Writer) Strings(a []string) error {
+	if err := w.StartArray(); err != nil {
+		return err
+	}
+	for i, s := range a {
+		w.elem(i)
+		writeString(w.sw, s, !w.noEscapeHTML)
+	}
+	w.comma = len(a) > 0
+	return w.EndArray()
+}
+
+// Ints writes a as an array of numbers.
+func (w *Writer) Ints(a []int64) error {
+	if err := w.StartArray(); err != nil {
+		return err
+	}
+	for i, n := range a {
+		w.elem(i)
+		w.sw.Write(strconv.AppendInt(w.scratch[:0], n, 10))
+	}
+	w.comma = len(a) > 0
+	return w.EndArray()
+}
+
+// Floats writes a as an array of numbers. Infinite and NaN values are
+// handled as specified by SetNonFinite. With the NonFiniteError policy,
+// Floats returns an error without writing output if a contains an infinite
+// or NaN value.
+func (w *Writer) Floats(a []float64) error {
+	if w.nonFinite == NonFiniteError {
+		for _, f := range a {
+			if math.IsInf(f, 0) || math.IsNaN(f) {
+				return errNonFinite
+			}
+		}
+	}
+	if err := w.StartArray(); err != nil {
+		return err
+	}
+	for i, f := range a {
+		w.elem(i)
+		if math.IsInf(f, 0) || math.IsNaN(f) {
+			p, _ := w.appendNonFinite(w.scratch[:0], f)
+			w.sw.Write(p)
+			continue
+		}
+		w.sw.Write(w.appendFloat(w.scratch[:0], f, 64))
+	}
+	w.comma = len(a) > 0
+	return w.EndArray()
+}
+
+// elem writes the separator before element i of an array or member i of an
+// object written by one of the slice and map methods.
+func (w *Writer) elem(i int) {
+	if w.stats != nil {
+		w.countValue()
+	}
+	if i > 0 {
 		w.sw.WriteByte(',')
 	}
-	return w.end(writeStringBytes(w.sw, p))
+	if w.indent {
+		w.newline(w.depth)
+	}
+}
+
+// EndDocument ends a top-level value by writing a newline. Use EndDocument
+// after each value to write newline-delimited JSON. With FrameSingle framing,
+// the writer returns ErrMultipleValues for a second top-level value unless
+// EndDocument is called after the first.
+func (w *Writer) EndDocument() error {
+	if w.err != nil {
+		return w.err
+	}
+	if w.str {
+		return ErrStringOpen
+	}
+	if w.depth != 0 {
+		return errors.New("EndDocument inside object or array")
+	}
+	if w.framing != FrameSingle {
+		return nil
+	}
+	err := w.end(w.sw.WriteByte('\n'))
+	w.done = false
+	return err
+}
+
+// sortFrame is the state for an open object with sorted members.
+type sortFrame struct {
+	sw      stringWriter // writer for the object's container
+	depth   int          // depth of the object's members
+	buf     bytes.Buffer // the object's members
+	members []sortMember
+}
+
+type sortMember struct {
+	name       string
+	start, end int // offsets of the member in buf
+}
+
+func (f *sortFrame) Len() int           { return len(f.members) }
+func (f *sortFrame) Less(i, j int) bool { return f.members[i].name < f.members[j].name }
+func (f *sortFrame) Swap(i, j int)      { f.members[i], f.members[j] = f.members[j], f.members[i] }
+
+// endMember records the end of the previous member, if any.
+func (f *sortFrame) endMember() {
+	if f != nil && len(f.members) > 0 {
+		f.members[len(f.members)-1].end = f.buf.Len()
+	}
+}
+
+// startMember records the start of a member.
+func (f *sortFrame) startMember(name string) {
+	if f != nil {
+		f.members = append(f.members, sortMember{name: name, start: f.buf.Len()})
+	}
+}
+
+// sortFrame returns the frame for the innermost open container or nil if the
+// container is not a sorted object.
+func (w *Writer) sortFrame() *sortFrame {
+	if w.nsorts == 0 {
+		return nil
+	}
+	if f := w.sorts[w.nsorts-1]; f.depth == w.depth {
+		return f
+	}
+	return nil
+}
+
+// startSort redirects output for the object just started to a buffer.
+func (w *Writer) startSort() {
+	if w.nsorts == len(w.sorts) {
+		w.sorts = append(w.sorts, &sortFrame{})
+	}
+	f := w.sorts[w.nsorts]
+	w.nsorts++
+	f.sw = w.sw
+	f.depth = w.depth
+	f.buf.Reset()
+	f.members = f.members[:0]
+	w.sw = &f.buf
+}
+
+// endSort writes the members of the sorted object to the object's container.
+func (w *Writer) endSort(f *sortFrame) {
+	f.endMember()
+	sort.Stable(f)
+	w.sw = f.sw
+	w.nsorts--
+	p := f.buf.Bytes()
+	for i, m := range f.members {
+		if i > 0 {
+			w.sw.WriteByte(',')
+		}
+		if w.indent {
+			w.newline(f.depth)
+		}
+		w.sw.Write(p[m.start:m.end])
+	}
+}
+
+// stickyWriter returns the first error from the underlying writer on all
+// subsequent writes.
+type stickyWriter struct {
+	sw  stringWriter
+	err error
+}
+
+func (w *stickyWriter) Write(p []byte) (int, error) {
+	if w.err != nil {
+		return 0, w.err
+	}
+	n, err := w.sw.Write(p)
+	w.err = err
+	return n, err
+}
+
+func (w *stickyWriter) WriteByte(c byte) error {
+	if w.err != nil {
+		return w.err
+	}
+	w.err = w.sw.WriteByte(c)
+	return w.err
+}
+
+func (w *stickyWriter) WriteString(s string) (int, error) {
+	if w.err != nil {
+		return 0, w.err
+	}
+	n, err := w.sw.WriteString(s)
+	w.err = err
+	return n, err
 }
@@ -6,10 +6,19 @@ package json
 
 import (
 	"bytes"
+	"errors"
 	"io"
+	"io/ioutil"
+	"math"
+	"math/big"
+	"net"
+	"strings"
 	"testing"
+	"time"
 )
 
+var testTime = time.Date(2014, 1, 2, 3, 4, 5, 600000000, time.UTC)
+
 var writerTests = []struct {
 	fn func(w *Writer)
 	s  string
@@ -33,6 +42,49 @@ var writerTests = []struct {
 	}, `{"a":"b","c":"d"}`},
 	{func(w *Writer) { w.StartArray(); w.String("hello"); w.EndArray() }, `["hello"]`},
 	{func(w *Writer) { w.StartArray(); w.String("a"); w.String("b"); w.EndArray() }, `["a","b"]`},
+	{func(w *Writer) { w.Raw([]byte(`{"a":[1,2]}`)) }, `{"a":[1,2]}`},
+	{func(w *Writer) { w.RawString(`"x"`) }, `"x"`},
+	{func(w *Writer) { w.StartArray(); w.Raw([]byte(`1`)); w.RawString(`{}`); w.EndArray() }, `[1,{}]`},
+	{func(w *Writer) { w.Null() }, "null"},
+	{func(w *Writer) { w.Number("-12345678901234567890.125e-7") }, "-12345678901234567890.125e-7"},
+	{func(w *Writer) {
+		w.StartObject()
+		w.NameBytes([]byte("a<"))
+		w.Null()
+		w.Name("b")
+		w.Int(1)
+		w.EndObject()
+	}, `{"a\u003c":null,"b":1}`},
+	{func(w *Writer) { w.Time(testTime, "") }, `"2014-01-02T03:04:05.6Z"`},
+	{func(w *Writer) { w.Time(testTime, time.Kitchen) }, `"3:04AM"`},
+	{func(w *Writer) { w.QuotedTime(testTime.Truncate(time.Second)) }, `"2014-01-02T03:04:05Z"`},
+	{func(w *Writer) { w.SetTimeLayout("2006-01-02"); w.QuotedTime(testTime) }, `"2014-01-02"`},
+	{func(w *Writer) { w.StartArray(); w.Time(testTime, `"2006"`); w.EndArray() }, `["\"2014\""]`},
+	{func(w *Writer) { w.StringMap(map[string]string{"b": "<", "a": "x", "c": ""}) }, `{"a":"x","b":"\u003c","c":""}`},
+	{func(w *Writer) { w.StringMap(nil) }, `{}`},
+	{func(w *Writer) { w.Strings([]string{"a", "\n"}) }, `["a","\n"]`},
+	{func(w *Writer) { w.Strings(nil) }, `[]`},
+	{func(w *Writer) { w.Ints([]int64{1, -2, 3}) }, `[1,-2,3]`},
+	{func(w *Writer) { w.Floats([]float64{1.5, 2}) }, `[1.5,2]`},
+	{func(w *Writer) { w.StartArray(); w.Ints(nil); w.Strings([]string{"a"}); w.Int(1); w.EndArray() }, `[[],["a"],1]`},
+	{func(w *Writer) {
+		w.StartObject()
+		w.Name("a")
+		w.Floats([]float64{1})
+		w.Name("b")
+		w.Null()
+		w.EndObject()
+	}, `{"a":[1],"b":null}`},
+	{func(w *Writer) { w.Float(1e6) }, "1e+06"},
+	{func(w *Writer) { w.Float32(0.1) }, "0.1"},
+	{func(w *Writer) {
+		w.SetFloatFormat(FloatFormatStd, 0)
+		w.Floats([]float64{1e6, 1e20, 1e21, -1e-7, 1.5, 0})
+	}, `[1000000,100000000000000000000,1e+21,-1e-7,1.5,0]`},
+	{func(w *Writer) { w.SetFloatFormat(FloatFormatStd, 0); w.Float32(1e6) }, "1000000"},
+	{func(w *Writer) { w.SetFloatFormat('f', 2); w.Float(1.005e3) }, "1005.00"},
+	{func(w *Writer) { w.SetFloatFormat('e', 3); w.Float32(1234.5) }, "1.234e+03"},
+	{func(w *Writer) { w.SetFloatFormat('x', -1); w.Float(0.5) }, "0.5"},
 }
 
 func TestWrite(t *testing.T) {
@@ -96,3 +148,585 @@ func TestWriteObject(t *testing.T) {
 		}
 	}
 }
+
+func TestWriteIndent(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	w.SetIndent(">", "  ")
+	w.StartObject()
+	w.Name("a")
+	w.Int(1)
+	w.Name("b")
+	w.StartArray()
+	w.String("x")
+	w.StartObject()
+	w.EndObject()
+	w.StartArray()
+	w.EndArray()
+	w.EndArray()
+	w.Name("c")
+	w.StartObject()
+	w.Name("d")
+	w.Bool(true)
+	w.EndObject()
+	w.EndObject()
+	want := `{
+>  "a": 1,
+>  "b": [
+>    "x",
+>    {},
+>    []
+>  ],
+>  "c": {
+>    "d": true
+>  }
+>}`
+	if got := buf.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestWriteSlicesIndent(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	w.SetIndent("", " ")
+	w.StartObject()
+	w.Name("a")
+	w.Ints([]int64{1, 2})
+	w.Name("b")
+	w.Strings(nil)
+	w.Name("c")
+	w.StringMap(map[string]string{"d": "e"})
+	w.EndObject()
+	want := `{
+ "a": [
+  1,
+  2
+ ],
+ "b": [],
+ "c": {
+  "d": "e"
+ }
+}`
+	if got := buf.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+
+	buf.Reset()
+	w = NewWriter(&buf)
+	if err := w.Floats([]float64{1, math.NaN()}); err == nil {
+		t.Error("no error for NaN")
+	}
+	if got := buf.String(); got != "" {
+		t.Errorf("got %s, want no output", got)
+	}
+}
+
+func TestWriteStringMap(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	w.SetSortKeys(true)
+	w.SetRejectDuplicateNames(true)
+	w.StartObject()
+	w.Name("z")
+	if err := w.StringMap(map[string]string{"b": "1", "a": "2"}); err != nil {
+		t.Fatal(err)
+	}
+	w.Name("y")
+	w.Int(1)
+	w.EndObject()
+	if got, want := buf.String(), `{"y":1,"z":{"a":"2","b":"1"}}`; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+
+	w = NewWriter(&buf)
+	w.StartObject()
+	if err := w.StringMap(nil); err != ErrMissingName {
+		t.Errorf("StringMap returned %v, want ErrMissingName", err)
+	}
+}
+
+func TestWriteNonFinite(t *testing.T) {
+	values := []float64{math.NaN(), math.Inf(1), math.Inf(-1)}
+	tests := []struct {
+		policy NonFinitePolicy
+		want   string
+	}{
+		{NonFiniteNull, `[null,null,null,[1,null,null,null],null]`},
+		{NonFiniteString, `["NaN","Infinity","-Infinity",[1,"NaN","Infinity","-Infinity"],"-Infinity"]`},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		w := NewWriter(&buf)
+		w.SetNonFinite(tt.policy)
+		w.StartArray()
+		for _, f := range values {
+			if err := w.Float(f); err != nil {
+				t.Errorf("Float(%v) returned %v", f, err)
+			}
+		}
+		w.Floats(append([]float64{1}, values...))
+		w.Float32(float32(math.Inf(-1)))
+		w.EndArray()
+		if got := buf.String(); got != tt.want {
+			t.Errorf("policy %d: got %s, want %s", tt.policy, got, tt.want)
+		}
+	}
+
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	w.StartArray()
+	for _, f := range values {
+		if err := w.Float(f); err == nil {
+			t.Errorf("Float(%v) returned nil error", f)
+		}
+	}
+	w.Int(1)
+	w.EndArray()
+	if got := buf.String(); got != "[1]" {
+		t.Errorf("got %s, want [1]", got)
+	}
+}
+
+func TestWriteEndDocument(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(writerOnly{&buf})
+	w.StartObject()
+	w.Name("a")
+	w.Int(1)
+	w.EndObject()
+	w.EndDocument()
+	w.Int(2)
+	w.EndDocument()
+	if got, want := buf.String(), "{\"a\":1}\n2\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	w.StartArray()
+	if err := w.EndDocument(); err == nil {
+		t.Error("EndDocument inside array returned nil error")
+	}
+}
+
+var escapeTests = []struct {
+	s            string
+	escapeHTML   string
+	noEscapeHTML string
+}{
+	{"a\b\f\n\r\tb", `"a\b\f\n\r\tb"`, `"a\b\f\n\r\tb"`},
+	{"\x00\x01\x1f\x7f", `"\u0000\u0001\u001f\u007f"`, `"\u0000\u0001\u001f\u007f"`},
+	{`"\`, `"\"\\"`, `"\"\\"`},
+	{"<a&b>", `"\u003ca\u0026b\u003e"`, `"<a&b>"`},
+	{"\u2028\u2029", `"\u2028\u2029"`, `"\u2028\u2029"`},
+	{"\xff", `"\ufffd"`, `"\ufffd"`},
+}
+
+func TestWriteEscape(t *testing.T) {
+	for _, tt := range escapeTests {
+		for _, escapeHTML := range []bool{true, false} {
+			want := tt.escapeHTML
+			if !escapeHTML {
+				want = tt.noEscapeHTML
+			}
+			for _, fn := range []func(w *Writer){
+				func(w *Writer) { w.String(tt.s) },
+				func(w *Writer) { w.StringBytes([]byte(tt.s)) },
+			} {
+				var buf bytes.Buffer
+				w := NewWriter(&buf)
+				w.SetEscapeHTML(escapeHTML)
+				fn(w)
+				if got := buf.String(); got != want {
+					t.Errorf("%q, escapeHTML=%v: got %s, want %s", tt.s, escapeHTML, got, want)
+				}
+			}
+		}
+	}
+}
+
+var writerStateTests = []struct {
+	fn   func(w *Writer) error
+	err  error
+	want string
+}{
+	{func(w *Writer) error { w.StartObject(); return w.String("a") }, ErrMissingName, `{`},
+	{func(w *Writer) error { w.StartObject(); return w.StartArray() }, ErrMissingName, `{`},
+	{func(w *Writer) error { return w.Name("a") }, ErrUnexpectedName, ``},
+	{func(w *Writer) error { w.StartArray(); return w.Name("a") }, ErrUnexpectedName, `[`},
+	{func(w *Writer) error { w.StartObject(); w.Name("a"); return w.Name("b") }, ErrUnexpectedName, `{"a":`},
+	{func(w *Writer) error { return w.EndArray() }, ErrUnexpectedEnd, ``},
+	{func(w *Writer) error { w.StartArray(); return w.EndObject() }, ErrUnexpectedEnd, `[`},
+	{func(w *Writer) error { w.StartObject(); return w.EndArray() }, ErrUnexpectedEnd, `{`},
+	{func(w *Writer) error { w.StartObject(); w.Name("a"); return w.EndObject() }, ErrUnexpectedEnd, `{"a":`},
+	{func(w *Writer) error { w.StartArray(); w.StartObject(); w.EndObject(); return w.EndArray() }, nil, `[{}]`},
+}
+
+func TestWriteState(t *testing.T) {
+	for i, tt := range writerStateTests {
+		var buf bytes.Buffer
+		w := NewWriter(&buf)
+		if err := tt.fn(w); err != tt.err {
+			t.Errorf("%d: got error %v, want %v", i, err, tt.err)
+		}
+		if buf.String() != tt.want {
+			t.Errorf("%d: got %s, want %s", i, buf.String(), tt.want)
+		}
+	}
+}
+
+func TestWriteReset(t *testing.T) {
+	var buf1, buf2 bytes.Buffer
+	w := NewWriter(writerOnly{&buf1})
+	w.SetEscapeHTML(false)
+	w.StartArray()
+	w.String("<")
+	w.Reset(writerOnly{&buf2})
+	w.StartObject()
+	w.Name("a")
+	w.String("<")
+	w.EndObject()
+	if buf2.String() != `{"a":"<"}` {
+		t.Errorf("got %s, want %s", buf2.String(), `{"a":"<"}`)
+	}
+}
+
+func TestWriteResetDiscardsBuffered(t *testing.T) {
+	var buf1, buf2 bytes.Buffer
+	w := NewWriter(writerOnly{&buf1})
+	w.StartArray()
+	w.Int(1)
+	w.Reset(&buf2)
+	w.Int(2)
+	if buf1.Len() != 0 {
+		t.Errorf("old writer got %q, want no output", buf1.String())
+	}
+	if buf2.String() != "2" {
+		t.Errorf("got %q, want %q", buf2.String(), "2")
+	}
+}
+
+func TestWriteStringWriter(t *testing.T) {
+	const s = "a\"<é€𝄞\u2028\n\xffz"
+	const want = `["a\"\u003cé€𝄞\u2028\n\ufffdz","\ufffd\ufffd"]`
+	for _, n := range []int{1, 2, 3, 100} {
+		var buf bytes.Buffer
+		w := NewWriter(writerOnly{&buf})
+		w.StartArray()
+		sw := w.StringWriter()
+		for p := s; p != ""; {
+			k := n
+			if k > len(p) {
+				k = len(p)
+			}
+			sw.Write([]byte(p[:k]))
+			p = p[k:]
+		}
+		if err := w.String("x"); err != ErrStringOpen {
+			t.Errorf("n=%d: String inside StringWriter returned %v, want %v", n, err, ErrStringOpen)
+		}
+		if err := sw.Close(); err != nil {
+			t.Fatal(err)
+		}
+		// An incomplete sequence at close is replaced.
+		if err := w.StringCopy(strings.NewReader("\xe2\x82")); err != nil {
+			t.Fatal(err)
+		}
+		w.EndArray()
+		if got := buf.String(); got != want {
+			t.Errorf("n=%d: got %s, want %s", n, got, want)
+		}
+	}
+}
+
+func TestWriteNumberInvalid(t *testing.T) {
+	for _, n := range []NumberValue{"", "-", "01", "1.", ".5", "1e", "1e+", "+1", "0x10", "NaN", "1 "} {
+		var buf bytes.Buffer
+		w := NewWriter(&buf)
+		if err := w.Number(n); err == nil {
+			t.Errorf("Number(%q) returned nil error", n)
+		}
+		if buf.Len() != 0 {
+			t.Errorf("Number(%q) wrote %q", n, buf.String())
+		}
+	}
+}
+
+func TestWriteSortKeys(t *testing.T) {
+	build := func(w *Writer) {
+		w.StartArray()
+		w.StartObject()
+		w.Name("c")
+		w.Int(1)
+		w.Name("a")
+		w.StartObject()
+		w.Name("z")
+		w.Null()
+		w.Name("y")
+		w.StartArray()
+		w.Bool(true)
+		w.EndArray()
+		w.EndObject()
+		w.Name("b")
+		w.StartObject()
+		w.EndObject()
+		w.Name("a")
+		w.String("dup")
+		w.EndObject()
+		w.EndArray()
+	}
+	var buf bytes.Buffer
+	w := NewWriter(writerOnly{&buf})
+	w.SetSortKeys(true)
+	build(w)
+	if got, want := buf.String(), `[{"a":{"y":[true],"z":null},"a":"dup","b":{},"c":1}]`; got != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+
+	buf.Reset()
+	w = NewWriter(&buf)
+	w.SetSortKeys(true)
+	w.SetIndent("", "  ")
+	build(w)
+	want := `[
+  {
+    "a": {
+      "y": [
+        true
+      ],
+      "z": null
+    },
+    "a": "dup",
+    "b": {},
+    "c": 1
+  }
+]`
+	if got := buf.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestWriteFlush(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriterSize(writerOnly{&buf}, 4096)
+	w.StartArray()
+	w.String("hello")
+	if buf.Len() != 0 {
+		t.Errorf("output %q written before flush", buf.String())
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := buf.String(), `["hello"`; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+	w.EndArray()
+	if got, want := buf.String(), `["hello"]`; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+var errTestWrite = errors.New("test write error")
+
+// failWriter fails all writes after n bytes.
+type failWriter struct {
+	n int
+}
+
+func (w *failWriter) Write(p []byte) (int, error) {
+	if len(p) > w.n {
+		n := w.n
+		w.n = 0
+		return n, errTestWrite
+	}
+	w.n -= len(p)
+	return len(p), nil
+}
+
+func (w *failWriter) WriteByte(c byte) error {
+	_, err := w.Write([]byte{c})
+	return err
+}
+
+func (w *failWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+func TestWriteErrorLatch(t *testing.T) {
+	for _, wr := range []io.Writer{&failWriter{n: 5}, writerOnly{&failWriter{n: 5}}} {
+		w := NewWriter(wr)
+		w.StartArray()
+		for i := 0; i < 10; i++ {
+			w.String("hello")
+		}
+		err := w.EndArray()
+		if err != errTestWrite {
+			t.Errorf("%T: EndArray returned %v, want %v", wr, err, errTestWrite)
+		}
+		if w.Err() != errTestWrite {
+			t.Errorf("%T: Err() = %v, want %v", wr, w.Err(), errTestWrite)
+		}
+		if err := w.Int(1); err != errTestWrite {
+			t.Errorf("%T: Int after error returned %v", wr, err)
+		}
+		w.Reset(&bytes.Buffer{})
+		if err := w.Int(1); err != nil {
+			t.Errorf("%T: Int after Reset returned %v", wr, err)
+		}
+	}
+
+	// The error is reported by the method that writes the bytes.
+	w := NewWriter(&failWriter{n: 4})
+	w.StartArray()
+	if err := w.String("a"); err != nil {
+		t.Errorf("String returned %v", err)
+	}
+	if err := w.Int(1); err != errTestWrite {
+		t.Errorf("Int returned %v, want %v", err, errTestWrite)
+	}
+}
+
+func TestWriteRejectDuplicateNames(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	w.SetRejectDuplicateNames(true)
+	w.StartArray()
+	w.StartObject()
+	w.Name("a")
+	w.StartObject()
+	w.Name("a")
+	w.Int(1)
+	w.EndObject()
+	err := w.NameBytes([]byte("a"))
+	if e, ok := err.(*DuplicateKeyError); !ok || e.Key != "a" {
+		t.Fatalf("NameBytes returned %v, want duplicate key a", err)
+	}
+	w.Name("b")
+	w.Int(2)
+	if err := w.Name("b"); err == nil {
+		t.Fatal("Name returned nil for duplicate b")
+	}
+	w.EndObject()
+	w.StartObject()
+	w.Name("a")
+	w.Int(3)
+	w.EndObject()
+	w.EndArray()
+	if got, want := buf.String(), `[{"a":{"a":1},"b":2},{"a":3}]`; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+
+	// Duplicate names are allowed by default.
+	buf.Reset()
+	w = NewWriter(&buf)
+	w.StartObject()
+	w.Name("a")
+	w.Int(1)
+	if err := w.Name("a"); err != nil {
+		t.Errorf("Name returned %v", err)
+	}
+}
+
+func TestWriteMultipleValues(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	w.Int(1)
+	if err := w.Int(2); err != ErrMultipleValues {
+		t.Errorf("Int returned %v, want ErrMultipleValues", err)
+	}
+	w.EndDocument()
+	w.String("x")
+	if err := w.String("y"); err != ErrMultipleValues {
+		t.Errorf("String returned %v, want ErrMultipleValues", err)
+	}
+	if got, want := buf.String(), "1\n\"x\""; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestWriteNameBytesAllocs(t *testing.T) {
+	w := NewWriter(ioutil.Discard)
+	w.StartArray()
+	name := []byte("a")
+	n := testing.AllocsPerRun(100, func() {
+		w.StartObject()
+		w.NameBytes(name)
+		w.Int(1)
+		w.EndObject()
+	})
+	if n != 0 {
+		t.Errorf("allocs = %g, want 0", n)
+	}
+}
+
+type failText struct{}
+
+func (failText) MarshalText() ([]byte, error) { return nil, errTestWrite }
+
+func TestWriteTextMarshalerStringer(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	w.StartArray()
+	w.TextMarshaler(net.IPv4(10, 0, 0, 1))
+	w.TextMarshaler(nil)
+	w.Stringer(90 * time.Second)
+	w.Stringer(NumberValue(`<"a">`))
+	w.Stringer(nil)
+	w.TextMarshaler((*time.Time)(nil))
+	w.Stringer((*time.Time)(nil))
+	if err := w.TextMarshaler(failText{}); err != errTestWrite {
+		t.Errorf("TextMarshaler returned %v, want %v", err, errTestWrite)
+	}
+	w.EndArray()
+	if got, want := buf.String(), `["10.0.0.1",null,"1m30s","\u003c\"a\"\u003e",null,null,null]`; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+var writeInterfaceTests = []struct {
+	v    interface{}
+	want string
+}{
+	{nil, `null`},
+	{true, `true`},
+	{"a<", `"a\u003c"`},
+	{NumberValue("1e3"), `1e3`},
+	{1.5, `1.5`},
+	{int64(-2), `-2`},
+	{big.NewInt(3), `3`},
+	{big.NewFloat(0.5), `0.5`},
+	{[]interface{}{}, `[]`},
+	{map[string]interface{}{"b": []interface{}{nil}, "a": map[string]interface{}{}}, `{"a":{},"b":[null]}`},
+	{&OrderedObject{[]Member{{"b", 1.0}, {"a", 2.0}}}, `{"b":1,"a":2}`},
+}
+
+func TestWriteInterface(t *testing.T) {
+	for _, tt := range writeInterfaceTests {
+		var buf bytes.Buffer
+		if err := NewWriter(&buf).Interface(tt.v); err != nil {
+			t.Errorf("%v: Interface returned %v", tt.v, err)
+			continue
+		}
+		if got := buf.String(); got != tt.want {
+			t.Errorf("%v: got %s, want %s", tt.v, got, tt.want)
+		}
+	}
+	if err := NewWriter(&bytes.Buffer{}).Interface(1); err == nil {
+		t.Error("Interface(1) returned nil error")
+	}
+}
+
+func TestWriteInterfaceRoundTrip(t *testing.T) {
+	const in = `{"a":[1,"x",{"b":null,"c":true}],"d":-1.5e-3}`
+	s := NewScannerBytes([]byte(in))
+	s.Scan()
+	v, err := DecodeValue(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := NewWriter(&buf).Interface(v); err != nil {
+		t.Fatal(err)
+	}
+	if got := buf.String(); got != in {
+		t.Errorf("got %s, want %s", got, in)
+	}
+}
@@ -0,0 +1,839 @@
+// Copyright 2014 Gary Burd. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package json
+
+import (
+	"bytes"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// YAMLError describes an error in YAML input.
+type YAMLError struct {
+	Line int // line of the error, starting at one
+	Msg  string
+}
+
+func (e *YAMLError) Error() string {
+	return "yaml: line " + strconv.Itoa(e.Line) + ": " + e.Msg
+}
+
+// YAMLToJSON converts the YAML documents in src to JSON values written to w.
+// Each document is written as a top-level value followed by a call to
+// w.EndDocument, so a stream of documents is written as newline-delimited
+// JSON.
+//
+// YAMLToJSON supports a subset of YAML 1.2: block mappings and sequences,
+// flow mappings and sequences, plain, single-quoted and double-quoted
+// scalars, literal and folded block scalars, comments and document markers.
+// Plain scalars are resolved with the YAML 1.2 core schema. Anchors, aliases,
+// tags, complex mapping keys and directives are not supported. Infinite and
+// NaN floats are errors because JSON cannot represent them.
+func YAMLToJSON(w *Writer, src io.Reader) error {
+	var buf bytes.Buffer
+	if _, err := buf.ReadFrom(src); err != nil {
+		return err
+	}
+	text := strings.TrimPrefix(buf.String(), "\ufeff")
+	text = strings.Replace(text, "\r\n", "\n", -1)
+	lines := strings.Split(text, "\n")
+	if lines[len(lines)-1] == "" {
+		// Drop the empty string following the final line break.
+		lines = lines[:len(lines)-1]
+	}
+	p := yamlParser{w: w, lines: lines}
+	return p.stream()
+}
+
+type yamlParser struct {
+	w     *Writer
+	lines []string
+	i     int // current line
+	c     int // current column in flow collections
+	start int // line of the outermost open flow collection
+	depth int // nesting depth of collections
+}
+
+func (p *yamlParser) errorf(msg string) error {
+	return p.errorAt(p.i, msg)
+}
+
+func (p *yamlParser) errorAt(i int, msg string) error {
+	return &YAMLError{Line: i + 1, Msg: msg}
+}
+
+// yamlIndent returns the number of leading spaces in line.
+func yamlIndent(line string) int {
+	n := 0
+	for n < len(line) && line[n] == ' ' {
+		n++
+	}
+	return n
+}
+
+// yamlBlank returns true if s contains only whitespace and comments.
+func yamlBlank(s string) bool {
+	s = strings.TrimLeft(s, " \t")
+	return s == "" || s[0] == '#'
+}
+
+// yamlSeqEntry returns true if s starts with a block sequence entry.
+func yamlSeqEntry(s string) bool {
+	return len(s) > 0 && s[0] == '-' && (len(s) == 1 || s[1] == ' ' || s[1] == '\t')
+}
+
+// yamlMarker returns true if line is the document marker m.
+func yamlMarker(line, m string) bool {
+	return strings.HasPrefix(line, m) && (len(line) == 3 || line[3] == ' ' || line[3] == '\t')
+}
+
+func (p *yamlParser) atEnd() bool {
+	return p.i >= len(p.lines) || yamlMarker(p.lines[p.i], "---") || yamlMarker(p.lines[p.i], "...")
+}
+
+// indent returns the indentation of the current line. Tabs are not allowed
+// in block indentation.
+func (p *yamlParser) indent() (int, error) {
+	line := p.lines[p.i]
+	n := yamlIndent(line)
+	if n < len(line) && line[n] == '\t' {
+		return 0, p.errorf("tab in indentation")
+	}
+	return n, nil
+}
+
+// skipBlank advances past blank and comment lines.
+func (p *yamlParser) skipBlank() {
+	for p.i < len(p.lines) && yamlBlank(p.lines[p.i]) {
+		p.i++
+	}
+}
+
+func (p *yamlParser) stream() error {
+	explicit := false // if true, the document was started with ---
+	for {
+		p.skipBlank()
+		if p.i >= len(p.lines) {
+			if explicit {
+				return p.null()
+			}
+			return nil
+		}
+		line := p.lines[p.i]
+		switch {
+		case line[0] == '%':
+			return p.errorf("directives are not supported")
+		case yamlMarker(line, "..."):
+			if explicit {
+				if err := p.null(); err != nil {
+					return err
+				}
+			}
+			explicit = false
+			p.i++
+		case yamlMarker(line, "---"):
+			if explicit {
+				if err := p.null(); err != nil {
+					return err
+				}
+			}
+			explicit = true
+			if !yamlBlank(line[3:]) {
+				if err := p.node(3, -1, false); err != nil {
+					return err
+				}
+				if err := p.w.EndDocument(); err != nil {
+					return err
+				}
+				explicit = false
+			} else {
+				p.i++
+			}
+		default:
+			if err := p.value(yamlIndent(line), -1); err != nil {
+				return err
+			}
+			if err := p.w.EndDocument(); err != nil {
+				return err
+			}
+			explicit = false
+		}
+		p.skipBlank()
+		if !explicit && !p.atEnd() {
+			return p.errorf("unexpected content after document")
+		}
+	}
+}
+
+// null writes an empty document.
+func (p *yamlParser) null() error {
+	if err := p.w.Null(); err != nil {
+		return err
+	}
+	return p.w.EndDocument()
+}
+
+// node writes the node that starts at column col of the current line. If
+// the rest of the line is blank, then the node is on the following lines.
+// Parent is the indentation of the enclosing block collection. The node
+// consumes the lines through the end of the node. If mapValue is true, the
+// node is a mapping value and cannot be a mapping on the line of its key.
+func (p *yamlParser) node(col, parent int, mapValue bool) error {
+	line := p.lines[p.i]
+	if !yamlBlank(line[col:]) {
+		col += len(line[col:]) - len(strings.TrimLeft(line[col:], " \t"))
+		if mapValue {
+			if _, _, ok := p.mappingKey(col); ok {
+				return p.errorf("mapping values are not allowed here")
+			}
+		}
+		return p.value(col, parent)
+	}
+	p.i++
+	p.skipBlank()
+	if !p.atEnd() {
+		line := p.lines[p.i]
+		n, err := p.indent()
+		if err != nil {
+			return err
+		}
+		if n > parent || mapValue && n == parent && yamlSeqEntry(line[n:]) {
+			return p.value(n, parent)
+		}
+	}
+	return p.w.Null()
+}
+
+// value writes the node that starts at column col of the current line.
+func (p *yamlParser) value(col, parent int) error {
+	t := p.lines[p.i][col:]
+	switch t[0] {
+	case '-':
+		if yamlSeqEntry(t) {
+			return p.sequence(col)
+		}
+	case '[', '{':
+		p.c = col
+		p.start = p.i
+		if err := p.flowValue(); err != nil {
+			return err
+		}
+		if !yamlBlank(p.lines[p.i][p.c:]) {
+			return p.errorf("unexpected content after flow collection")
+		}
+		p.i++
+		return nil
+	case '|', '>':
+		return p.blockScalar(col, parent)
+	case '&', '*', '!':
+		return p.errorf("anchors, aliases and tags are not supported")
+	case '?':
+		if len(t) == 1 || t[1] == ' ' {
+			return p.errorf("complex mapping keys are not supported")
+		}
+	case '\t':
+		return p.errorf("tab in indentation")
+	}
+	if _, _, ok := p.mappingKey(col); ok {
+		return p.mapping(col)
+	}
+	if t[0] == '"' || t[0] == '\'' {
+		p.c = col
+		s, err := p.quoted()
+		if err != nil {
+			return err
+		}
+		if !yamlBlank(p.lines[p.i][p.c:]) {
+			return p.errorf("unexpected content after quoted scalar")
+		}
+		p.i++
+		return p.w.String(s)
+	}
+	return p.plain(col, parent)
+}
+
+func (p *yamlParser) enter() error {
+	p.depth++
+	if p.depth > DefaultMaxDepth {
+		return p.errorf("nesting depth exceeds limit")
+	}
+	return nil
+}
+
+func (p *yamlParser) sequence(indent int) error {
+	if err := p.enter(); err != nil {
+		return err
+	}
+	if err := p.w.StartArray(); err != nil {
+		return err
+	}
+	for {
+		if err := p.node(indent+1, indent, false); err != nil {
+			return err
+		}
+		p.skipBlank()
+		if p.atEnd() {
+			break
+		}
+		line := p.lines[p.i]
+		n, err := p.indent()
+		if err != nil {
+			return err
+		}
+		if n < indent || n == indent && !yamlSeqEntry(line[n:]) {
+			break
+		}
+		if n > indent {
+			return p.errorf("unexpected indentation")
+		}
+	}
+	p.depth--
+	return p.w.EndArray()
+}
+
+func (p *yamlParser) mapping(indent int) error {
+	if err := p.enter(); err != nil {
+		return err
+	}
+	if err := p.w.StartObject(); err != nil {
+		return err
+	}
+	for {
+		key, col, ok := p.mappingKey(indent)
+		if !ok {
+			return p.errorf("expected mapping key")
+		}
+		if err := p.w.Name(key); err != nil {
+			return err
+		}
+		if err := p.node(col, indent, true); err != nil {
+			return err
+		}
+		p.skipBlank()
+		if p.atEnd() {
+			break
+		}
+		n, err := p.indent()
+		if err != nil {
+			return err
+		}
+		if n < indent {
+			break
+		}
+		if n > indent {
+			return p.errorf("unexpected indentation")
+		}
+	}
+	p.depth--
+	return p.w.EndObject()
+}
+
+// mappingKey parses the mapping key at column col of the current line. The
+// function returns the key and the column following the ':' indicator.
+func (p *yamlParser) mappingKey(col int) (key string, end int, ok bool) {
+	line := p.lines[p.i]
+	t := line[col:]
+	if t[0] == '"' || t[0] == '\'' {
+		i, c := p.i, p.c
+		p.c = col
+		key, err := p.quoted()
+		end := p.c
+		sameLine := p.i == i
+		p.i, p.c = i, c
+		if err != nil || !sameLine {
+			return "", 0, false
+		}
+		for end < len(line) && line[end] == ' ' {
+			end++
+		}
+		if end < len(line) && line[end] == ':' && (end+1 == len(line) || line[end+1] == ' ' || line[end+1] == '\t') {
+			return key, end + 1, true
+		}
+		return "", 0, false
+	}
+	switch t[0] {
+	case '[', '{', '#', '|', '>', '&', '*', '!':
+		return "", 0, false
+	}
+	for i := 0; i < len(t); i++ {
+		switch t[i] {
+		case ':':
+			if i+1 == len(t) || t[i+1] == ' ' || t[i+1] == '\t' {
+				return strings.TrimRight(t[:i], " \t"), col + i + 1, true
+			}
+		case '#':
+			if i > 0 && (t[i-1] == ' ' || t[i-1] == '\t') {
+				return "", 0, false
+			}
+		}
+	}
+	return "", 0, false
+}
+
+// plain writes the plain scalar at column col. The scalar continues on
+// following lines that are indented more than parent.
+func (p *yamlParser) plain(col, parent int) error {
+	start := p.i
+	s := yamlStripComment(p.lines[p.i][col:])
+	p.i++
+	empty := 0
+	for j := p.i; j < len(p.lines); j++ {
+		line := p.lines[j]
+		if strings.TrimLeft(line, " \t") == "" {
+			empty++
+			continue
+		}
+		if yamlIndent(line) <= parent || yamlMarker(line, "---") || yamlMarker(line, "...") || yamlBlank(line) {
+			break
+		}
+		if empty == 0 {
+			s += " "
+		} else {
+			s += strings.Repeat("\n", empty)
+		}
+		t := yamlStripComment(strings.TrimLeft(line, " \t"))
+		if strings.Contains(t, ": ") || strings.HasSuffix(t, ":") {
+			return p.errorAt(j, "mapping values are not allowed here")
+		}
+		s += t
+		empty = 0
+		p.i = j + 1
+	}
+	end := p.i
+	p.i = start
+	err := p.scalar(s)
+	p.i = end
+	return err
+}
+
+// yamlStripComment removes a trailing comment and whitespace from s.
+func yamlStripComment(s string) string {
+	for i := 1; i < len(s); i++ {
+		if s[i] == '#' && (s[i-1] == ' ' || s[i-1] == '\t') {
+			s = s[:i]
+			break
+		}
+	}
+	return strings.TrimRight(s, " \t")
+}
+
+// scalar writes the plain scalar s resolved with the YAML 1.2 core schema.
+func (p *yamlParser) scalar(s string) error {
+	switch s {
+	case "", "~", "null", "Null", "NULL":
+		return p.w.Null()
+	case "true", "True", "TRUE":
+		return p.w.Bool(true)
+	case "false", "False", "FALSE":
+		return p.w.Bool(false)
+	case ".inf", ".Inf", ".INF", "+.inf", "+.Inf", "+.INF", "-.inf", "-.Inf", "-.INF", ".nan", ".NaN", ".NAN":
+		return p.errorf("cannot represent " + s + " in JSON")
+	}
+	switch {
+	case strings.HasPrefix(s, "0x"):
+		if u, err := strconv.ParseUint(s[2:], 16, 64); err == nil {
+			return p.w.Uint(u)
+		}
+	case strings.HasPrefix(s, "0o"):
+		if u, err := strconv.ParseUint(s[2:], 8, 64); err == nil {
+			return p.w.Uint(u)
+		}
+	case yamlNumber(s):
+		n := strings.TrimPrefix(s, "+")
+		if isValidNumber(n) {
+			return p.w.Number(NumberValue(n))
+		}
+		f, err := strconv.ParseFloat(n, 64)
+		if err != nil || math.IsInf(f, 0) {
+			return p.errorf("cannot represent " + s + " in JSON")
+		}
+		return p.w.Float(f)
+	}
+	return p.w.String(s)
+}
+
+// yamlNumber returns true if s matches the core schema int or float.
+func yamlNumber(s string) bool {
+	i := 0
+	if i < len(s) && (s[i] == '-' || s[i] == '+') {
+		i++
+	}
+	digits := 0
+	for i < len(s) && isDecimalDigit(s[i]) {
+		i++
+		digits++
+	}
+	if i < len(s) && s[i] == '.' {
+		i++
+		for i < len(s) && isDecimalDigit(s[i]) {
+			i++
+			digits++
+		}
+	}
+	if digits == 0 {
+		return false
+	}
+	if i < len(s) && (s[i] == 'e' || s[i] == 'E') {
+		i++
+		if i < len(s) && (s[i] == '-' || s[i] == '+') {
+			i++
+		}
+		if i == len(s) || !isDecimalDigit(s[i]) {
+			return false
+		}
+		for i < len(s) && isDecimalDigit(s[i]) {
+			i++
+		}
+	}
+	return i == len(s)
+}
+
+// quoted parses the single-quoted or double-quoted scalar at p.c of the
+// current line. Line breaks in the scalar are folded.
+func (p *yamlParser) quoted() (string, error) {
+	line := p.lines[p.i]
+	q := line[p.c]
+	start := p.i
+	var b []byte
+	keep := 0 // length of b that is not trimmed at a line break
+	i := p.c + 1
+	for {
+		if i >= len(line) {
+			// Fold the line break.
+			for len(b) > keep && (b[len(b)-1] == ' ' || b[len(b)-1] == '\t') {
+				b = b[:len(b)-1]
+			}
+			empty := 0
+			for {
+				p.i++
+				if p.i >= len(p.lines) {
+					return "", p.errorAt(start, "unterminated quoted scalar")
+				}
+				line = p.lines[p.i]
+				if strings.TrimLeft(line, " \t") != "" {
+					break
+				}
+				empty++
+			}
+			if empty == 0 {
+				b = append(b, ' ')
+			}
+			for ; empty > 0; empty-- {
+				b = append(b, '\n')
+			}
+			i = len(line) - len(strings.TrimLeft(line, " \t"))
+			keep = len(b)
+			continue
+		}
+		c := line[i]
+		switch {
+		case c == q && q == '\'' && i+1 < len(line) && line[i+1] == '\'':
+			b = append(b, '\'')
+			i += 2
+		case c == q:
+			p.c = i + 1
+			return string(b), nil
+		case c == '\\' && q == '"':
+			if i+1 == len(line) {
+				// Escaped line break.
+				p.i++
+				if p.i >= len(p.lines) {
+					return "", p.errorAt(start, "unterminated quoted scalar")
+				}
+				line = p.lines[p.i]
+				i = len(line) - len(strings.TrimLeft(line, " \t"))
+				keep = len(b)
+				continue
+			}
+			var err error
+			b, i, err = p.escape(b, line, i+1)
+			if err != nil {
+				return "", err
+			}
+			keep = len(b)
+		default:
+			b = append(b, c)
+			i++
+		}
+	}
+}
+
+// escape appends the escape sequence at line[i] to b.
+func (p *yamlParser) escape(b []byte, line string, i int) ([]byte, int, error) {
+	c := line[i]
+	if r, ok := yamlEscapes[c]; ok {
+		return append(b, r...), i + 1, nil
+	}
+	n := 0
+	switch c {
+	case 'x':
+		n = 2
+	case 'u':
+		n = 4
+	case 'U':
+		n = 8
+	default:
+		return nil, 0, p.errorf("invalid escape " + strconv.Quote(line[i-1:i+1]))
+	}
+	if i+1+n > len(line) {
+		return nil, 0, p.errorf("invalid escape")
+	}
+	u, err := strconv.ParseUint(line[i+1:i+1+n], 16, 32)
+	if err != nil || u > utf8.MaxRune {
+		return nil, 0, p.errorf("invalid escape " + strconv.Quote(line[i-1:i+1+n]))
+	}
+	var buf [utf8.UTFMax]byte
+	return append(b, buf[:utf8.EncodeRune(buf[:], rune(u))]...), i + 1 + n, nil
+}
+
+var yamlEscapes = map[byte]string{
+	'0': "\x00", 'a': "\a", 'b': "\b", 't': "\t", '\t': "\t", 'n': "\n",
+	'v': "\v", 'f': "\f", 'r': "\r", 'e': "\x1b", ' ': " ", '"': "\"",
+	'/': "/", '\\': "\\", 'N': "\u0085", '_': "\u00a0", 'L': "\u2028",
+	'P': "\u2029",
+}
+
+// blockScalar writes the literal or folded block scalar with the header at
+// column col of the current line.
+func (p *yamlParser) blockScalar(col, parent int) error {
+	header := yamlStripComment(p.lines[p.i][col:])
+	folded := header[0] == '>'
+	chomp := byte(0)
+	indent := 0
+	for _, c := range []byte(header[1:]) {
+		switch {
+		case (c == '-' || c == '+') && chomp == 0:
+			chomp = c
+		case c >= '1' && c <= '9' && indent == 0:
+			indent = int(c - '0')
+		default:
+			return p.errorf("invalid block scalar header")
+		}
+	}
+	top := parent < 0
+	if indent > 0 && !top {
+		indent += parent
+	}
+	p.i++
+
+	// Collect the content lines.
+	var lines []string
+	for ; p.i < len(p.lines); p.i++ {
+		line := p.lines[p.i]
+		n := yamlIndent(line)
+		if n == len(line) {
+			if indent > 0 && n > indent {
+				lines = append(lines, line[indent:])
+			} else {
+				lines = append(lines, "")
+			}
+			continue
+		}
+		if indent == 0 {
+			if !top && n <= parent || yamlMarker(line, "---") || yamlMarker(line, "...") {
+				break
+			}
+			indent = n
+		}
+		if n < indent {
+			break
+		}
+		lines = append(lines, line[indent:])
+	}
+
+	// Separate the trailing empty lines for chomping.
+	trailing := 0
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+		trailing++
+	}
+
+	var b strings.Builder
+	if folded {
+		normal := false // if true, the previous line is not more indented
+		empty := 0
+		for _, line := range lines {
+			if line == "" {
+				empty++
+				continue
+			}
+			more := line[0] == ' ' || line[0] == '\t'
+			switch {
+			case b.Len() == 0:
+				b.WriteString(strings.Repeat("\n", empty))
+			case normal && !more && empty == 0:
+				b.WriteByte(' ')
+			case normal && !more:
+				b.WriteString(strings.Repeat("\n", empty))
+			default:
+				b.WriteString(strings.Repeat("\n", empty+1))
+			}
+			b.WriteString(line)
+			normal = !more
+			empty = 0
+		}
+	} else {
+		b.WriteString(strings.Join(lines, "\n"))
+	}
+
+	switch {
+	case chomp == '-':
+	case chomp == '+':
+		if len(lines) > 0 {
+			trailing++
+		}
+		b.WriteString(strings.Repeat("\n", trailing))
+	case len(lines) > 0:
+		b.WriteByte('\n')
+	}
+	return p.w.String(b.String())
+}
+
+// flowSkip advances p.i and p.c past whitespace, line breaks and comments in
+// a flow collection.
+func (p *yamlParser) flowSkip() error {
+	for p.i < len(p.lines) {
+		line := p.lines[p.i]
+		for p.c < len(line) && (line[p.c] == ' ' || line[p.c] == '\t') {
+			p.c++
+		}
+		if p.c < len(line) && line[p.c] != '#' {
+			return nil
+		}
+		p.i++
+		p.c = 0
+	}
+	return p.errorAt(p.start, "unterminated flow collection")
+}
+
+// flowValue writes the flow node at p.c of the current line.
+func (p *yamlParser) flowValue() error {
+	line := p.lines[p.i]
+	switch line[p.c] {
+	case '[':
+		return p.flowCollection(false)
+	case '{':
+		return p.flowCollection(true)
+	case '"', '\'':
+		s, err := p.quoted()
+		if err != nil {
+			return err
+		}
+		return p.w.String(s)
+	case '&', '*', '!':
+		return p.errorf("anchors, aliases and tags are not supported")
+	case ',', ']', '}':
+		return p.w.Null()
+	}
+	return p.scalar(p.flowPlain(false))
+}
+
+// flowPlain returns the plain scalar at p.c in a flow collection.
+func (p *yamlParser) flowPlain(key bool) string {
+	line := p.lines[p.i]
+	i := p.c
+loop:
+	for ; i < len(line); i++ {
+		switch line[i] {
+		case ',', '[', ']', '{', '}':
+			break loop
+		case ':':
+			if i+1 == len(line) || strings.IndexByte(" \t,[]{}", line[i+1]) >= 0 || key {
+				break loop
+			}
+		case '#':
+			if i > p.c && (line[i-1] == ' ' || line[i-1] == '\t') {
+				break loop
+			}
+		}
+	}
+	s := strings.TrimRight(line[p.c:i], " \t")
+	p.c = i
+	return s
+}
+
+func (p *yamlParser) flowCollection(object bool) error {
+	if err := p.enter(); err != nil {
+		return err
+	}
+	end := byte(']')
+	var err error
+	if object {
+		end = '}'
+		err = p.w.StartObject()
+	} else {
+		err = p.w.StartArray()
+	}
+	if err != nil {
+		return err
+	}
+	p.c++
+	for {
+		if err := p.flowSkip(); err != nil {
+			return err
+		}
+		if p.lines[p.i][p.c] == end {
+			break
+		}
+		if object {
+			if err := p.flowKey(); err != nil {
+				return err
+			}
+		} else if err := p.flowValue(); err != nil {
+			return err
+		}
+		if err := p.flowSkip(); err != nil {
+			return err
+		}
+		switch c := p.lines[p.i][p.c]; {
+		case c == ',':
+			p.c++
+		case c == end:
+		case c == ':' && !object:
+			return p.errorf("mappings in flow sequences are not supported")
+		default:
+			return p.errorf("expected , or " + string(end) + " in flow collection")
+		}
+	}
+	p.c++
+	p.depth--
+	if object {
+		return p.w.EndObject()
+	}
+	return p.w.EndArray()
+}
+
+// flowKey writes a member of a flow mapping.
+func (p *yamlParser) flowKey() error {
+	var key string
+	switch p.lines[p.i][p.c] {
+	case '"', '\'':
+		var err error
+		if key, err = p.quoted(); err != nil {
+			return err
+		}
+	case '[', '{', '&', '*', '!', '?':
+		return p.errorf("unsupported flow mapping key")
+	default:
+		key = p.flowPlain(true)
+	}
+	if err := p.w.Name(key); err != nil {
+		return err
+	}
+	if err := p.flowSkip(); err != nil {
+		return err
+	}
+	if p.lines[p.i][p.c] != ':' {
+		return p.w.Null()
+	}
+	p.c++
+	if err := p.flowSkip(); err != nil {
+		return err
+	}
+	return p.flowValue()
+}
@@ -0,0 +1,117 @@
+// Copyright 2014 Gary Burd. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package json
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+var yamlTests = []struct {
+	yaml string
+	json string
+}{
+	{"", ""},
+	{"# comment\n", ""},
+	{"a", `"a"`},
+	{"---\n", `null`},
+	{"--- 1\n", `1`},
+	{"~", `null`},
+	{"null", `null`},
+	{"True", `true`},
+	{"false", `false`},
+	{"12", `12`},
+	{"-007", `-7`},
+	{"+12", `12`},
+	{"0x1F", `31`},
+	{"0o17", `15`},
+	{"1.5", `1.5`},
+	{"1.", `1`},
+	{".5", `0.5`},
+	{"1e3", `1e3`},
+	{"1_000", `"1_000"`},
+	{"yes", `"yes"`},
+	{"http://example.com/a#b", `"http://example.com/a#b"`},
+	{"a # comment", `"a"`},
+	{"a b\n  c\n\n  d", `"a b c\nd"`},
+	{`"a\tb\u00e9\x41\"" # comment`, `"a\tbéA\""`},
+	{"'it''s'", `"it's"`},
+	{"\"a\n  b\n\n  c\\\n  d\"", `"a b\ncd"`},
+	{"a: 1\nb: two\nc:\nd: [1, 2]", `{"a":1,"b":"two","c":null,"d":[1,2]}`},
+	{"a:\n  b:\n    c: 1\n  d: 2\ne: 3", `{"a":{"b":{"c":1},"d":2},"e":3}`},
+	{"'a b': 1\n\"c\": 2", `{"a b":1,"c":2}`},
+	{"- 1\n- two\n-\n- - 3\n  - 4\n- a: 5\n  b: 6", `[1,"two",null,[3,4],{"a":5,"b":6}]`},
+	{"a:\n- 1\n- 2\nb: 3", `{"a":[1,2],"b":3}`},
+	{"a:\n  - 1\n  # comment\n  - 2", `{"a":[1,2]}`},
+	{"[a, 'b', \"c\", [], {}, {x: 1, y}]", `["a","b","c",[],{},{"x":1,"y":null}]`},
+	{"{a: [1,\n  2], # comment\n  b: {c: d},\n}", `{"a":[1,2],"b":{"c":"d"}}`},
+	{"a: |\n  x\n   y\n\n  z\nb: 1", `{"a":"x\n y\n\nz\n","b":1}`},
+	{"a: |-\n  x\n\n", `{"a":"x"}`},
+	{"a: |+\n  x\n\n", `{"a":"x\n\n"}`},
+	{"a: >\n  x\n  y\n\n  z\n    w\n  v\n", `{"a":"x y\nz\n  w\nv\n"}`},
+	{"- |2\n   x\n  y", `[" x\ny\n"]`},
+	{"a: |\nb: 1", `{"a":"","b":1}`},
+	{"- 1\n---\n- 2\n...\n", "[1]\n[2]"},
+	{"---\n---\na", "null\n\"a\""},
+	{"1\n---\n2\n", "1\n2"},
+	{"---\n---", "null\nnull"},
+	{"\ufeffa: 1\r\n", `{"a":1}`},
+}
+
+func TestYAMLToJSON(t *testing.T) {
+	for _, tt := range yamlTests {
+		var buf bytes.Buffer
+		if err := YAMLToJSON(NewWriter(&buf), strings.NewReader(tt.yaml)); err != nil {
+			t.Errorf("%q: unexpected error %v", tt.yaml, err)
+			continue
+		}
+		if got := strings.TrimSuffix(buf.String(), "\n"); got != tt.json {
+			t.Errorf("%q:\n got %s\nwant %s", tt.yaml, got, tt.json)
+		}
+	}
+}
+
+var yamlErrorTests = []struct {
+	yaml string
+	line int
+}{
+	{"%YAML 1.2\n---\na", 1},
+	{"a: &x 1", 1},
+	{"a: *x", 1},
+	{"a: !!str 1", 1},
+	{"? a\n: b", 1},
+	{"a: 1\n  b: 2", 2},
+	{"a: 1\nb", 2},
+	{"a:\n  - 1\n - 2", 3},
+	{"a: .inf", 1},
+	{"a: [1, 2", 1},
+	{"[a: 1]", 1},
+	{"a: \"x", 1},
+	{`"\q"`, 1},
+	{"a: [1] x", 1},
+	{"\ta: 1", 1},
+	{"a:\n\tb: 1\n", 2},
+	{"a: 1\n\tb: 2", 2},
+	{"- 1\n\t- 2", 2},
+	{"a: b: c", 1},
+	{"a:\n  b: c: d", 2},
+	{"a: 1\n---\n- x\nb", 4},
+}
+
+func TestYAMLToJSONError(t *testing.T) {
+	for _, tt := range yamlErrorTests {
+		var buf bytes.Buffer
+		err := YAMLToJSON(NewWriter(&buf), strings.NewReader(tt.yaml))
+		e, ok := err.(*YAMLError)
+		if !ok {
+			t.Errorf("%q: got error %v, want YAMLError", tt.yaml, err)
+			continue
+		}
+		if e.Line != tt.line {
+			t.Errorf("%q: got line %d, want %d (%v)", tt.yaml, e.Line, tt.line, e)
+		}
+	}
+}